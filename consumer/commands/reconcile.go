@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"consumer/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const reconcileUsage = "usage: reconcile [-adjust] [-report file]"
+
+// Reconcile runs `consumer reconcile`, writing the report as JSON to the
+// report file, or to w without one. It fails when discrepancies remain
+// unadjusted, so a scheduled run can alert on its exit status.
+func Reconcile(ctx context.Context, reconciliationService services.IReconciliationService, args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	adjust := flags.Bool("adjust", false, "emit an AdjustBalanceEvent for every balance mismatch")
+	reportFile := flags.String("report", "", "file to write the report to instead of stdout")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 {
+		return errors.New(reconcileUsage)
+	}
+
+	report, err := reconciliationService.Reconcile(ctx, *adjust)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *reportFile != "" {
+		err = os.WriteFile(*reportFile, data, 0644)
+	} else {
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	unadjusted := 0
+	for _, discrepancy := range report.Discrepancies {
+		if !discrepancy.Adjusted {
+			unadjusted++
+		}
+	}
+	if unadjusted > 0 {
+		return fmt.Errorf("%d discrepancies left unadjusted", unadjusted)
+	}
+	return nil
+}
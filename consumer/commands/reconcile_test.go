@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"bytes"
+	"consumer/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReconciliationService struct {
+	report services.ReconciliationReport
+	err    error
+	adjust bool
+}
+
+func (obj *fakeReconciliationService) Reconcile(ctx context.Context, adjust bool) (services.ReconciliationReport, error) {
+	obj.adjust = adjust
+	if adjust {
+		for i := range obj.report.Discrepancies {
+			obj.report.Discrepancies[i].Adjusted = true
+		}
+	}
+	return obj.report, obj.err
+}
+
+func Test_Reconcile(t *testing.T) {
+	report := func(discrepancies ...services.Discrepancy) services.ReconciliationReport {
+		return services.ReconciliationReport{
+			GeneratedAt:   time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			Messages:      3,
+			Accounts:      1,
+			Skipped:       []services.SkippedMessage{},
+			Discrepancies: append([]services.Discrepancy{}, discrepancies...),
+		}
+	}
+	mismatch := services.Discrepancy{AccountID: "123", Kind: services.DiscrepancyBalance, Difference: 500}
+
+	tests := []struct {
+		name       string
+		args       []string
+		mockReport services.ReconciliationReport
+		mockErr    error
+
+		wantAdjust bool
+		wantReport services.ReconciliationReport
+		wantError  string
+	}{
+		{
+			name:       "Test should write the report and succeed without discrepancies",
+			mockReport: report(),
+			wantReport: report(),
+		},
+		{
+			name:       "Test should fail when discrepancies are left unadjusted",
+			mockReport: report(mismatch),
+			wantReport: report(mismatch),
+			wantError:  "1 discrepancies left unadjusted",
+		},
+		{
+			name:       "Test should succeed once discrepancies are adjusted",
+			args:       []string{"-adjust"},
+			mockReport: report(mismatch),
+			wantAdjust: true,
+			wantReport: report(services.Discrepancy{AccountID: "123", Kind: services.DiscrepancyBalance, Difference: 500, Adjusted: true}),
+		},
+		{
+			name:      "Test should return error when reconciliation fails",
+			mockErr:   errors.New("error"),
+			wantError: "error",
+		},
+		{
+			name:      "Test should reject unknown arguments",
+			args:      []string{"now"},
+			wantError: reconcileUsage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reconciliationService := &fakeReconciliationService{report: test.mockReport, err: test.mockErr}
+			var output bytes.Buffer
+
+			err := Reconcile(context.Background(), reconciliationService, test.args, &output)
+
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.wantAdjust, reconciliationService.adjust)
+			if test.mockErr == nil && test.wantError != reconcileUsage {
+				var report services.ReconciliationReport
+				assert.NoError(t, json.Unmarshal(output.Bytes(), &report))
+				assert.Equal(t, test.wantReport, report)
+			}
+		})
+	}
+
+	t.Run("Test should write the report to a file", func(t *testing.T) {
+		reportFile := filepath.Join(t.TempDir(), "report.json")
+		var output bytes.Buffer
+
+		err := Reconcile(context.Background(), &fakeReconciliationService{report: report()}, []string{"-report", reportFile}, &output)
+
+		assert.NoError(t, err)
+		assert.Empty(t, output.String())
+		data, err := os.ReadFile(reportFile)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"discrepancies": []`)
+	})
+}
@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"consumer/services"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"time"
+)
+
+const statementUsage = "usage: statement -account id [-month 2006-01 | -from date [-to date]] [-format csv|json|text]"
+
+// Statement runs `consumer statement`, writing an account's statement for
+// a period to w.
+func Statement(ctx context.Context, statementService services.IStatementService, args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("statement", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	account := flags.String("account", "", "account ID")
+	month := flags.String("month", "", "month of the statement, e.g. 2024-03")
+	from := flags.String("from", "", "start of the period, an RFC 3339 time or a date")
+	to := flags.String("to", "", "end of the period, an RFC 3339 time or a date")
+	format := flags.String("format", "text", "csv, json or text")
+	err := flags.Parse(args)
+	if err != nil || *account == "" || flags.NArg() > 0 {
+		return errors.New(statementUsage)
+	}
+
+	start, end, err := services.ParsePeriod(*month, *from, *to, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, ok := services.StatementContentTypes[*format]; !ok {
+		return errors.New(statementUsage)
+	}
+
+	statement, err := statementService.Statement(ctx, *account, start, end)
+	if err != nil {
+		return err
+	}
+	return services.WriteStatement(w, statement, *format)
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"bytes"
+	"consumer/internal"
+	"consumer/repositories"
+	"consumer/services"
+	"context"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Statement(t *testing.T) {
+	db := internal.OpenSQLiteDB("statement_command")
+	historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+	eventService := services.NewHistoryEventService(services.NewEventService(repositories.NewAccountRepository(db, repositories.DefaultTable), logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard())
+	ctx := services.ContextWithPosition(context.Background(), services.Position{Timestamp: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)})
+	assert.NoError(t, eventService.Handle(ctx, "OpenAccountEvent", []byte(`{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`)))
+	statementService := services.NewStatementService(historyRepo)
+
+	tests := []struct {
+		name string
+		args []string
+
+		wantOutput string
+		wantError  string
+	}{
+		{
+			name: "Test should write the statement of a month",
+			args: []string{"-account", "123", "-month", "2024-03", "-format", "csv"},
+			wantOutput: "date,type,amount,balance\n" +
+				"2024-03-01T00:00:00Z,opening balance,,0.00\n" +
+				"2024-03-01T09:00:00Z,open,1000.00,1000.00\n" +
+				"2024-03-31T23:59:59Z,closing balance,,1000.00\n",
+		},
+		{
+			name:      "Test should require an account",
+			args:      []string{"-month", "2024-03"},
+			wantError: statementUsage,
+		},
+		{
+			name:      "Test should reject an unknown format",
+			args:      []string{"-account", "123", "-format", "pdf"},
+			wantError: statementUsage,
+		},
+		{
+			name:      "Test should reject an invalid period",
+			args:      []string{"-account", "123", "-from", "yesterday"},
+			wantError: `from "yesterday" is neither an RFC 3339 time nor a date`,
+		},
+		{
+			name:      "Test should fail for an account without history",
+			args:      []string{"-account", "456", "-month", "2024-03"},
+			wantError: services.ErrNoHistory.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := Statement(context.Background(), statementService, test.args, &output)
+
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantOutput, output.String())
+		})
+	}
+}
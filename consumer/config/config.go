@@ -0,0 +1,189 @@
+package config
+
+import (
+	"consumer/repositories"
+	"platform/config"
+	"platform/database"
+	"platform/kafka"
+	"time"
+)
+
+type Config struct {
+	Profile string
+
+	Kafka struct {
+		kafka.Config `mapstructure:",squash"`
+		Group        string
+	}
+
+	DB struct {
+		database.Config `mapstructure:",squash"`
+		// Table holds the account read model.
+		Table string
+	}
+
+	// Workers is how many messages of a partition are handled at once, in
+	// order per account.
+	Workers int
+
+	// Batch applies up to Size messages of a partition, or those that
+	// arrive within Timeout of the first, in one transaction. 0 handles
+	// messages one by one on the workers.
+	Batch struct {
+		Size    int
+		Timeout time.Duration
+	}
+
+	RateLimit struct {
+		// MessagesPerSecond caps the messages handed to the handler across
+		// every partition; 0 disables the limit.
+		MessagesPerSecond float64 `mapstructure:"messagesPerSecond"`
+		// Burst is how many messages may go through at once after a quiet
+		// spell.
+		Burst int
+	} `mapstructure:"rateLimit"`
+
+	AutoPause struct {
+		// OnDBFailure pauses every partition while the db health check
+		// fails, and resumes them once it passes again.
+		OnDBFailure bool `mapstructure:"onDBFailure"`
+		// Interval is how often the db health check runs.
+		Interval time.Duration
+	} `mapstructure:"autoPause"`
+
+	// Retry handles a message that fails up to Attempts times, waiting
+	// Backoff before the first retry and twice as long before each next
+	// one, then records it in <table>_failed_messages.
+	Retry struct {
+		Attempts int
+		Backoff  time.Duration
+	}
+
+	History struct {
+		// SnapshotEvery is how many events an account gets between
+		// snapshots of its history; 0 disables snapshots.
+		SnapshotEvery int `mapstructure:"snapshotEvery"`
+	}
+
+	Capture struct {
+		File string
+	}
+
+	SchemaRegistry struct {
+		URL  string
+		File string
+	} `mapstructure:"schemaRegistry"`
+
+	Tracing struct {
+		Exporter string
+		Endpoint string
+	}
+
+	Log struct {
+		Level  string
+		Format string
+	}
+
+	Shutdown struct {
+		// Timeout bounds draining and closing clients after a signal.
+		Timeout time.Duration
+	}
+
+	Metrics struct {
+		Address string
+	}
+
+	Producer struct {
+		// URL is the base URL of the producer API, which reconciliation
+		// sends its balance adjustments to.
+		URL string
+	}
+
+	// Admin serves the account API and the consumer controls, apart from
+	// the metrics, so whoever can scrape them cannot pause the consumer.
+	Admin struct {
+		Address string
+	}
+}
+
+var defaults = map[string]interface{}{
+	"kafka.clientId":              "consumer",
+	"kafka.group":                 "accountConsumer",
+	"db.table":                    repositories.DefaultTable,
+	"workers":                     1,
+	"batch.size":                  0,
+	"batch.timeout":               "100ms",
+	"rateLimit.messagesPerSecond": 0,
+	"rateLimit.burst":             1,
+	"autoPause.onDBFailure":       true,
+	"autoPause.interval":          "5s",
+	"retry.attempts":              3,
+	"retry.backoff":               "100ms",
+	"history.snapshotEvery":       100,
+	"capture.file":                "",
+	"schemaRegistry.url":          "",
+	"schemaRegistry.file":         "",
+	"tracing.exporter":            "none",
+	"tracing.endpoint":            "localhost:4318",
+	"log.level":                   "info",
+	"log.format":                  "json",
+	"shutdown.timeout":            "30s",
+	"metrics.address":             ":9100",
+	"admin.address":               "localhost:9101",
+	"producer.url":                "http://localhost:8000",
+}
+
+// Load reads the consumer config for profile from dir, resolves secret
+// files and validates it.
+func Load(dir string, profile string) (Config, error) {
+	obj := Config{}
+	err := config.Load(config.Options{Dir: dir, Profile: profile, Defaults: config.MergeDefaults(kafka.Defaults, database.Defaults, defaults)}, &obj)
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = obj.Kafka.ResolveSecrets()
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = obj.DB.ResolveSecrets()
+	if err != nil {
+		return Config{}, err
+	}
+	return obj, obj.Validate()
+}
+
+// Validate reports every invalid field at once.
+func (obj Config) Validate() error {
+	errs := config.ValidationError{}
+
+	obj.Kafka.Validate(&errs)
+	errs.Check(obj.Kafka.Group != "", "kafka.group", "is required")
+	obj.DB.Validate(&errs)
+	errs.Check(obj.DB.Table != "", "db.table", "is required")
+	errs.Check(obj.Workers > 0, "workers", "must be positive")
+	errs.Check(obj.Batch.Size >= 0, "batch.size", "must not be negative")
+	if obj.Batch.Size > 0 {
+		errs.Check(obj.Batch.Timeout > 0, "batch.timeout", "must be positive in batch mode")
+	}
+	errs.Check(obj.RateLimit.MessagesPerSecond >= 0, "rateLimit.messagesPerSecond", "must not be negative")
+	errs.Check(obj.RateLimit.Burst > 0, "rateLimit.burst", "must be positive")
+	errs.Check(obj.AutoPause.Interval > 0, "autoPause.interval", "must be positive")
+	errs.Check(obj.Retry.Attempts > 0, "retry.attempts", "must be positive")
+	errs.Check(obj.Retry.Backoff >= 0, "retry.backoff", "must not be negative")
+	errs.Check(obj.History.SnapshotEvery >= 0, "history.snapshotEvery", "must not be negative")
+	errs.OneOf("tracing.exporter", obj.Tracing.Exporter, "otlp", "stdout", "none")
+	if obj.Tracing.Exporter == "otlp" {
+		errs.Check(obj.Tracing.Endpoint != "", "tracing.endpoint", "is required for the otlp exporter")
+	}
+	errs.OneOf("log.level", obj.Log.Level, "debug", "info", "warn", "error")
+	errs.OneOf("log.format", obj.Log.Format, "json", "text")
+	errs.Check(obj.Shutdown.Timeout > 0, "shutdown.timeout", "must be positive")
+	errs.Check(obj.Metrics.Address != "", "metrics.address", "is required")
+	errs.Check(obj.Admin.Address != "", "admin.address", "is required")
+	errs.Check(obj.Admin.Address != obj.Metrics.Address, "admin.address", "must differ from metrics.address")
+	errs.Check(obj.Producer.URL != "", "producer.url", "is required")
+
+	return errs.Err()
+}
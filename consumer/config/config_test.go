@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Load(t *testing.T) {
+	for _, profile := range []string{"local", "test"} {
+		t.Run("Test should load committed config for "+profile+" profile", func(t *testing.T) {
+			config, err := Load("..", profile)
+
+			assert.NoError(t, err)
+			assert.Equal(t, profile, config.Profile)
+			assert.Equal(t, "accountConsumer", config.Kafka.Group)
+			assert.Equal(t, "P@ssw0rd", config.DB.Password)
+			assert.Equal(t, "localhost:9101", config.Admin.Address)
+			assert.Equal(t, "http://localhost:8000", config.Producer.URL)
+		})
+	}
+
+	t.Run("Test should read db password from file for prod profile", func(t *testing.T) {
+		passwordFile := filepath.Join(t.TempDir(), "db_password")
+		assert.NoError(t, os.WriteFile(passwordFile, []byte("s3cret\n"), 0600))
+		t.Setenv("DB_PASSWORDFILE", passwordFile)
+		t.Setenv("DB_HOST", "db.internal")
+
+		config, err := Load("..", "prod")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cret", config.DB.Password)
+		assert.Equal(t, "db.internal", config.DB.Host)
+		assert.Equal(t, "otlp", config.Tracing.Exporter)
+	})
+
+	t.Run("Test should return error when password file is missing", func(t *testing.T) {
+		t.Setenv("DB_PASSWORDFILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := Load("..", "local")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("Test should use in-memory sqlite for test profile", func(t *testing.T) {
+		config, err := Load("..", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", config.DB.Driver)
+		assert.Equal(t, "file:consumer_test?mode=memory&cache=shared", config.DB.DSN())
+		assert.Equal(t, "bond_banks", config.DB.Table)
+	})
+
+	t.Run("Test should list every invalid field", func(t *testing.T) {
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+kafka:
+  group: ""
+db:
+  port: 70000
+  username: kafka-account
+workers: 0
+batch:
+  size: 10
+  timeout: 0s
+rateLimit:
+  messagesPerSecond: -1
+retry:
+  attempts: 0
+admin:
+  address: :9100
+producer:
+  url: ""
+`), 0644)
+		assert.NoError(t, err)
+
+		_, err = Load(dir, "")
+
+		assert.EqualError(t, err, `invalid config: `+
+			`kafka.group: is required; `+
+			`db.port: must be between 1 and 65535; `+
+			`db.database: is required; `+
+			`workers: must be positive; `+
+			`batch.timeout: must be positive in batch mode; `+
+			`rateLimit.messagesPerSecond: must not be negative; `+
+			`retry.attempts: must be positive; `+
+			`admin.address: must differ from metrics.address; `+
+			`producer.url: is required`)
+	})
+}
@@ -0,0 +1,67 @@
+package accountcontrollers
+
+import (
+	"consumer/repositories"
+	"consumer/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+type accountResponse struct {
+	ID            string  `json:"id"`
+	AccountHolder string  `json:"accountHolder"`
+	AccountType   int     `json:"accountType"`
+	Balance       float64 `json:"balance"`
+}
+
+func newAccountResponse(bankAccount repositories.BankAccount) accountResponse {
+	return accountResponse{bankAccount.ID, bankAccount.AccountHolder, bankAccount.AccountType, bankAccount.Balance}
+}
+
+type accountsController struct {
+	accountService services.IAccountService
+	logger         *slog.Logger
+}
+
+// list serves GET /accounts.
+func (obj accountsController) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	bankAccounts, err := obj.accountService.Accounts(r.Context())
+	if err != nil {
+		obj.logger.ErrorContext(r.Context(), "account list failed", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	response := []accountResponse{}
+	for _, bankAccount := range bankAccounts {
+		response = append(response, newAccountResponse(bankAccount))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// serve serves GET /accounts/:id.
+func (obj accountsController) serve(w http.ResponseWriter, r *http.Request, id string) {
+	bankAccount, err := obj.accountService.Account(r.Context(), id)
+	if errors.Is(err, services.ErrAccountNotFound) {
+		http.Error(w, fmt.Sprintf("account %v not found", id), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		obj.logger.ErrorContext(r.Context(), "account query failed", "account_id", id, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newAccountResponse(bankAccount))
+}
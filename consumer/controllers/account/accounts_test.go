@@ -0,0 +1,86 @@
+package accountcontrollers
+
+import (
+	"consumer/repositories"
+	"consumer/services"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"platform/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accountsController(t *testing.T) {
+	accountRepo := repositories.NewMemoryAccountRepository()
+	assert.NoError(t, accountRepo.Save(context.Background(), repositories.BankAccount{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000}))
+	assert.NoError(t, accountRepo.Save(context.Background(), repositories.BankAccount{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000}))
+	historyRepo := repositories.NewHistoryRepository(nil, repositories.DefaultTable)
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, services.NewAccountService(accountRepo), services.NewHistoryService(historyRepo), services.NewStatementService(historyRepo), logging.Discard())
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "Test should list accounts by id",
+			target:         "/accounts",
+			wantStatusCode: 200,
+			wantBody: `[{"id":"123","accountHolder":"John Doe","accountType":1,"balance":1000},` +
+				`{"id":"456","accountHolder":"Jane Doe","accountType":2,"balance":2000}]` + "\n",
+		},
+		{
+			name:           "Test should return an account",
+			target:         "/accounts/456",
+			wantStatusCode: 200,
+			wantBody:       `{"id":"456","accountHolder":"Jane Doe","accountType":2,"balance":2000}` + "\n",
+		},
+		{
+			name:           "Test should return not found for an unknown account",
+			target:         "/accounts/789",
+			wantStatusCode: 404,
+			wantBody:       "account 789 not found\n",
+		},
+		{
+			name:           "Test should return not found without an id",
+			target:         "/accounts/",
+			wantStatusCode: 404,
+		},
+		{
+			name:           "Test should reject other methods on the list",
+			method:         http.MethodPost,
+			target:         "/accounts",
+			wantStatusCode: 405,
+		},
+		{
+			name:           "Test should reject other methods on an account",
+			method:         http.MethodDelete,
+			target:         "/accounts/123",
+			wantStatusCode: 405,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			response := httptest.NewRecorder()
+
+			mux.ServeHTTP(response, httptest.NewRequest(method, test.target, nil))
+
+			assert.Equal(t, test.wantStatusCode, response.Code)
+			if test.wantBody != "" {
+				assert.Equal(t, test.wantBody, response.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package accountcontrollers
+
+import (
+	"consumer/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type balanceResponse struct {
+	ID         string    `json:"id"`
+	AsOf       time.Time `json:"asOf"`
+	Balance    float64   `json:"balance"`
+	Closed     bool      `json:"closed"`
+	Sequence   int       `json:"sequence"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type balanceController struct {
+	historyService services.IHistoryService
+	logger         *slog.Logger
+}
+
+func (obj balanceController) serve(w http.ResponseWriter, r *http.Request, id string) {
+	asOf, err := parseAsOf(r.URL.Query().Get("asOf"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	state, err := obj.historyService.BalanceAsOf(r.Context(), id, asOf)
+	if errors.Is(err, services.ErrNoHistory) {
+		http.Error(w, fmt.Sprintf("account %v has no history as of %v", id, asOf.Format(time.RFC3339)), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		obj.logger.ErrorContext(r.Context(), "balance query failed", "account_id", id, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balanceResponse{state.ID, asOf, state.Balance, state.Closed, state.Sequence, state.OccurredAt})
+}
+
+// parseAsOf reads an RFC 3339 time, or a date meaning the end of that day
+// in UTC. Without one the balance is the current one.
+func parseAsOf(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now().UTC(), nil
+	}
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return asOf.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err == nil {
+		return day.Add(24*time.Hour - time.Microsecond), nil
+	}
+	return time.Time{}, fmt.Errorf("asOf %q is neither an RFC 3339 time nor a date", value)
+}
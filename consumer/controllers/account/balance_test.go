@@ -0,0 +1,99 @@
+package accountcontrollers
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"consumer/services"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_balanceController(t *testing.T) {
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	db := internal.OpenSQLiteDB("balance_controller")
+	historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+	eventService := services.NewHistoryEventService(services.NewEventService(repositories.NewAccountRepository(db, repositories.DefaultTable), logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard())
+	for i, message := range []struct{ topic, value string }{
+		{"OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+	} {
+		ctx := services.ContextWithPosition(context.Background(), services.Position{Topic: message.topic, Offset: int64(i), Timestamp: day.AddDate(0, 0, i)})
+		assert.NoError(t, eventService.Handle(ctx, message.topic, []byte(message.value)))
+	}
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, services.NewAccountService(repositories.NewMemoryAccountRepository()), services.NewHistoryService(historyRepo), services.NewStatementService(historyRepo), logging.Discard())
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "Test should return the balance as of a time",
+			target:         "/accounts/123/balance?asOf=2024-03-02T08:59:59Z",
+			wantStatusCode: 200,
+			wantBody:       `{"id":"123","asOf":"2024-03-02T08:59:59Z","balance":1000,"closed":false,"sequence":1,"occurredAt":"2024-03-01T09:00:00Z"}` + "\n",
+		},
+		{
+			name:           "Test should return the balance at the end of a date",
+			target:         "/accounts/123/balance?asOf=2024-03-02",
+			wantStatusCode: 200,
+			wantBody:       `{"id":"123","asOf":"2024-03-02T23:59:59.999999Z","balance":1500,"closed":false,"sequence":2,"occurredAt":"2024-03-02T09:00:00Z"}` + "\n",
+		},
+		{
+			name:           "Test should return the current balance without asOf",
+			target:         "/accounts/123/balance",
+			wantStatusCode: 200,
+		},
+		{
+			name:           "Test should return not found before the account was opened",
+			target:         "/accounts/123/balance?asOf=2024-02-29",
+			wantStatusCode: 404,
+			wantBody:       "account 123 has no history as of 2024-02-29T23:59:59Z\n",
+		},
+		{
+			name:           "Test should return bad request for an invalid asOf",
+			target:         "/accounts/123/balance?asOf=yesterday",
+			wantStatusCode: 400,
+			wantBody:       `asOf "yesterday" is neither an RFC 3339 time nor a date` + "\n",
+		},
+		{
+			name:           "Test should return not found for another path",
+			target:         "/accounts/123/transactions",
+			wantStatusCode: 404,
+		},
+		{
+			name:           "Test should reject other methods",
+			method:         http.MethodPost,
+			target:         "/accounts/123/balance",
+			wantStatusCode: 405,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			response := httptest.NewRecorder()
+
+			mux.ServeHTTP(response, httptest.NewRequest(method, test.target, nil))
+
+			assert.Equal(t, test.wantStatusCode, response.Code)
+			if test.wantBody != "" {
+				assert.Equal(t, test.wantBody, response.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package accountcontrollers
+
+import (
+	"consumer/services"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// router dispatches GET /accounts/:id/<resource> to the handler of the
+// resource, and GET /accounts/:id to the one of "".
+type router map[string]func(w http.ResponseWriter, r *http.Request, id string)
+
+// RegisterRoutes serves the read model and the accounts' history:
+//
+//	GET /accounts
+//	GET /accounts/:id
+//	GET /accounts/:id/balance?asOf=
+//	GET /accounts/:id/statement?month=|from=&to=&format=
+func RegisterRoutes(mux *http.ServeMux, accountService services.IAccountService, historyService services.IHistoryService, statementService services.IStatementService, logger *slog.Logger) {
+	accounts := accountsController{accountService, logger}
+	mux.HandleFunc("/accounts", accounts.list)
+	mux.Handle("/accounts/", router{
+		"":          accounts.serve,
+		"balance":   balanceController{historyService, logger}.serve,
+		"statement": statementController{statementService, logger}.serve,
+	})
+}
+
+func (obj router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/accounts/"), "/")
+	serve, ok := obj[resource]
+	if !ok || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	serve(w, r, id)
+}
@@ -0,0 +1,51 @@
+package accountcontrollers
+
+import (
+	"consumer/services"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type statementController struct {
+	statementService services.IStatementService
+	logger           *slog.Logger
+}
+
+func (obj statementController) serve(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+	from, to, err := services.ParsePeriod(query.Get("month"), query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	contentType, ok := services.StatementContentTypes[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("format %q is not one of %v", format, strings.Join(services.StatementFormats, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	statement, err := obj.statementService.Statement(r.Context(), id, from, to)
+	if errors.Is(err, services.ErrNoHistory) {
+		http.Error(w, fmt.Sprintf("account %v has no history by %v", id, to.Format(time.RFC3339)), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		obj.logger.ErrorContext(r.Context(), "statement query failed", "account_id", id, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	err = services.WriteStatement(w, statement, format)
+	if err != nil {
+		obj.logger.ErrorContext(r.Context(), "statement export failed", "account_id", id, "error", err)
+	}
+}
@@ -0,0 +1,112 @@
+package accountcontrollers
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"consumer/services"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_statementController(t *testing.T) {
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	db := internal.OpenSQLiteDB("statement_controller")
+	historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+	eventService := services.NewHistoryEventService(services.NewEventService(repositories.NewAccountRepository(db, repositories.DefaultTable), logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard())
+	for i, message := range []struct{ topic, value string }{
+		{"OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+	} {
+		ctx := services.ContextWithPosition(context.Background(), services.Position{Topic: message.topic, Offset: int64(i), Timestamp: day.AddDate(0, i, 0)})
+		assert.NoError(t, eventService.Handle(ctx, message.topic, []byte(message.value)))
+	}
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, services.NewAccountService(repositories.NewMemoryAccountRepository()), services.NewHistoryService(historyRepo), services.NewStatementService(historyRepo), logging.Discard())
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+
+		wantStatusCode  int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "Test should return a monthly statement as csv",
+			target:          "/accounts/123/statement?month=2024-04&format=csv",
+			wantStatusCode:  200,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "date,type,amount,balance\n" +
+				"2024-04-01T00:00:00Z,opening balance,,1000.00\n" +
+				"2024-04-01T09:00:00Z,deposit,500.00,1500.00\n" +
+				"2024-04-30T23:59:59Z,closing balance,,1500.00\n",
+		},
+		{
+			name:            "Test should return json without a format",
+			target:          "/accounts/123/statement?from=2024-03-01&to=2024-03-31",
+			wantStatusCode:  200,
+			wantContentType: "application/json",
+			wantBody: `{"accountId":"123","accountHolder":"John Doe","from":"2024-03-01T00:00:00Z","to":"2024-03-31T23:59:59.999999Z",` +
+				`"openingBalance":0,"lines":[{"sequence":1,"occurredAt":"2024-03-01T09:00:00Z","type":"open","amount":1000,"balance":1000}],` +
+				`"closingBalance":1000}` + "\n",
+		},
+		{
+			name:            "Test should return a statement as text",
+			target:          "/accounts/123/statement?month=2024-05&format=text",
+			wantStatusCode:  200,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:           "Test should return not found before the account was opened",
+			target:         "/accounts/123/statement?month=2024-02",
+			wantStatusCode: 404,
+			wantBody:       "account 123 has no history by 2024-02-29T23:59:59Z\n",
+		},
+		{
+			name:           "Test should return bad request for an invalid period",
+			target:         "/accounts/123/statement?month=March",
+			wantStatusCode: 400,
+			wantBody:       `month "March" is not a month like 2006-01` + "\n",
+		},
+		{
+			name:           "Test should return bad request for an unknown format",
+			target:         "/accounts/123/statement?month=2024-03&format=pdf",
+			wantStatusCode: 400,
+			wantBody:       `format "pdf" is not one of csv, json, text` + "\n",
+		},
+		{
+			name:           "Test should reject other methods",
+			method:         http.MethodPost,
+			target:         "/accounts/123/statement",
+			wantStatusCode: 405,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			response := httptest.NewRecorder()
+
+			mux.ServeHTTP(response, httptest.NewRequest(method, test.target, nil))
+
+			assert.Equal(t, test.wantStatusCode, response.Code)
+			if test.wantContentType != "" {
+				assert.Equal(t, test.wantContentType, response.Header().Get("Content-Type"))
+			}
+			if test.wantBody != "" {
+				assert.Equal(t, test.wantBody, response.Body.String())
+			}
+		})
+	}
+}
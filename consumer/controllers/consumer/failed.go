@@ -0,0 +1,133 @@
+package consumercontrollers
+
+import (
+	"consumer/repositories"
+	"consumer/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RegisterFailedMessageRoutes serves the messages the consumer dead
+// lettered:
+//
+//	GET  /consumer/failed-messages
+//	POST /consumer/failed-messages/redrive?topic=&partition=&offset=
+func RegisterFailedMessageRoutes(mux *http.ServeMux, redriveService services.IRedriveService, logger *slog.Logger) {
+	controller := failedMessagesController{redriveService, logger}
+	mux.HandleFunc("/consumer/failed-messages", controller.list)
+	mux.HandleFunc("/consumer/failed-messages/redrive", controller.redrive)
+}
+
+type failedMessageResponse struct {
+	Topic     string            `json:"topic"`
+	Partition int32             `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	// Value is the payload as read from Kafka, in base64.
+	Value    []byte    `json:"value"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+type redriveResponse struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+type failedMessagesController struct {
+	redriveService services.IRedriveService
+	logger         *slog.Logger
+}
+
+// list serves GET /consumer/failed-messages.
+func (obj failedMessagesController) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	messages, err := obj.redriveService.FailedMessages(r.Context())
+	if err != nil {
+		obj.logger.ErrorContext(r.Context(), "failed message list failed", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	response := []failedMessageResponse{}
+	for _, message := range messages {
+		response = append(response, failedMessageResponse{
+			Topic:     message.Topic,
+			Partition: message.Partition,
+			Offset:    message.Offset,
+			Timestamp: message.Timestamp,
+			Key:       string(message.Key),
+			Headers:   message.Headers,
+			Value:     message.Value,
+			Error:     message.Error,
+			Attempts:  message.Attempts,
+			FailedAt:  message.FailedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// redrive serves POST /consumer/failed-messages/redrive.
+func (obj failedMessagesController) redrive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	position, err := parsePosition(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = obj.redriveService.Redrive(r.Context(), position)
+	switch {
+	case errors.Is(err, services.ErrFailedMessageNotFound):
+		http.Error(w, fmt.Sprintf("no failed message at %v/%v/%v", position.Topic, position.Partition, position.Offset), http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrRedriveFailed):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		obj.logger.ErrorContext(r.Context(), "redrive failed", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redriveResponse{position.Topic, position.Partition, position.Offset})
+}
+
+// parsePosition reads the topic, partition and offset of the message to
+// redrive.
+func parsePosition(r *http.Request) (repositories.Position, error) {
+	query := r.URL.Query()
+	topic := query.Get("topic")
+	if topic == "" {
+		return repositories.Position{}, errors.New("topic is required")
+	}
+	partition, err := strconv.ParseInt(query.Get("partition"), 10, 32)
+	if err != nil || partition < 0 {
+		return repositories.Position{}, fmt.Errorf("invalid partition %q", query.Get("partition"))
+	}
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return repositories.Position{}, fmt.Errorf("invalid offset %q", query.Get("offset"))
+	}
+	return repositories.Position{Topic: topic, Partition: int32(partition), Offset: offset}, nil
+}
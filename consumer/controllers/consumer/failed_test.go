@@ -0,0 +1,126 @@
+package consumercontrollers
+
+import (
+	"consumer/repositories"
+	"consumer/services"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedriveService holds one failed message and redrives it with err.
+type fakeRedriveService struct {
+	message repositories.FailedMessage
+	err     error
+}
+
+func (obj fakeRedriveService) FailedMessages(ctx context.Context) ([]repositories.FailedMessage, error) {
+	return []repositories.FailedMessage{obj.message}, obj.err
+}
+
+func (obj fakeRedriveService) Redrive(ctx context.Context, position repositories.Position) error {
+	if position != (repositories.Position{Topic: obj.message.Topic, Partition: obj.message.Partition, Offset: obj.message.Offset}) {
+		return services.ErrFailedMessageNotFound
+	}
+	return obj.err
+}
+
+func Test_failedMessagesController(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	message := repositories.FailedMessage{
+		Topic:     "DepositFundEvent",
+		Partition: 1,
+		Offset:    5,
+		Timestamp: day,
+		Value:     []byte(`{}`),
+		Headers:   map[string]string{"request-id": "request"},
+		Error:     "record not found",
+		Attempts:  3,
+		FailedAt:  day,
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		method string
+		target string
+
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "Test should list the failed messages",
+			target:         "/consumer/failed-messages",
+			wantStatusCode: 200,
+			wantBody: `[{"topic":"DepositFundEvent","partition":1,"offset":5,"timestamp":"2024-03-01T00:00:00Z","headers":{"request-id":"request"},` +
+				`"value":"e30=","error":"record not found","attempts":3,"failedAt":"2024-03-01T00:00:00Z"}]` + "\n",
+		},
+		{
+			name:           "Test should redrive a failed message",
+			method:         http.MethodPost,
+			target:         "/consumer/failed-messages/redrive?topic=DepositFundEvent&partition=1&offset=5",
+			wantStatusCode: 200,
+			wantBody:       `{"topic":"DepositFundEvent","partition":1,"offset":5}` + "\n",
+		},
+		{
+			name:           "Test should return not found without a failed message at the position",
+			method:         http.MethodPost,
+			target:         "/consumer/failed-messages/redrive?topic=DepositFundEvent&partition=0&offset=5",
+			wantStatusCode: 404,
+			wantBody:       "no failed message at DepositFundEvent/0/5\n",
+		},
+		{
+			name:           "Test should return unprocessable when the message fails again",
+			err:            fmt.Errorf("%w: record not found", services.ErrRedriveFailed),
+			method:         http.MethodPost,
+			target:         "/consumer/failed-messages/redrive?topic=DepositFundEvent&partition=1&offset=5",
+			wantStatusCode: 422,
+			wantBody:       "message failed again: record not found\n",
+		},
+		{
+			name:           "Test should return internal server error when the store fails",
+			err:            errors.New("database is locked"),
+			method:         http.MethodPost,
+			target:         "/consumer/failed-messages/redrive?topic=DepositFundEvent&partition=1&offset=5",
+			wantStatusCode: 500,
+		},
+		{
+			name:           "Test should reject a redrive without an offset",
+			method:         http.MethodPost,
+			target:         "/consumer/failed-messages/redrive?topic=DepositFundEvent&partition=1",
+			wantStatusCode: 400,
+			wantBody:       "invalid offset \"\"\n",
+		},
+		{
+			name:           "Test should reject reading from redrive",
+			target:         "/consumer/failed-messages/redrive?topic=DepositFundEvent&partition=1&offset=5",
+			wantStatusCode: 405,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			RegisterFailedMessageRoutes(mux, fakeRedriveService{message, test.err}, logging.Discard())
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, httptest.NewRequest(method, test.target, nil))
+
+			assert.Equal(t, test.wantStatusCode, w.Code)
+			if test.wantBody != "" {
+				assert.Equal(t, test.wantBody, w.Body.String())
+			}
+		})
+	}
+}
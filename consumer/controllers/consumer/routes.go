@@ -0,0 +1,81 @@
+package consumercontrollers
+
+import (
+	"consumer/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// RegisterRoutes serves the runtime controls of the consumer group:
+//
+//	GET  /consumer/pause
+//	POST /consumer/pause?topic=&partition=
+//	POST /consumer/resume?topic=&partition=
+//
+// Without a topic every partition is paused or resumed; partition may be
+// repeated. Each answers with the pause state.
+func RegisterRoutes(mux *http.ServeMux, control services.IConsumerControl) {
+	controller := controlController{control}
+	mux.HandleFunc("/consumer/pause", controller.pause)
+	mux.HandleFunc("/consumer/resume", controller.resume)
+}
+
+type controlController struct {
+	control services.IConsumerControl
+}
+
+// pause serves GET and POST /consumer/pause.
+func (obj controlController) pause(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		obj.writeState(w)
+		return
+	}
+	obj.change(w, r, obj.control.Pause)
+}
+
+// resume serves POST /consumer/resume.
+func (obj controlController) resume(w http.ResponseWriter, r *http.Request) {
+	obj.change(w, r, obj.control.Resume)
+}
+
+func (obj controlController) change(w http.ResponseWriter, r *http.Request, change func(topic string, partitions []int32)) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	topic, partitions, err := parseTarget(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	change(topic, partitions)
+	obj.writeState(w)
+}
+
+func (obj controlController) writeState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obj.control.State())
+}
+
+// parseTarget reads the topic and partitions to pause or resume.
+func parseTarget(r *http.Request) (string, []int32, error) {
+	query := r.URL.Query()
+	topic := query.Get("topic")
+	partitions := []int32{}
+	for _, value := range query["partition"] {
+		partition, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || partition < 0 {
+			return "", nil, fmt.Errorf("invalid partition %q", value)
+		}
+		partitions = append(partitions, int32(partition))
+	}
+	if topic == "" && len(partitions) > 0 {
+		return "", nil, errors.New("partition needs a topic")
+	}
+	return topic, partitions, nil
+}
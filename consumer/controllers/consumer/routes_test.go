@@ -0,0 +1,99 @@
+package consumercontrollers
+
+import (
+	"consumer/services"
+	"net/http"
+	"net/http/httptest"
+	"platform/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nopPauser struct{}
+
+func (nopPauser) Pause(partitions map[string][]int32)  {}
+func (nopPauser) Resume(partitions map[string][]int32) {}
+
+func Test_controlController(t *testing.T) {
+	tests := []struct {
+		name        string
+		pausedTopic string
+		method      string
+		target      string
+
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "Test should return the pause state",
+			target:         "/consumer/pause",
+			wantStatusCode: 200,
+			wantBody:       `{"all":false,"topics":[],"partitions":{},"paused":{}}` + "\n",
+		},
+		{
+			name:           "Test should pause partitions of a topic",
+			method:         http.MethodPost,
+			target:         "/consumer/pause?topic=DepositFundEvent&partition=0&partition=2",
+			wantStatusCode: 200,
+			wantBody:       `{"all":false,"topics":[],"partitions":{"DepositFundEvent":[0,2]},"paused":{}}` + "\n",
+		},
+		{
+			name:           "Test should pause every partition without a topic",
+			method:         http.MethodPost,
+			target:         "/consumer/pause",
+			wantStatusCode: 200,
+			wantBody:       `{"all":true,"topics":[],"partitions":{},"paused":{}}` + "\n",
+		},
+		{
+			name:           "Test should resume a topic",
+			pausedTopic:    "DepositFundEvent",
+			method:         http.MethodPost,
+			target:         "/consumer/resume?topic=DepositFundEvent",
+			wantStatusCode: 200,
+			wantBody:       `{"all":false,"topics":[],"partitions":{},"paused":{}}` + "\n",
+		},
+		{
+			name:           "Test should reject a partition without a topic",
+			method:         http.MethodPost,
+			target:         "/consumer/pause?partition=1",
+			wantStatusCode: 400,
+			wantBody:       "partition needs a topic\n",
+		},
+		{
+			name:           "Test should reject an invalid partition",
+			method:         http.MethodPost,
+			target:         "/consumer/resume?topic=DepositFundEvent&partition=first",
+			wantStatusCode: 400,
+			wantBody:       "invalid partition \"first\"\n",
+		},
+		{
+			name:           "Test should reject reading from resume",
+			target:         "/consumer/resume",
+			wantStatusCode: 405,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			control := services.NewConsumerControl(nopPauser{}, 0, 1, nil, logging.Discard())
+			if test.pausedTopic != "" {
+				control.Pause(test.pausedTopic, nil)
+			}
+			mux := http.NewServeMux()
+			RegisterRoutes(mux, control)
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, httptest.NewRequest(method, test.target, nil))
+
+			assert.Equal(t, test.wantStatusCode, w.Code)
+			if test.wantBody != "" {
+				assert.Equal(t, test.wantBody, w.Body.String())
+			}
+		})
+	}
+}
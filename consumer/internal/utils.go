@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"consumer/migrations"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func MarshalJSONData(req interface{}) []byte {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		panic(errors.New("cannot marshal json data"))
+	}
+
+	return jsonData
+}
+
+// OpenSQLiteDB opens a private in-memory SQLite database named after name
+// and migrates it, so every test gets its own empty bond_banks table.
+func OpenSQLiteDB(name string) *gorm.DB {
+	dsn := fmt.Sprintf("file:%v?mode=memory&cache=shared", name)
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	migrator, err := migrations.New(db, "bond_banks")
+	if err != nil {
+		panic(err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
@@ -1,66 +1,361 @@
 package main
 
 import (
+	"consumer/commands"
+	"consumer/config"
+	accountcontrollers "consumer/controllers/account"
+	consumercontrollers "consumer/controllers/consumer"
+	"consumer/metrics"
+	"consumer/migrations"
 	"consumer/repositories"
 	"consumer/services"
 	"context"
+	"errors"
 	"events"
 	"fmt"
-	"strings"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"platform/database"
+	"platform/health"
+	"platform/kafka"
+	"platform/logging"
+	"platform/shutdown"
+	"platform/tracing"
+	"syscall"
+	"time"
 
 	"github.com/Shopify/sarama"
-	"github.com/spf13/viper"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-func init() {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	if err := viper.ReadInConfig(); err != nil {
+func initDatabase(cfg config.Config) *gorm.DB {
+	db, err := database.Open(cfg.DB.Config)
+	if err != nil {
 		panic(err)
 	}
+	return db
 }
 
-func initDatabase() *gorm.DB {
-	dsn := fmt.Sprintf("%v:%v@tcp(%v:%v)/%v",
-		viper.GetString("db.username"),
-		viper.GetString("db.password"),
-		viper.GetString("db.host"),
-		viper.GetInt("db.port"),
-		viper.GetString("db.database"),
-	)
+// migrate applies pending migrations when the config allows it at startup,
+// and otherwise refuses to run against an outdated schema.
+func migrate(cfg config.Config, db *gorm.DB, logger *slog.Logger) {
+	migrator, err := migrations.New(db, cfg.DB.Table)
+	if err != nil {
+		panic(err)
+	}
 
-	dial := mysql.Open(dsn)
+	if !cfg.DB.MigrateOnStart {
+		pending, err := migrator.Pending(context.Background())
+		if err != nil {
+			panic(err)
+		}
+		if len(pending) > 0 {
+			panic(fmt.Errorf("%d pending migrations, run `consumer migrate up` first", len(pending)))
+		}
+		return
+	}
 
-	db, err := gorm.Open(dial, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+	applied, err := migrator.Up(context.Background())
+	for _, migration := range applied {
+		logger.Info("applied migration", "version", migration.Version, "name", migration.Name)
+	}
 	if err != nil {
 		panic(err)
 	}
-	return db
+}
+
+// provision ensures the declared topics at startup when the config allows
+// it. Drift it cannot fix is only logged.
+func provision(cfg config.Config, client sarama.Client, logger *slog.Logger) {
+	if !cfg.Kafka.Topics.Provision {
+		return
+	}
+
+	// closing the admin would close the shared client
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		panic(err)
+	}
+	changes, err := kafka.ProvisionTopics(admin, cfg.Kafka.Topics.Resolve(events.Topics), true)
+	for _, change := range changes {
+		attrs := []any{"topic", change.Topic, "setting", change.Setting, "have", change.Have, "want", change.Want}
+		if change.Status == kafka.ChangeManual {
+			logger.Warn("topic drift needs a manual fix", attrs...)
+			continue
+		}
+		logger.Info("topic provisioned", attrs...)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+// controlBySignal pauses every partition on SIGUSR1 and resumes them on
+// SIGUSR2, for hosts where the admin address is not reachable.
+func controlBySignal(ctx context.Context, control services.IConsumerControl) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case sig := <-signals:
+			if sig == syscall.SIGUSR1 {
+				control.Pause("", nil)
+			} else {
+				control.Resume("", nil)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// subcommands run instead of the consumer when named by the first argument.
+var subcommands = map[string]func(cfg config.Config, logger *slog.Logger, args []string) error{
+	"migrate":   runMigrate,
+	"statement": runStatement,
+	"reconcile": runReconcile,
+	"provision": runProvision,
+}
+
+func runMigrate(cfg config.Config, logger *slog.Logger, args []string) error {
+	migrator, err := migrations.New(initDatabase(cfg), cfg.DB.Table)
+	if err != nil {
+		return err
+	}
+	return migrator.Command(context.Background(), args, os.Stdout)
+}
+
+func runStatement(cfg config.Config, logger *slog.Logger, args []string) error {
+	statementService := services.NewStatementService(repositories.NewHistoryRepository(initDatabase(cfg), cfg.DB.Table))
+	return commands.Statement(context.Background(), statementService, args, os.Stdout)
+}
+
+// runReconcile reads the whole log, checking the messages the consumer
+// group has not committed against the history, and only sends adjustments
+// to the producer when asked to.
+func runReconcile(cfg config.Config, logger *slog.Logger, args []string) error {
+	saramaConfig, err := cfg.Kafka.Sarama()
+	if err != nil {
+		return err
+	}
+	client, err := sarama.NewClient(cfg.Kafka.Servers, saramaConfig)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		return err
+	}
+
+	db := initDatabase(cfg)
+	reconciliationService := services.NewReconciliationService(
+		services.NewKafkaLogReader(client, admin, cfg.Kafka.Group),
+		repositories.NewAccountRepository(db, cfg.DB.Table),
+		repositories.NewHistoryRepository(db, cfg.DB.Table),
+		repositories.NewFailedMessageRepository(db, cfg.DB.Table),
+		services.NewProducerAdjuster(&http.Client{Timeout: 10 * time.Second}, cfg.Producer.URL),
+		logger,
+	)
+	return commands.Reconcile(context.Background(), reconciliationService, args, os.Stdout)
+}
+
+func runProvision(cfg config.Config, logger *slog.Logger, args []string) error {
+	saramaConfig, err := cfg.Kafka.Sarama()
+	if err != nil {
+		return err
+	}
+	admin, err := sarama.NewClusterAdmin(cfg.Kafka.Servers, saramaConfig)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	return kafka.ProvisionCommand(admin, cfg.Kafka.Topics.Resolve(events.Topics), args, os.Stdout)
 }
 
 func main() {
+	cfg, err := config.Load(".", os.Getenv("APP_PROFILE"))
+	if err != nil {
+		panic(err)
+	}
+
+	var subcommand func(cfg config.Config, logger *slog.Logger, args []string) error
+	if len(os.Args) > 1 {
+		subcommand = subcommands[os.Args[1]]
+	}
 
-	consumer, err := sarama.NewConsumerGroup(viper.GetStringSlice("kafka.servers"), viper.GetString("kafka.group"), nil)
+	// subcommands keep stdout for their output
+	logOutput := io.Writer(os.Stdout)
+	if subcommand != nil {
+		logOutput = os.Stderr
+	}
+	logger, err := logging.New(logOutput, logging.Config{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer consumer.Close()
+	slog.SetDefault(logger)
 
-	db := initDatabase()
-	accountRepo := repositories.NewAccountRepository(db)
-	eventService := services.NewEventService(accountRepo)
-	accountConsumerService := services.NewConsumerService(eventService)
+	registry := events.NewSchemaRegistry(cfg.SchemaRegistry.URL, cfg.SchemaRegistry.File)
+	if registry != nil {
+		events.RegisterCodec(events.AvroCodec{Registry: registry})
+	}
 
-	fmt.Println("Account consumer started...")
-	for {
-		consumer.Consume(context.Background(), events.Topics, accountConsumerService)
+	if subcommand != nil {
+		err := subcommand(cfg, logger, os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: "consumer",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	saramaConfig, err := cfg.Kafka.Sarama()
+	if err != nil {
+		panic(err)
+	}
+
+	client, err := sarama.NewClient(cfg.Kafka.Servers, saramaConfig)
+	if err != nil {
+		panic(err)
+	}
+	provision(cfg, client, logger)
+
+	consumer, err := sarama.NewConsumerGroupFromClient(cfg.Kafka.Group, client)
+	if err != nil {
+		panic(err)
+	}
+
+	db := initDatabase(cfg)
+	migrate(cfg, db, logger)
+	accountRepo := repositories.NewAccountRepository(db, cfg.DB.Table)
+	historyRepo := repositories.NewHistoryRepository(db, cfg.DB.Table)
+	eventService := services.NewUpcastingEventService(
+		services.NewHistoryEventService(services.NewEventService(accountRepo, logger), historyRepo, repositories.NewTransactor(db), cfg.History.SnapshotEvery, logger),
+		services.Upcasters,
+	)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+
+	checker := health.NewChecker()
+	checker.Add("kafka", kafka.Check(client))
+	checker.Add("db", database.Check(db))
+
+	var messageRecorder services.IMessageRecorder
+	var captureFile *os.File
+	if cfg.Capture.File != "" {
+		captureFile, err = os.OpenFile(cfg.Capture.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			panic(err)
+		}
+		messageRecorder = services.NewMessageRecorder(captureFile)
+		logger.Info("capturing consumed messages", "file", cfg.Capture.File)
+	}
+
+	consumerMetrics := metrics.New()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", consumerMetrics.Handler())
+	mux.Handle("/healthz", health.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+	server := &http.Server{Addr: cfg.Metrics.Address, Handler: mux}
+
+	control := services.NewConsumerControl(consumer, cfg.RateLimit.MessagesPerSecond, cfg.RateLimit.Burst, consumerMetrics, logger)
+	adminMux := http.NewServeMux()
+	accountcontrollers.RegisterRoutes(adminMux, services.NewAccountService(accountRepo), services.NewHistoryService(historyRepo), services.NewStatementService(historyRepo), logger)
+	consumercontrollers.RegisterRoutes(adminMux, control)
+	failedRepo := repositories.NewFailedMessageRepository(db, cfg.DB.Table)
+	consumercontrollers.RegisterFailedMessageRoutes(adminMux, services.NewRedriveService(eventService, failedRepo, logger), logger)
+	adminServer := &http.Server{Addr: cfg.Admin.Address, Handler: adminMux}
+
+	for _, server := range []*http.Server{server, adminServer} {
+		go func(server *http.Server) {
+			err := server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				panic(err)
+			}
+		}(server)
+	}
+	retry := services.RetryPolicy{Attempts: cfg.Retry.Attempts, Backoff: cfg.Retry.Backoff}
+	accountConsumerService := services.NewConsumerService(eventService, messageRecorder, failedRepo, retry, consumerMetrics, logger, cfg.Workers)
+	if cfg.Batch.Size > 0 {
+		batchService := services.NewBatchService(repositories.NewBatchRepository(db, cfg.DB.Table), historyRepo, repositories.NewTransactor(db), services.Upcasters, cfg.History.SnapshotEvery, logger)
+		accountConsumerService = services.NewBatchConsumerService(eventService, batchService, messageRecorder, failedRepo, retry, consumerMetrics, logger, cfg.Batch.Size, cfg.Batch.Timeout)
+		logger.Info("consuming in batches", "size", cfg.Batch.Size, "timeout", cfg.Batch.Timeout)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go controlBySignal(ctx, control)
+	if cfg.AutoPause.OnDBFailure {
+		go services.WatchHealth(ctx, control, "db", database.Check(db), cfg.AutoPause.Interval)
+	}
+
+	// Consume returns when a rebalance ends the session, so it runs in a
+	// loop; after ctx is cancelled it returns once ConsumeClaim has
+	// finished the message in hand.
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		for ctx.Err() == nil {
+			err := consumer.Consume(ctx, events.Topics, control.Handler(accountConsumerService))
+			if err != nil {
+				logger.Error("consume failed", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+
+	logger.Info("account consumer started")
+	<-ctx.Done()
+	logger.Info("shutting down", "timeout", cfg.Shutdown.Timeout)
+	checker.Shutdown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+	steps := []shutdown.Step{
+		{Name: "consumer session", Run: func(ctx context.Context) error {
+			<-consumed
+			return nil
+		}},
+		// closing the group commits the offsets marked so far
+		shutdown.Close("kafka consumer group", consumer),
+		shutdown.Close("kafka client", client),
+		shutdown.Close("database", sqlDB),
+	}
+	if captureFile != nil {
+		steps = append(steps, shutdown.Close("capture file", captureFile))
+	}
+	steps = append(steps,
+		shutdown.Step{Name: "admin server", Run: adminServer.Shutdown},
+		shutdown.Step{Name: "metrics server", Run: server.Shutdown},
+		shutdown.Step{Name: "tracing", Run: shutdownTracing},
+	)
+	err = shutdown.Run(shutdownCtx, steps...)
+	if err != nil {
+		logger.Error("shutdown incomplete", "error", err)
+		os.Exit(1)
 	}
+	logger.Info("shutdown complete")
 }
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Outcomes recorded by MessagesConsumed.
+const (
+	OutcomeSuccess     = "success"
+	OutcomeError       = "error"
+	OutcomeDecodeError = "decode_error"
+)
+
+// Metrics holds the consumer collectors on a registry of its own, so tests
+// can build one per case and read it back with testutil.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	MessagesConsumed *prometheus.CounterVec
+	HandlerDuration  *prometheus.HistogramVec
+	ConsumerLag      *prometheus.GaugeVec
+	InFlight         *prometheus.GaugeVec
+	Retries          *prometheus.CounterVec
+	DeadLettered     *prometheus.CounterVec
+	BatchSize        prometheus.Histogram
+	BatchDuration    *prometheus.HistogramVec
+	Paused           *prometheus.GaugeVec
+	AutoPaused       prometheus.Gauge
+	RateLimitWait    prometheus.Counter
+}
+
+func New() *Metrics {
+	obj := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		MessagesConsumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "consumer_messages_total",
+			Help: "Messages consumed, by topic and outcome.",
+		}, []string{"topic", "outcome"}),
+		HandlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "consumer_handler_duration_seconds",
+			Help:    "Time spent decoding and handling a message, by topic.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"topic"}),
+		ConsumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "consumer_lag",
+			Help: "Messages between the last handled offset and the high water mark, by topic and partition.",
+		}, []string{"topic", "partition"}),
+		InFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "consumer_in_flight_messages",
+			Help: "Messages dispatched to the worker pool and not handled yet, by topic and partition.",
+		}, []string{"topic", "partition"}),
+		Retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "consumer_retries_total",
+			Help: "Message handling attempts retried, by topic.",
+		}, []string{"topic"}),
+		DeadLettered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "consumer_dead_lettered_total",
+			Help: "Messages recorded as failed after their last attempt, or because they do not decode, by topic.",
+		}, []string{"topic"}),
+		BatchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "consumer_batch_size",
+			Help:    "Messages per batch applied in batch mode.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 11),
+		}),
+		BatchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "consumer_batch_duration_seconds",
+			Help:    "Time spent applying a batch in one transaction, by outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		Paused: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "consumer_partition_paused",
+			Help: "1 while a claimed partition is paused, by hand or automatically, by topic and partition.",
+		}, []string{"topic", "partition"}),
+		AutoPaused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "consumer_auto_paused",
+			Help: "1 while consumption is paused because a health check fails.",
+		}),
+		RateLimitWait: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "consumer_rate_limit_wait_seconds_total",
+			Help: "Time messages waited for the rate limiter.",
+		}),
+	}
+	obj.Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		obj.MessagesConsumed,
+		obj.HandlerDuration,
+		obj.ConsumerLag,
+		obj.InFlight,
+		obj.Retries,
+		obj.DeadLettered,
+		obj.BatchSize,
+		obj.BatchDuration,
+		obj.Paused,
+		obj.AutoPaused,
+		obj.RateLimitWait,
+	)
+	return obj
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (obj *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(obj.Registry, promhttp.HandlerOpts{})
+}
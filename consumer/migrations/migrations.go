@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+
+	"platform/migrations"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Source returns the consumer's migrations, with table as the name of the
+// account read model table.
+func Source(table string) migrations.Source {
+	sql, _ := fs.Sub(files, "sql")
+	return migrations.Source{Files: sql, Data: struct{ Table string }{table}}
+}
+
+// New loads the migrations for db's driver.
+func New(db *gorm.DB, table string) (*migrations.Migrator, error) {
+	return migrations.New(db, Source(table))
+}
@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"platform/migrations"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type bankAccount struct {
+	ID            string
+	AccountHolder string
+	AccountType   int
+	Balance       float64
+}
+
+func openSQLiteDB(t *testing.T) *gorm.DB {
+	dsn := fmt.Sprintf("file:%v?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func Test_Source(t *testing.T) {
+	tests := []struct {
+		driver string
+
+		wantUp []string
+	}{
+		{
+			driver: "mysql",
+			wantUp: []string{"ALTER TABLE accounts MODIFY id VARCHAR(191) NOT NULL, ADD PRIMARY KEY (id)"},
+		},
+		{
+			driver: "postgres",
+			wantUp: []string{"ALTER TABLE accounts ALTER COLUMN id SET NOT NULL, ADD PRIMARY KEY (id)"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("Test should pick "+test.driver+" files over generic ones", func(t *testing.T) {
+			migrations, err := migrations.Load(Source("accounts"), test.driver)
+
+			assert.NoError(t, err)
+			assert.Len(t, migrations, 4)
+			assert.Equal(t, 1, migrations[0].Version)
+			assert.Equal(t, "create_bank_accounts", migrations[0].Name)
+			assert.Contains(t, migrations[0].Up[0], "CREATE TABLE IF NOT EXISTS accounts (")
+			assert.Equal(t, []string{"DROP TABLE IF EXISTS accounts"}, migrations[0].Down)
+			assert.Equal(t, test.wantUp, migrations[1].Up)
+		})
+	}
+
+	t.Run("Test should split sqlite rebuild into statements", func(t *testing.T) {
+		migrations, err := migrations.Load(Source("accounts"), "sqlite")
+
+		assert.NoError(t, err)
+		assert.Len(t, migrations[1].Up, 4)
+		assert.Equal(t, "ALTER TABLE accounts_new RENAME TO accounts", migrations[1].Up[3])
+	})
+}
+
+func Test_Migrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Test should apply every migration once and enforce the primary key", func(t *testing.T) {
+		db := openSQLiteDB(t)
+		migrator, err := New(db, "bond_banks")
+		assert.NoError(t, err)
+
+		applied, err := migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, applied, 4)
+		applied, err = migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+
+		statuses, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		for _, status := range statuses {
+			assert.True(t, status.Applied, status.Name)
+			assert.NotEmpty(t, status.AppliedAt)
+		}
+
+		assert.NoError(t, db.Table("bond_banks").Create(&bankAccount{ID: "123", AccountHolder: "John Doe"}).Error)
+		assert.Error(t, db.Table("bond_banks").Create(&bankAccount{ID: "123", AccountHolder: "Jane Doe"}).Error)
+	})
+
+	t.Run("Test should keep rows of a table created by AutoMigrate", func(t *testing.T) {
+		db := openSQLiteDB(t)
+		assert.NoError(t, db.Table("bond_banks").AutoMigrate(&bankAccount{}))
+		assert.NoError(t, db.Table("bond_banks").Create(&bankAccount{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000}).Error)
+		migrator, err := New(db, "bond_banks")
+		assert.NoError(t, err)
+
+		_, err = migrator.Up(ctx)
+		assert.NoError(t, err)
+
+		var bankAccounts []bankAccount
+		assert.NoError(t, db.Table("bond_banks").Find(&bankAccounts).Error)
+		assert.Equal(t, []bankAccount{{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000}}, bankAccounts)
+	})
+
+	t.Run("Test should revert newest first and apply again", func(t *testing.T) {
+		db := openSQLiteDB(t)
+		migrator, err := New(db, "bond_banks")
+		assert.NoError(t, err)
+		_, err = migrator.Up(ctx)
+		assert.NoError(t, err)
+
+		reverted, err := migrator.Down(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, reverted[0].Version)
+		assert.False(t, db.Migrator().HasTable("bond_banks_failed_messages"))
+		statuses, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []bool{true, true, true, false}, []bool{statuses[0].Applied, statuses[1].Applied, statuses[2].Applied, statuses[3].Applied})
+
+		reverted, err = migrator.Down(ctx, 5)
+		assert.NoError(t, err)
+		assert.Len(t, reverted, 3)
+		assert.False(t, db.Migrator().HasTable("bond_banks_events"))
+		assert.False(t, db.Migrator().HasTable("bond_banks"))
+
+		applied, err := migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, applied, 4)
+	})
+}
@@ -0,0 +1,8 @@
+-- Baseline: the table GORM AutoMigrate created before migrations existed, so
+-- databases that already have it skip straight to 0002.
+CREATE TABLE IF NOT EXISTS {{.Table}} (
+	id TEXT,
+	account_holder TEXT,
+	account_type BIGINT,
+	balance DOUBLE PRECISION
+);
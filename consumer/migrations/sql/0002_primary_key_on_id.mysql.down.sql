@@ -0,0 +1 @@
+ALTER TABLE {{.Table}} DROP PRIMARY KEY, MODIFY id LONGTEXT;
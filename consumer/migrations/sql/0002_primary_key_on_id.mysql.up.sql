@@ -0,0 +1 @@
+ALTER TABLE {{.Table}} MODIFY id VARCHAR(191) NOT NULL, ADD PRIMARY KEY (id);
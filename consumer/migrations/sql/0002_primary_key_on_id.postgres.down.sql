@@ -0,0 +1 @@
+ALTER TABLE {{.Table}} DROP CONSTRAINT {{.Table}}_pkey, ALTER COLUMN id DROP NOT NULL;
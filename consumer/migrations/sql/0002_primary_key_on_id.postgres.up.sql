@@ -0,0 +1 @@
+ALTER TABLE {{.Table}} ALTER COLUMN id SET NOT NULL, ADD PRIMARY KEY (id);
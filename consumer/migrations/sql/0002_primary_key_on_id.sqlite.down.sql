@@ -0,0 +1,10 @@
+CREATE TABLE {{.Table}}_old (
+	id TEXT,
+	account_holder TEXT,
+	account_type BIGINT,
+	balance DOUBLE PRECISION
+);
+INSERT INTO {{.Table}}_old (id, account_holder, account_type, balance)
+	SELECT id, account_holder, account_type, balance FROM {{.Table}};
+DROP TABLE {{.Table}};
+ALTER TABLE {{.Table}}_old RENAME TO {{.Table}};
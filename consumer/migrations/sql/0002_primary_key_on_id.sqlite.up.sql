@@ -0,0 +1,11 @@
+-- SQLite cannot add a primary key to a table, so rebuild it.
+CREATE TABLE {{.Table}}_new (
+	id TEXT NOT NULL PRIMARY KEY,
+	account_holder TEXT,
+	account_type BIGINT,
+	balance DOUBLE PRECISION
+);
+INSERT INTO {{.Table}}_new (id, account_holder, account_type, balance)
+	SELECT id, account_holder, account_type, balance FROM {{.Table}};
+DROP TABLE {{.Table}};
+ALTER TABLE {{.Table}}_new RENAME TO {{.Table}};
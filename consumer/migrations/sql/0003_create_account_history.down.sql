@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{.Table}}_snapshots;
+DROP TABLE IF EXISTS {{.Table}}_events;
@@ -0,0 +1,35 @@
+-- Every handled event, in the order the consumer applied it to the account,
+-- so past balances can be rebuilt after the read model is overwritten.
+-- occurred_at is the Kafka message timestamp as fixed width UTC text, which
+-- sorts the same way in every driver.
+CREATE TABLE IF NOT EXISTS {{.Table}}_events (
+	account_id VARCHAR(191) NOT NULL,
+	sequence BIGINT NOT NULL,
+	event_type VARCHAR(191) NOT NULL,
+	data TEXT NOT NULL,
+	occurred_at VARCHAR(32) NOT NULL,
+	topic VARCHAR(191) NOT NULL,
+	kafka_partition INTEGER NOT NULL,
+	kafka_offset BIGINT NOT NULL,
+	PRIMARY KEY (account_id, sequence)
+);
+
+-- A message is applied once: a redelivered one is skipped, and one applied
+-- concurrently fails to commit.
+CREATE UNIQUE INDEX {{.Table}}_events_position ON {{.Table}}_events (topic, kafka_partition, kafka_offset);
+
+-- The account's state after its first sequence events, and the Kafka
+-- position of the last of them.
+CREATE TABLE IF NOT EXISTS {{.Table}}_snapshots (
+	account_id VARCHAR(191) NOT NULL,
+	sequence BIGINT NOT NULL,
+	account_holder TEXT NOT NULL,
+	account_type BIGINT NOT NULL,
+	balance DOUBLE PRECISION NOT NULL,
+	closed BOOLEAN NOT NULL,
+	occurred_at VARCHAR(32) NOT NULL,
+	topic VARCHAR(191) NOT NULL,
+	kafka_partition INTEGER NOT NULL,
+	kafka_offset BIGINT NOT NULL,
+	PRIMARY KEY (account_id, sequence)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS {{.Table}}_failed_messages;
@@ -0,0 +1,17 @@
+-- Messages the consumer gave up on after its last attempt, as read from
+-- Kafka, so they can be redriven once the cause is fixed. value is the
+-- payload in base64, since Avro payloads are binary, and headers is a JSON
+-- object. failed_at is fixed width UTC text like occurred_at.
+CREATE TABLE IF NOT EXISTS {{.Table}}_failed_messages (
+	topic VARCHAR(191) NOT NULL,
+	kafka_partition INTEGER NOT NULL,
+	kafka_offset BIGINT NOT NULL,
+	kafka_timestamp VARCHAR(32) NOT NULL,
+	message_key TEXT NOT NULL,
+	value TEXT NOT NULL,
+	headers TEXT NOT NULL,
+	error TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	failed_at VARCHAR(32) NOT NULL,
+	PRIMARY KEY (topic, kafka_partition, kafka_offset)
+);
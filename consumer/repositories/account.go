@@ -1,6 +1,10 @@
 package repositories
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
 
 type BankAccount struct {
 	ID            string
@@ -10,35 +14,40 @@ type BankAccount struct {
 }
 
 type IAccountRepository interface {
-	Save(bankAccount BankAccount) error
-	Delete(id string) error
-	FindAll() (bankAccounts []BankAccount, err error)
-	FindByID(id string) (bankAccount BankAccount, err error)
+	Save(ctx context.Context, bankAccount BankAccount) error
+	Delete(ctx context.Context, id string) error
+	FindAll(ctx context.Context) (bankAccounts []BankAccount, err error)
+	FindByID(ctx context.Context, id string) (bankAccount BankAccount, err error)
 }
 
+// DefaultTable is the table the read model has always used.
+const DefaultTable = "bond_banks"
+
 type accountRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	table string
 }
 
-func NewAccountRepository(db *gorm.DB) IAccountRepository {
-	db.Table("bond_banks").AutoMigrate(&BankAccount{})
-	return accountRepository{db}
+// NewAccountRepository reads and writes table, which the migrations in
+// consumer/migrations create.
+func NewAccountRepository(db *gorm.DB, table string) IAccountRepository {
+	return accountRepository{db, table}
 }
 
-func (obj accountRepository) Save(bankAccount BankAccount) error {
-	return obj.db.Table("bond_banks").Save(bankAccount).Error
+func (obj accountRepository) Save(ctx context.Context, bankAccount BankAccount) error {
+	return conn(ctx, obj.db).Table(obj.table).Save(bankAccount).Error
 }
 
-func (obj accountRepository) Delete(id string) error {
-	return obj.db.Table("bond_banks").Where("id=?", id).Delete(&BankAccount{}).Error
+func (obj accountRepository) Delete(ctx context.Context, id string) error {
+	return conn(ctx, obj.db).Table(obj.table).Where("id=?", id).Delete(&BankAccount{}).Error
 }
 
-func (obj accountRepository) FindAll() (bankAccounts []BankAccount, err error) {
-	err = obj.db.Table("bond_banks").Find(&bankAccounts).Error
+func (obj accountRepository) FindAll(ctx context.Context) (bankAccounts []BankAccount, err error) {
+	err = conn(ctx, obj.db).Table(obj.table).Find(&bankAccounts).Error
 	return bankAccounts, err
 }
 
-func (obj accountRepository) FindByID(id string) (bankAccount BankAccount, err error) {
-	err = obj.db.Table("bond_banks").Where("id=?", id).First(&bankAccount).Error
+func (obj accountRepository) FindByID(ctx context.Context, id string) (bankAccount BankAccount, err error) {
+	err = conn(ctx, obj.db).Table(obj.table).Where("id=?", id).First(&bankAccount).Error
 	return bankAccount, err
 }
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountBatch is what a batch of events changes: the accounts to upsert
+// and delete, and the events to append to their histories, in order.
+type AccountBatch struct {
+	Saves   []BankAccount
+	Deletes []string
+	Events  []AccountEvent
+}
+
+type IBatchRepository interface {
+	// Apply reads the accounts among ids that exist, passes them to fold
+	// and writes the batch it returns, all in one transaction. It returns
+	// the batch's events with their sequences once committed.
+	Apply(ctx context.Context, ids []string, fold func(bankAccounts map[string]BankAccount) (AccountBatch, error)) ([]AccountEvent, error)
+}
+
+type batchRepository struct {
+	db          *gorm.DB
+	table       string
+	eventsTable string
+}
+
+// NewBatchRepository writes the accounts in table and their history in
+// <table>_events, like NewAccountRepository and NewHistoryRepository.
+func NewBatchRepository(db *gorm.DB, table string) IBatchRepository {
+	return batchRepository{db, table, table + "_events"}
+}
+
+func (obj batchRepository) Apply(ctx context.Context, ids []string, fold func(bankAccounts map[string]BankAccount) (AccountBatch, error)) ([]AccountEvent, error) {
+	var accountEvents []AccountEvent
+	err := conn(ctx, obj.db).Transaction(func(tx *gorm.DB) error {
+		var found []BankAccount
+		err := tx.Table(obj.table).Where("id IN ?", ids).Find(&found).Error
+		if err != nil {
+			return err
+		}
+		bankAccounts := map[string]BankAccount{}
+		for _, bankAccount := range found {
+			bankAccounts[bankAccount.ID] = bankAccount
+		}
+
+		batch, err := fold(bankAccounts)
+		if err != nil {
+			return err
+		}
+
+		if len(batch.Saves) > 0 {
+			err = tx.Table(obj.table).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				UpdateAll: true,
+			}).Create(&batch.Saves).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(batch.Deletes) > 0 {
+			err = tx.Table(obj.table).Where("id IN ?", batch.Deletes).Delete(&BankAccount{}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		accountEvents, err = obj.append(tx, batch.Events)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accountEvents, nil
+}
+
+// append numbers the events after the last sequence of their accounts and
+// inserts them at once.
+func (obj batchRepository) append(tx *gorm.DB, accountEvents []AccountEvent) ([]AccountEvent, error) {
+	if len(accountEvents) == 0 {
+		return nil, nil
+	}
+
+	accountIDs := []string{}
+	for _, event := range accountEvents {
+		accountIDs = append(accountIDs, event.AccountID)
+	}
+	var last []struct {
+		AccountID string
+		Sequence  int
+	}
+	err := tx.Table(obj.eventsTable).Where("account_id IN ?", accountIDs).
+		Select("account_id, MAX(sequence) AS sequence").Group("account_id").Scan(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	sequences := map[string]int{}
+	for _, row := range last {
+		sequences[row.AccountID] = row.Sequence
+	}
+
+	rows := []accountEventRow{}
+	for i := range accountEvents {
+		event := &accountEvents[i]
+		sequences[event.AccountID]++
+		event.Sequence = sequences[event.AccountID]
+		event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+		rows = append(rows, accountEventRow{
+			AccountID:      event.AccountID,
+			Sequence:       event.Sequence,
+			EventType:      event.EventType,
+			Data:           event.Data,
+			OccurredAt:     event.OccurredAt.Format(occurredAtLayout),
+			Topic:          event.Topic,
+			KafkaPartition: event.Partition,
+			KafkaOffset:    event.Offset,
+		})
+	}
+	return accountEvents, tx.Table(obj.eventsTable).Create(&rows).Error
+}
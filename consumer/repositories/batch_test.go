@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_batchRepository_Apply(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Test should upsert, delete and append in one go", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("batch_apply")
+		accountRepo := NewAccountRepository(db, DefaultTable)
+		historyRepo := NewHistoryRepository(db, DefaultTable)
+		assert.NoError(t, accountRepo.Save(ctx, BankAccount{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000}))
+		assert.NoError(t, accountRepo.Save(ctx, BankAccount{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 500}))
+		_, err := historyRepo.Append(ctx, AccountEvent{AccountID: "123", EventType: "OpenAccountEvent", Data: `{}`, OccurredAt: day})
+		assert.NoError(t, err)
+
+		var found map[string]BankAccount
+		accountEvents, err := NewBatchRepository(db, DefaultTable).Apply(ctx, []string{"123", "456", "789"}, func(bankAccounts map[string]BankAccount) (AccountBatch, error) {
+			found = bankAccounts
+			return AccountBatch{
+				Saves: []BankAccount{
+					{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1500},
+					{ID: "789", AccountHolder: "Max Doe", AccountType: 1, Balance: 10},
+				},
+				Deletes: []string{"456"},
+				Events: []AccountEvent{
+					{AccountID: "123", EventType: "DepositFundEvent", Data: `{}`, OccurredAt: day.Add(time.Hour), Offset: 1},
+					{AccountID: "789", EventType: "OpenAccountEvent", Data: `{}`, OccurredAt: day.Add(time.Hour), Offset: 2},
+					{AccountID: "123", EventType: "DepositFundEvent", Data: `{}`, OccurredAt: day.Add(time.Hour), Offset: 3},
+				},
+			}, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"123", "456"}, accountIDs(found))
+		assert.Equal(t, []int{2, 1, 3}, []int{accountEvents[0].Sequence, accountEvents[1].Sequence, accountEvents[2].Sequence})
+		bankAccounts, err := accountRepo.FindAll(ctx)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []BankAccount{
+			{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1500},
+			{ID: "789", AccountHolder: "Max Doe", AccountType: 1, Balance: 10},
+		}, bankAccounts)
+		history, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, history, 3)
+	})
+
+	t.Run("Test should write nothing when fold fails", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("batch_apply_fold_error")
+		accountRepo := NewAccountRepository(db, DefaultTable)
+		assert.NoError(t, accountRepo.Save(ctx, BankAccount{ID: "123", Balance: 1000}))
+
+		_, err := NewBatchRepository(db, DefaultTable).Apply(ctx, []string{"123"}, func(bankAccounts map[string]BankAccount) (AccountBatch, error) {
+			return AccountBatch{}, errors.New("fold failed")
+		})
+
+		assert.EqualError(t, err, "fold failed")
+		bankAccount, err := accountRepo.FindByID(ctx, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1000), bankAccount.Balance)
+	})
+
+	t.Run("Test should roll back the accounts when appending fails", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("batch_apply_append_error")
+		accountRepo := NewAccountRepository(db, DefaultTable)
+		assert.NoError(t, accountRepo.Save(ctx, BankAccount{ID: "123", Balance: 1000}))
+		assert.NoError(t, db.Migrator().DropTable(DefaultTable+"_events"))
+
+		_, err := NewBatchRepository(db, DefaultTable).Apply(ctx, []string{"123"}, func(bankAccounts map[string]BankAccount) (AccountBatch, error) {
+			return AccountBatch{
+				Deletes: []string{"123"},
+				Events:  []AccountEvent{{AccountID: "123", EventType: "CloseAccountEvent", Data: `{}`, OccurredAt: day}},
+			}, nil
+		})
+
+		assert.Error(t, err)
+		_, err = accountRepo.FindByID(ctx, "123")
+		assert.NoError(t, err)
+	})
+}
+
+func accountIDs(bankAccounts map[string]BankAccount) []string {
+	ids := []string{}
+	for id := range bankAccounts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,81 @@
+// Package conformance holds the behaviour every IAccountRepository backend
+// must share, so the services can run on any of them.
+package conformance
+
+import (
+	"consumer/repositories"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Run checks the repository returned by newRepository, which must be empty
+// on every call.
+func Run(t *testing.T, newRepository func(t *testing.T) repositories.IAccountRepository) {
+	ctx := context.Background()
+	johnDoe := repositories.BankAccount{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000}
+	janeDoe := repositories.BankAccount{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 500}
+
+	t.Run("Test should find saved bank account by id", func(t *testing.T) {
+		accountRepo := newRepository(t)
+		assert.NoError(t, accountRepo.Save(ctx, johnDoe))
+
+		bankAccount, err := accountRepo.FindByID(ctx, "123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, johnDoe, bankAccount)
+	})
+
+	t.Run("Test should update bank account saved again with the same id", func(t *testing.T) {
+		accountRepo := newRepository(t)
+		assert.NoError(t, accountRepo.Save(ctx, johnDoe))
+		updated := johnDoe
+		updated.Balance = 3000
+		assert.NoError(t, accountRepo.Save(ctx, updated))
+
+		bankAccounts, err := accountRepo.FindAll(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []repositories.BankAccount{updated}, bankAccounts)
+	})
+
+	t.Run("Test should return record not found for unknown id", func(t *testing.T) {
+		accountRepo := newRepository(t)
+
+		_, err := accountRepo.FindByID(ctx, "missing")
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Test should find all bank accounts", func(t *testing.T) {
+		accountRepo := newRepository(t)
+
+		bankAccounts, err := accountRepo.FindAll(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, bankAccounts)
+
+		assert.NoError(t, accountRepo.Save(ctx, johnDoe))
+		assert.NoError(t, accountRepo.Save(ctx, janeDoe))
+		bankAccounts, err = accountRepo.FindAll(ctx)
+
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []repositories.BankAccount{johnDoe, janeDoe}, bankAccounts)
+	})
+
+	t.Run("Test should delete only the bank account with the id", func(t *testing.T) {
+		accountRepo := newRepository(t)
+		assert.NoError(t, accountRepo.Save(ctx, johnDoe))
+		assert.NoError(t, accountRepo.Save(ctx, janeDoe))
+
+		assert.NoError(t, accountRepo.Delete(ctx, "123"))
+		assert.NoError(t, accountRepo.Delete(ctx, "missing"))
+
+		_, err := accountRepo.FindByID(ctx, "123")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		bankAccounts, err := accountRepo.FindAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []repositories.BankAccount{janeDoe}, bankAccounts)
+	})
+}
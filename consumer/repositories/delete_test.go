@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accountRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockRecords       []BankAccount
+		mockRepoRequest   string
+		wantRepoError     error
+		wantStoredRecords []BankAccount
+	}{
+		{
+			name: "Test should delete only the bank account matching id",
+			mockRecords: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+			mockRepoRequest: "123",
+			wantStoredRecords: []BankAccount{
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+		},
+		{
+			name: "Test should return nil when id does not exist",
+			mockRecords: []BankAccount{
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+			mockRepoRequest: "123",
+			wantStoredRecords: []BankAccount{
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("delete_%d", i))
+			accountRepo := NewAccountRepository(db, DefaultTable)
+			for _, record := range test.mockRecords {
+				assert.NoError(t, db.Table("bond_banks").Create(&record).Error)
+			}
+
+			err := accountRepo.Delete(context.Background(), test.mockRepoRequest)
+
+			assert.Equal(t, test.wantRepoError, err)
+
+			var storedRecords []BankAccount
+			db.Table("bond_banks").Find(&storedRecords)
+			assert.Equal(t, test.wantStoredRecords, storedRecords)
+		})
+	}
+}
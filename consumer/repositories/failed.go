@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FailedMessage is a message the consumer gave up handling, as it was read
+// from Kafka, with the error of its last attempt.
+type FailedMessage struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Error     string
+	Attempts  int
+	FailedAt  time.Time
+}
+
+type IFailedMessageRepository interface {
+	// Save records message, replacing what was recorded for its position.
+	Save(ctx context.Context, message FailedMessage) error
+	// FindAll returns the failed messages by topic, partition and offset.
+	FindAll(ctx context.Context) ([]FailedMessage, error)
+	// FindByPosition returns the message that failed at position, or
+	// gorm.ErrRecordNotFound.
+	FindByPosition(ctx context.Context, position Position) (FailedMessage, error)
+	// Delete forgets the message that failed at position.
+	Delete(ctx context.Context, position Position) error
+}
+
+type failedMessageRow struct {
+	Topic          string
+	KafkaPartition int32
+	KafkaOffset    int64
+	KafkaTimestamp string
+	MessageKey     string
+	Value          string
+	Headers        string
+	Error          string
+	Attempts       int
+	FailedAt       string
+}
+
+type failedMessageRepository struct {
+	db    *gorm.DB
+	table string
+}
+
+// NewFailedMessageRepository keeps the failed messages of the consumer of
+// table in <table>_failed_messages.
+func NewFailedMessageRepository(db *gorm.DB, table string) IFailedMessageRepository {
+	return failedMessageRepository{db, table + "_failed_messages"}
+}
+
+func (obj failedMessageRepository) Save(ctx context.Context, message FailedMessage) error {
+	if message.Headers == nil {
+		message.Headers = map[string]string{}
+	}
+	headers, err := json.Marshal(message.Headers)
+	if err != nil {
+		return err
+	}
+	return conn(ctx, obj.db).Table(obj.table).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "topic"}, {Name: "kafka_partition"}, {Name: "kafka_offset"}},
+		UpdateAll: true,
+	}).Create(&failedMessageRow{
+		Topic:          message.Topic,
+		KafkaPartition: message.Partition,
+		KafkaOffset:    message.Offset,
+		KafkaTimestamp: message.Timestamp.UTC().Format(occurredAtLayout),
+		MessageKey:     string(message.Key),
+		Value:          base64.StdEncoding.EncodeToString(message.Value),
+		Headers:        string(headers),
+		Error:          message.Error,
+		Attempts:       message.Attempts,
+		FailedAt:       message.FailedAt.UTC().Format(occurredAtLayout),
+	}).Error
+}
+
+func (obj failedMessageRepository) FindAll(ctx context.Context) ([]FailedMessage, error) {
+	var rows []failedMessageRow
+	err := conn(ctx, obj.db).Table(obj.table).Order("topic, kafka_partition, kafka_offset").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []FailedMessage{}
+	for _, row := range rows {
+		message, err := row.message()
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (obj failedMessageRepository) FindByPosition(ctx context.Context, position Position) (FailedMessage, error) {
+	row := failedMessageRow{}
+	err := conn(ctx, obj.db).Table(obj.table).
+		Where("topic = ? AND kafka_partition = ? AND kafka_offset = ?", position.Topic, position.Partition, position.Offset).
+		First(&row).Error
+	if err != nil {
+		return FailedMessage{}, err
+	}
+	return row.message()
+}
+
+func (obj failedMessageRepository) Delete(ctx context.Context, position Position) error {
+	return conn(ctx, obj.db).Table(obj.table).
+		Where("topic = ? AND kafka_partition = ? AND kafka_offset = ?", position.Topic, position.Partition, position.Offset).
+		Delete(&failedMessageRow{}).Error
+}
+
+func (obj failedMessageRow) message() (FailedMessage, error) {
+	timestamp, err := time.Parse(occurredAtLayout, obj.KafkaTimestamp)
+	if err != nil {
+		return FailedMessage{}, err
+	}
+	failedAt, err := time.Parse(occurredAtLayout, obj.FailedAt)
+	if err != nil {
+		return FailedMessage{}, err
+	}
+	value, err := base64.StdEncoding.DecodeString(obj.Value)
+	if err != nil {
+		return FailedMessage{}, err
+	}
+	headers := map[string]string{}
+	err = json.Unmarshal([]byte(obj.Headers), &headers)
+	if err != nil {
+		return FailedMessage{}, err
+	}
+
+	message := FailedMessage{
+		Topic:     obj.Topic,
+		Partition: obj.KafkaPartition,
+		Offset:    obj.KafkaOffset,
+		Timestamp: timestamp,
+		Value:     value,
+		Headers:   headers,
+		Error:     obj.Error,
+		Attempts:  obj.Attempts,
+		FailedAt:  failedAt,
+	}
+	if obj.MessageKey != "" {
+		message.Key = []byte(obj.MessageKey)
+	}
+	return message, nil
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_failedMessageRepository(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	failedRepo := NewFailedMessageRepository(internal.OpenSQLiteDB("failed_messages"), DefaultTable)
+	deposit := FailedMessage{
+		Topic:     "DepositFundEvent",
+		Partition: 2,
+		Offset:    7,
+		Timestamp: day,
+		Key:       []byte("123"),
+		Value:     []byte{0, 0, 0, 0, 1, 0xff},
+		Headers:   map[string]string{"content-type": "application/vnd.confluent.avro"},
+		Error:     "record not found",
+		Attempts:  3,
+		FailedAt:  day.Add(time.Minute),
+	}
+	opened := FailedMessage{Topic: "OpenAccountEvent", Value: []byte(`{"ID":"456"`), Headers: map[string]string{}, Error: "unexpected end of JSON input", Attempts: 1, Timestamp: day, FailedAt: day}
+
+	t.Run("Test should return the failed messages by position", func(t *testing.T) {
+		assert.NoError(t, failedRepo.Save(ctx, opened))
+		assert.NoError(t, failedRepo.Save(ctx, deposit))
+
+		messages, err := failedRepo.FindAll(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []FailedMessage{deposit, opened}, messages)
+	})
+
+	t.Run("Test should replace a message failing again", func(t *testing.T) {
+		again := deposit
+		again.Error = "database is locked"
+		again.FailedAt = day.Add(time.Hour)
+
+		assert.NoError(t, failedRepo.Save(ctx, again))
+
+		messages, err := failedRepo.FindAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []FailedMessage{again, opened}, messages)
+	})
+
+	t.Run("Test should find and forget a message by position", func(t *testing.T) {
+		position := Position{Topic: "DepositFundEvent", Partition: 2, Offset: 7}
+		message, err := failedRepo.FindByPosition(ctx, position)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), message.Offset)
+
+		assert.NoError(t, failedRepo.Delete(ctx, position))
+
+		_, err = failedRepo.FindByPosition(ctx, position)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		messages, err := failedRepo.FindAll(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []FailedMessage{opened}, messages)
+	})
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accountRepository_FindAll(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockRecords      []BankAccount
+		wantRepoError    error
+		wantRepoResponse []BankAccount
+	}{
+		{
+			name: "Test should return empty when there is no bank account",
+		},
+		{
+			name: "Test should return every bank account",
+			mockRecords: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+			wantRepoResponse: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("find_all_%d", i))
+			accountRepo := NewAccountRepository(db, DefaultTable)
+			for _, record := range test.mockRecords {
+				assert.NoError(t, db.Table("bond_banks").Create(&record).Error)
+			}
+
+			response, err := accountRepo.FindAll(context.Background())
+
+			assert.Equal(t, test.wantRepoError, err)
+			assert.ElementsMatch(t, test.wantRepoResponse, response)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_accountRepository_FindByID(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockRecords      []BankAccount
+		mockRepoRequest  string
+		wantRepoError    error
+		wantRepoResponse BankAccount
+	}{
+		{
+			name:            "Test should return record not found when id does not exist",
+			mockRepoRequest: "123",
+			wantRepoError:   gorm.ErrRecordNotFound,
+		},
+		{
+			name: "Test should return bank account matching id",
+			mockRecords: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+			},
+			mockRepoRequest: "456",
+			wantRepoResponse: BankAccount{
+				ID:            "456",
+				AccountHolder: "Jane Doe",
+				AccountType:   2,
+				Balance:       2000,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("find_by_id_%d", i))
+			accountRepo := NewAccountRepository(db, DefaultTable)
+			for _, record := range test.mockRecords {
+				assert.NoError(t, db.Table("bond_banks").Create(&record).Error)
+			}
+
+			response, err := accountRepo.FindByID(context.Background(), test.mockRepoRequest)
+
+			assert.ErrorIs(t, err, test.wantRepoError)
+			assert.Equal(t, test.wantRepoResponse, response)
+		})
+	}
+}
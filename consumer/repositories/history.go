@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// occurredAtLayout is fixed width UTC, so comparing the stored text
+// compares the times.
+const occurredAtLayout = "2006-01-02T15:04:05.000000Z"
+
+// AccountEvent is one event in an account's history.
+type AccountEvent struct {
+	AccountID string
+	// Sequence numbers the account's events from 1 in the order they were
+	// applied to the read model.
+	Sequence   int
+	EventType  string
+	Data       string
+	OccurredAt time.Time
+	Topic      string
+	Partition  int32
+	Offset     int64
+}
+
+// Position is where an event was read from Kafka. Each is in the history
+// at most once.
+type Position struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+}
+
+// AccountSnapshot is an account's state after its first Sequence events,
+// with the Kafka position of the last of them.
+type AccountSnapshot struct {
+	AccountID     string
+	Sequence      int
+	AccountHolder string
+	AccountType   int
+	Balance       float64
+	Closed        bool
+	OccurredAt    time.Time
+	Topic         string
+	Partition     int32
+	Offset        int64
+}
+
+type IHistoryRepository interface {
+	// Append stores event as the account's next one and returns it with its
+	// sequence.
+	Append(ctx context.Context, event AccountEvent) (AccountEvent, error)
+	// Recorded returns which of positions some event in the history was
+	// read from, so a redelivered message is not applied twice.
+	Recorded(ctx context.Context, positions []Position) (map[Position]bool, error)
+	SaveSnapshot(ctx context.Context, snapshot AccountSnapshot) error
+	// LatestSnapshot returns the account's last snapshot as of asOf, or
+	// gorm.ErrRecordNotFound. As of asOf, the history is the account's
+	// events in sequence order up to the first that occurred after asOf, so
+	// the state it adds up to is one the account was in even when messages
+	// of other partitions came in out of timestamp order. A zero asOf means
+	// no time limit, as for Events.
+	LatestSnapshot(ctx context.Context, accountID string, asOf time.Time) (AccountSnapshot, error)
+	// Events returns the account's events after afterSequence as of asOf,
+	// in sequence order.
+	Events(ctx context.Context, accountID string, afterSequence int, asOf time.Time) ([]AccountEvent, error)
+}
+
+type accountEventRow struct {
+	AccountID      string
+	Sequence       int
+	EventType      string
+	Data           string
+	OccurredAt     string
+	Topic          string
+	KafkaPartition int32
+	KafkaOffset    int64
+}
+
+type accountSnapshotRow struct {
+	AccountID      string
+	Sequence       int
+	AccountHolder  string
+	AccountType    int
+	Balance        float64
+	Closed         bool
+	OccurredAt     string
+	Topic          string
+	KafkaPartition int32
+	KafkaOffset    int64
+}
+
+type historyRepository struct {
+	db             *gorm.DB
+	eventsTable    string
+	snapshotsTable string
+}
+
+// NewHistoryRepository keeps the history of the accounts in table, in the
+// tables <table>_events and <table>_snapshots.
+func NewHistoryRepository(db *gorm.DB, table string) IHistoryRepository {
+	return historyRepository{db, table + "_events", table + "_snapshots"}
+}
+
+func (obj historyRepository) Append(ctx context.Context, event AccountEvent) (AccountEvent, error) {
+	event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+	err := conn(ctx, obj.db).Transaction(func(tx *gorm.DB) error {
+		var sequence int
+		err := tx.Table(obj.eventsTable).Where("account_id = ?", event.AccountID).Select("COALESCE(MAX(sequence), 0)").Scan(&sequence).Error
+		if err != nil {
+			return err
+		}
+		event.Sequence = sequence + 1
+
+		return tx.Table(obj.eventsTable).Create(&accountEventRow{
+			AccountID:      event.AccountID,
+			Sequence:       event.Sequence,
+			EventType:      event.EventType,
+			Data:           event.Data,
+			OccurredAt:     event.OccurredAt.Format(occurredAtLayout),
+			Topic:          event.Topic,
+			KafkaPartition: event.Partition,
+			KafkaOffset:    event.Offset,
+		}).Error
+	})
+	if err != nil {
+		return AccountEvent{}, err
+	}
+	return event, nil
+}
+
+func (obj historyRepository) Recorded(ctx context.Context, positions []Position) (map[Position]bool, error) {
+	recorded := map[Position]bool{}
+	if len(positions) == 0 {
+		return recorded, nil
+	}
+
+	values := [][]interface{}{}
+	for _, position := range positions {
+		values = append(values, []interface{}{position.Topic, position.Partition, position.Offset})
+	}
+	var rows []accountEventRow
+	err := conn(ctx, obj.db).Table(obj.eventsTable).Select("topic, kafka_partition, kafka_offset").
+		Where("(topic, kafka_partition, kafka_offset) IN ?", values).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		recorded[Position{row.Topic, row.KafkaPartition, row.KafkaOffset}] = true
+	}
+	return recorded, nil
+}
+
+func (obj historyRepository) SaveSnapshot(ctx context.Context, snapshot AccountSnapshot) error {
+	return conn(ctx, obj.db).Table(obj.snapshotsTable).Create(&accountSnapshotRow{
+		AccountID:      snapshot.AccountID,
+		Sequence:       snapshot.Sequence,
+		AccountHolder:  snapshot.AccountHolder,
+		AccountType:    snapshot.AccountType,
+		Balance:        snapshot.Balance,
+		Closed:         snapshot.Closed,
+		OccurredAt:     snapshot.OccurredAt.UTC().Format(occurredAtLayout),
+		Topic:          snapshot.Topic,
+		KafkaPartition: snapshot.Partition,
+		KafkaOffset:    snapshot.Offset,
+	}).Error
+}
+
+func (obj historyRepository) LatestSnapshot(ctx context.Context, accountID string, asOf time.Time) (AccountSnapshot, error) {
+	query := obj.asOf(ctx, conn(ctx, obj.db).Table(obj.snapshotsTable).Where("account_id = ?", accountID), accountID, asOf)
+	row := accountSnapshotRow{}
+	err := query.Order("sequence DESC").First(&row).Error
+	if err != nil {
+		return AccountSnapshot{}, err
+	}
+
+	occurredAt, err := time.Parse(occurredAtLayout, row.OccurredAt)
+	if err != nil {
+		return AccountSnapshot{}, err
+	}
+	return AccountSnapshot{
+		AccountID:     row.AccountID,
+		Sequence:      row.Sequence,
+		AccountHolder: row.AccountHolder,
+		AccountType:   row.AccountType,
+		Balance:       row.Balance,
+		Closed:        row.Closed,
+		OccurredAt:    occurredAt,
+		Topic:         row.Topic,
+		Partition:     row.KafkaPartition,
+		Offset:        row.KafkaOffset,
+	}, nil
+}
+
+func (obj historyRepository) Events(ctx context.Context, accountID string, afterSequence int, asOf time.Time) ([]AccountEvent, error) {
+	query := obj.asOf(ctx, conn(ctx, obj.db).Table(obj.eventsTable).Where("account_id = ? AND sequence > ?", accountID, afterSequence), accountID, asOf)
+	var rows []accountEventRow
+	err := query.Order("sequence").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var accountEvents []AccountEvent
+	for _, row := range rows {
+		occurredAt, err := time.Parse(occurredAtLayout, row.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+		accountEvents = append(accountEvents, AccountEvent{
+			AccountID:  row.AccountID,
+			Sequence:   row.Sequence,
+			EventType:  row.EventType,
+			Data:       row.Data,
+			OccurredAt: occurredAt,
+			Topic:      row.Topic,
+			Partition:  row.KafkaPartition,
+			Offset:     row.KafkaOffset,
+		})
+	}
+	return accountEvents, nil
+}
+
+// asOf limits query to the sequences before the account's first event that
+// occurred after asOf, so the events before a later one stay in sequence
+// order rather than by timestamp.
+func (obj historyRepository) asOf(ctx context.Context, query *gorm.DB, accountID string, asOf time.Time) *gorm.DB {
+	if asOf.IsZero() {
+		return query
+	}
+	later := conn(ctx, obj.db).Table(obj.eventsTable).Select("MIN(sequence)").
+		Where("account_id = ? AND occurred_at > ?", accountID, asOf.UTC().Format(occurredAtLayout))
+	return query.Where("sequence < COALESCE((?), ?)", later, math.MaxInt32)
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Test_historyRepository(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	historyRepo := NewHistoryRepository(internal.OpenSQLiteDB("history"), DefaultTable)
+
+	for i, occurredAt := range []time.Time{day, day.Add(time.Hour), day.Add(2 * time.Hour)} {
+		event, err := historyRepo.Append(ctx, AccountEvent{AccountID: "123", EventType: "DepositFundEvent", Data: `{"ID":"123","Amount":100}`, OccurredAt: occurredAt, Topic: "DepositFundEvent", Offset: int64(i)})
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, event.Sequence)
+	}
+	event, err := historyRepo.Append(ctx, AccountEvent{AccountID: "456", EventType: "CloseAccountEvent", Data: `{"ID":"456"}`, OccurredAt: day.In(time.FixedZone("UTC+7", 7*60*60))})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, event.Sequence)
+	assert.Equal(t, day, event.OccurredAt)
+
+	t.Run("Test should return events after a sequence up to a time", func(t *testing.T) {
+		accountEvents, err := historyRepo.Events(ctx, "123", 1, day.Add(90*time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []AccountEvent{
+			{AccountID: "123", Sequence: 2, EventType: "DepositFundEvent", Data: `{"ID":"123","Amount":100}`, OccurredAt: day.Add(time.Hour), Topic: "DepositFundEvent", Offset: 1},
+		}, accountEvents)
+	})
+
+	t.Run("Test should return every event without a time", func(t *testing.T) {
+		accountEvents, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+
+		assert.NoError(t, err)
+		assert.Len(t, accountEvents, 3)
+	})
+
+	t.Run("Test should return the latest snapshot up to a time", func(t *testing.T) {
+		_, err := historyRepo.LatestSnapshot(ctx, "123", time.Time{})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		first := AccountSnapshot{AccountID: "123", Sequence: 1, AccountHolder: "John Doe", AccountType: 1, Balance: 1000, OccurredAt: day, Topic: "DepositFundEvent"}
+		second := AccountSnapshot{AccountID: "123", Sequence: 2, AccountHolder: "John Doe", AccountType: 1, Balance: 1100, OccurredAt: day.Add(time.Hour), Topic: "DepositFundEvent", Offset: 1}
+		assert.NoError(t, historyRepo.SaveSnapshot(ctx, first))
+		assert.NoError(t, historyRepo.SaveSnapshot(ctx, second))
+
+		snapshot, err := historyRepo.LatestSnapshot(ctx, "123", day.Add(59*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, first, snapshot)
+		snapshot, err = historyRepo.LatestSnapshot(ctx, "123", time.Time{})
+		assert.NoError(t, err)
+		assert.Equal(t, second, snapshot)
+		_, err = historyRepo.LatestSnapshot(ctx, "123", day.Add(-time.Second))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Test should stop at the first event after a time in sequence order", func(t *testing.T) {
+		historyRepo := NewHistoryRepository(internal.OpenSQLiteDB("history_out_of_order"), DefaultTable)
+		// the third event was applied after the second but stamped before it
+		for i, occurredAt := range []time.Time{day, day.Add(2 * time.Hour), day.Add(time.Hour)} {
+			_, err := historyRepo.Append(ctx, AccountEvent{AccountID: "789", EventType: "DepositFundEvent", Data: `{"ID":"789","Amount":100}`, OccurredAt: occurredAt, Topic: "DepositFundEvent", Partition: 1, Offset: int64(i)})
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, historyRepo.SaveSnapshot(ctx, AccountSnapshot{AccountID: "789", Sequence: 3, Balance: 300, OccurredAt: day.Add(time.Hour), Topic: "DepositFundEvent", Partition: 1, Offset: 2}))
+
+		accountEvents, err := historyRepo.Events(ctx, "789", 0, day.Add(90*time.Minute))
+		assert.NoError(t, err)
+		assert.Len(t, accountEvents, 1)
+		assert.Equal(t, 1, accountEvents[0].Sequence)
+		_, err = historyRepo.LatestSnapshot(ctx, "789", day.Add(90*time.Minute))
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		snapshot, err := historyRepo.LatestSnapshot(ctx, "789", day.Add(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, snapshot.Sequence)
+	})
+
+	t.Run("Test should return the positions already in the history", func(t *testing.T) {
+		recorded, err := historyRepo.Recorded(ctx, []Position{
+			{Topic: "DepositFundEvent", Offset: 1},
+			{Topic: "DepositFundEvent", Offset: 3},
+			{Topic: "DepositFundEvent", Partition: 1, Offset: 1},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[Position]bool{{Topic: "DepositFundEvent", Offset: 1}: true}, recorded)
+	})
+
+	t.Run("Test should refuse a second event from the same position", func(t *testing.T) {
+		_, err := historyRepo.Append(ctx, AccountEvent{AccountID: "789", EventType: "DepositFundEvent", Data: `{"ID":"789","Amount":100}`, OccurredAt: day, Topic: "DepositFundEvent", Offset: 2})
+
+		assert.Error(t, err)
+		accountEvents, err := historyRepo.Events(ctx, "789", 0, time.Time{})
+		assert.NoError(t, err)
+		assert.Empty(t, accountEvents)
+	})
+}
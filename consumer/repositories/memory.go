@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type memoryAccountRepository struct {
+	mutex        *sync.RWMutex
+	bankAccounts map[string]BankAccount
+}
+
+// NewMemoryAccountRepository keeps accounts in a map, for tests and demos
+// that run without a database. It returns the same errors as the gorm
+// repository, so callers can check gorm.ErrRecordNotFound for both.
+func NewMemoryAccountRepository() IAccountRepository {
+	return memoryAccountRepository{&sync.RWMutex{}, map[string]BankAccount{}}
+}
+
+func (obj memoryAccountRepository) Save(ctx context.Context, bankAccount BankAccount) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	obj.bankAccounts[bankAccount.ID] = bankAccount
+	return nil
+}
+
+func (obj memoryAccountRepository) Delete(ctx context.Context, id string) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	delete(obj.bankAccounts, id)
+	return nil
+}
+
+func (obj memoryAccountRepository) FindAll(ctx context.Context) (bankAccounts []BankAccount, err error) {
+	obj.mutex.RLock()
+	defer obj.mutex.RUnlock()
+	for _, bankAccount := range obj.bankAccounts {
+		bankAccounts = append(bankAccounts, bankAccount)
+	}
+	sort.Slice(bankAccounts, func(i, j int) bool { return bankAccounts[i].ID < bankAccounts[j].ID })
+	return bankAccounts, nil
+}
+
+func (obj memoryAccountRepository) FindByID(ctx context.Context, id string) (bankAccount BankAccount, err error) {
+	obj.mutex.RLock()
+	defer obj.mutex.RUnlock()
+	bankAccount, ok := obj.bankAccounts[id]
+	if !ok {
+		return BankAccount{}, gorm.ErrRecordNotFound
+	}
+	return bankAccount, nil
+}
@@ -0,0 +1,7 @@
+package mockRepo
+
+import "github.com/stretchr/testify/mock"
+
+func (m *IAccountRepository) ClearAll() {
+	m.Mock = mock.Mock{}
+}
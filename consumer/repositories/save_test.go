@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accountRepository_Save(t *testing.T) {
+	tests := []struct {
+		name              string
+		mockRecords       []BankAccount
+		mockRepoRequest   BankAccount
+		wantRepoError     error
+		wantStoredRecords []BankAccount
+	}{
+		{
+			name: "Test should insert bank account when id does not exist",
+			mockRepoRequest: BankAccount{
+				ID:            "123",
+				AccountHolder: "John Doe",
+				AccountType:   1,
+				Balance:       1000,
+			},
+			wantStoredRecords: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+			},
+		},
+		{
+			name: "Test should update bank account when id already exists",
+			mockRecords: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+			},
+			mockRepoRequest: BankAccount{
+				ID:            "123",
+				AccountHolder: "John Doe",
+				AccountType:   1,
+				Balance:       3000,
+			},
+			wantStoredRecords: []BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 3000},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("save_%d", i))
+			accountRepo := NewAccountRepository(db, DefaultTable)
+			for _, record := range test.mockRecords {
+				assert.NoError(t, db.Table("bond_banks").Create(&record).Error)
+			}
+
+			err := accountRepo.Save(context.Background(), test.mockRepoRequest)
+
+			assert.Equal(t, test.wantRepoError, err)
+
+			var storedRecords []BankAccount
+			db.Table("bond_banks").Find(&storedRecords)
+			assert.Equal(t, test.wantStoredRecords, storedRecords)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ITransactor interface {
+	// Transaction runs fn in one transaction, committed when fn returns nil
+	// and rolled back otherwise. The repositories of the same database
+	// write in it when given the ctx fn gets.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) ITransactor {
+	return transactor{db}
+}
+
+type txKey struct{}
+
+func (obj transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, obj.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction ctx runs in, or db outside of one.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	if ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"consumer/internal"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_transactor_Transaction(t *testing.T) {
+	ctx := context.Background()
+	db := internal.OpenSQLiteDB("transaction")
+	accountRepo := NewAccountRepository(db, DefaultTable)
+	historyRepo := NewHistoryRepository(db, DefaultTable)
+	write := func(ctx context.Context, id string, offset int64) error {
+		err := accountRepo.Save(ctx, BankAccount{ID: id, AccountHolder: "John Doe", AccountType: 1, Balance: 1000})
+		if err != nil {
+			return err
+		}
+		_, err = historyRepo.Append(ctx, AccountEvent{AccountID: id, EventType: "OpenAccountEvent", Data: `{}`, OccurredAt: time.Now(), Topic: "OpenAccountEvent", Offset: offset})
+		return err
+	}
+
+	t.Run("Test should commit the writes of every repository", func(t *testing.T) {
+		err := NewTransactor(db).Transaction(ctx, func(ctx context.Context) error {
+			return write(ctx, "123", 1)
+		})
+
+		assert.NoError(t, err)
+		_, err = accountRepo.FindByID(ctx, "123")
+		assert.NoError(t, err)
+		accountEvents, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, accountEvents, 1)
+	})
+
+	t.Run("Test should roll back the writes of every repository", func(t *testing.T) {
+		err := NewTransactor(db).Transaction(ctx, func(ctx context.Context) error {
+			err := write(ctx, "456", 2)
+			if err != nil {
+				return err
+			}
+			return errors.New("handling failed")
+		})
+
+		assert.EqualError(t, err, "handling failed")
+		_, err = accountRepo.FindByID(ctx, "456")
+		assert.Error(t, err)
+		accountEvents, err := historyRepo.Events(ctx, "456", 0, time.Time{})
+		assert.NoError(t, err)
+		assert.Empty(t, accountEvents)
+	})
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"consumer/repositories"
+	"context"
+	"errors"
+	"platform/tracing"
+	"sort"
+
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+// ErrAccountNotFound means the read model has no open account with the ID.
+var ErrAccountNotFound = errors.New("account not found")
+
+type IAccountService interface {
+	// Accounts lists the open accounts of the read model by ID.
+	Accounts(ctx context.Context) ([]repositories.BankAccount, error)
+	// Account returns one open account, or ErrAccountNotFound.
+	Account(ctx context.Context, id string) (repositories.BankAccount, error)
+}
+
+type accountService struct {
+	accountRepo repositories.IAccountRepository
+}
+
+func NewAccountService(accountRepo repositories.IAccountRepository) IAccountService {
+	return accountService{accountRepo}
+}
+
+func (obj accountService) Accounts(ctx context.Context) (bankAccounts []repositories.BankAccount, err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "accountService.Accounts")
+	defer func() { tracing.End(span, err) }()
+
+	bankAccounts, err = obj.accountRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bankAccounts == nil {
+		bankAccounts = []repositories.BankAccount{}
+	}
+	sort.Slice(bankAccounts, func(i, j int) bool { return bankAccounts[i].ID < bankAccounts[j].ID })
+	return bankAccounts, nil
+}
+
+func (obj accountService) Account(ctx context.Context, id string) (bankAccount repositories.BankAccount, err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "accountService.Account")
+	defer func() { tracing.End(span, err) }()
+
+	bankAccount, err = obj.accountRepo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repositories.BankAccount{}, ErrAccountNotFound
+	}
+	return bankAccount, err
+}
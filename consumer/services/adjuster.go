@@ -0,0 +1,59 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"events"
+	"fmt"
+	"io"
+	"net/http"
+	"platform/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type IBalanceAdjuster interface {
+	Adjust(ctx context.Context, event events.AdjustBalanceEvent) error
+}
+
+type producerAdjuster struct {
+	client *http.Client
+	url    string
+}
+
+// NewProducerAdjuster posts adjustments to the producer's /adjustBalance at
+// url, so they are checked against the account's stream and appended to it
+// like any other command, and reach the consumer through the same topic.
+func NewProducerAdjuster(client *http.Client, url string) IBalanceAdjuster {
+	return producerAdjuster{client, url}
+}
+
+func (obj producerAdjuster) Adjust(ctx context.Context, event events.AdjustBalanceEvent) (err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "producerAdjuster.Adjust")
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, obj.url+"/adjustBalance", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Correlation-ID", events.MetadataFromContext(ctx).CorrelationID)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := obj.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("adjust account %v: %v: %s", event.ID, res.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"events"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_producerAdjuster_Adjust(t *testing.T) {
+	t.Run("Test should post adjustments to the producer with the correlation id", func(t *testing.T) {
+		var got events.AdjustBalanceEvent
+		var correlationID string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/adjustBalance", r.URL.Path)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			correlationID = r.Header.Get("X-Correlation-ID")
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.Write([]byte(`{"message":"adjust balance success"}`))
+		}))
+		defer server.Close()
+		ctx := events.ContextWithMetadata(context.Background(), events.Metadata{CorrelationID: "run"})
+
+		err := NewProducerAdjuster(server.Client(), server.URL).Adjust(ctx, events.AdjustBalanceEvent{ID: "123", Amount: -100, Reason: AdjustmentReason})
+
+		assert.NoError(t, err)
+		assert.Equal(t, events.AdjustBalanceEvent{ID: "123", Amount: -100, Reason: AdjustmentReason}, got)
+		assert.Equal(t, "run", correlationID)
+	})
+
+	t.Run("Test should return error when the producer rejects the adjustment", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("account is closed"))
+		}))
+		defer server.Close()
+
+		err := NewProducerAdjuster(server.Client(), server.URL).Adjust(context.Background(), events.AdjustBalanceEvent{ID: "123", Amount: -100, Reason: AdjustmentReason})
+
+		assert.EqualError(t, err, "adjust account 123: 409 Conflict: account is closed")
+	})
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"consumer/repositories"
+	"context"
+	"encoding/json"
+	"events"
+	"fmt"
+	"log/slog"
+	"platform/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// BatchMessage is a decoded message of a batch.
+type BatchMessage struct {
+	Topic      string
+	EventBytes []byte
+	Position   Position
+}
+
+type IBatchService interface {
+	// HandleBatch applies the events of messages, in order, in one
+	// transaction: either all of them change the read model and history,
+	// or none does. Messages whose position is already in the history are
+	// skipped.
+	HandleBatch(ctx context.Context, messages []BatchMessage) error
+}
+
+type batchService struct {
+	batchRepo     repositories.IBatchRepository
+	historyRepo   repositories.IHistoryRepository
+	transactor    repositories.ITransactor
+	upcasters     map[string][]Upcaster
+	snapshotEvery int
+	logger        *slog.Logger
+}
+
+// NewBatchService applies events the way the upcasting, history and event
+// services do one by one, folding each account's events into a single write
+// and taking the snapshots in the same transaction.
+func NewBatchService(batchRepo repositories.IBatchRepository, historyRepo repositories.IHistoryRepository, transactor repositories.ITransactor, upcasters map[string][]Upcaster, snapshotEvery int, logger *slog.Logger) IBatchService {
+	return batchService{batchRepo, historyRepo, transactor, upcasters, snapshotEvery, logger}
+}
+
+// batchEvent is a decoded event of a batch with the message it came in.
+type batchEvent struct {
+	event      events.Event
+	eventBytes []byte
+	message    BatchMessage
+}
+
+func (obj batchService) HandleBatch(ctx context.Context, messages []BatchMessage) (err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "batchService.HandleBatch",
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(messages))),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	positions := []repositories.Position{}
+	for _, message := range messages {
+		positions = append(positions, repositories.Position{Topic: message.Position.Topic, Partition: message.Position.Partition, Offset: message.Position.Offset})
+	}
+	recorded, err := obj.historyRepo.Recorded(ctx, positions)
+	if err != nil {
+		return err
+	}
+
+	batchEvents := []batchEvent{}
+	ids := []string{}
+	seen := map[string]bool{}
+	for i, message := range messages {
+		if recorded[positions[i]] {
+			obj.logger.InfoContext(ctx, "event already handled", "topic", message.Position.Topic, "partition", message.Position.Partition, "offset", message.Position.Offset)
+			continue
+		}
+		eventBytes, err := upcastPayload(obj.upcasters[message.Topic], message.EventBytes)
+		if err != nil {
+			return err
+		}
+		event, err := events.New(message.Topic)
+		if err != nil {
+			return fmt.Errorf("no event service for topic %v", message.Topic)
+		}
+		err = json.Unmarshal(eventBytes, event)
+		if err != nil {
+			return err
+		}
+
+		id := accountID(event)
+		if !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+		batchEvents = append(batchEvents, batchEvent{event, eventBytes, message})
+	}
+
+	if len(batchEvents) == 0 {
+		return nil
+	}
+	err = obj.transactor.Transaction(ctx, func(ctx context.Context) error {
+		accountEvents, err := obj.batchRepo.Apply(ctx, ids, func(bankAccounts map[string]repositories.BankAccount) (repositories.AccountBatch, error) {
+			return fold(ids, bankAccounts, batchEvents)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, accountEvent := range accountEvents {
+			err = snapshot(ctx, obj.historyRepo, accountEvent, obj.snapshotEvery)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	obj.logger.InfoContext(ctx, "batch handled", "messages", len(messages), "accounts", len(ids))
+	return nil
+}
+
+// fold applies the events to the accounts, ids listing them in the order
+// they first appear. Like the event service it fails on an event for an
+// account that is not open.
+func fold(ids []string, bankAccounts map[string]repositories.BankAccount, batchEvents []batchEvent) (repositories.AccountBatch, error) {
+	batch := repositories.AccountBatch{}
+	for _, batchEvent := range batchEvents {
+		id := accountID(batchEvent.event)
+		bankAccount, ok := bankAccounts[id]
+
+		switch event := batchEvent.event.(type) {
+		case *events.OpenAccountEvent:
+			bankAccounts[id] = repositories.BankAccount{
+				ID:            event.ID,
+				AccountHolder: event.AccountHolder,
+				AccountType:   event.AccountType,
+				Balance:       event.OpeningBalance,
+			}
+		case *events.CloseAccountEvent:
+			delete(bankAccounts, id)
+		default:
+			if !ok {
+				return repositories.AccountBatch{}, fmt.Errorf("%v for account %v: %w", batchEvent.message.Topic, id, gorm.ErrRecordNotFound)
+			}
+			switch event := event.(type) {
+			case *events.DepositFundEvent:
+				bankAccount.Balance += event.Amount
+			case *events.WithdrawFundEvent:
+				bankAccount.Balance -= event.Amount
+			case *events.AdjustBalanceEvent:
+				bankAccount.Balance += event.Amount
+			}
+			bankAccounts[id] = bankAccount
+		}
+
+		position := batchEvent.message.Position
+		occurredAt := position.Timestamp
+		if occurredAt.IsZero() {
+			occurredAt = time.Now()
+		}
+		batch.Events = append(batch.Events, repositories.AccountEvent{
+			AccountID:  id,
+			EventType:  batchEvent.message.Topic,
+			Data:       string(batchEvent.eventBytes),
+			OccurredAt: occurredAt,
+			Topic:      position.Topic,
+			Partition:  position.Partition,
+			Offset:     position.Offset,
+		})
+	}
+
+	for _, id := range ids {
+		bankAccount, ok := bankAccounts[id]
+		if ok {
+			batch.Saves = append(batch.Saves, bankAccount)
+		} else {
+			batch.Deletes = append(batch.Deletes, id)
+		}
+	}
+	return batch, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"consumer/metrics"
+	"consumer/repositories"
+	"context"
+	"events"
+	"log/slog"
+	"platform/tracing"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type batchConsumerService struct {
+	consumerService
+	batchService IBatchService
+	size         int
+	timeout      time.Duration
+}
+
+// NewBatchConsumerService builds a consumer group handler that collects up
+// to size messages of a claim, or those that arrive within timeout of the
+// first, and applies them with batchService in one transaction, marking
+// their offsets only once it commits. A batch that fails is handled again
+// message by message with eventService, so a bad message is reported the
+// same way as without batches, retried and dead lettered, instead of
+// holding up the claim.
+func NewBatchConsumerService(eventService IEventService, batchService IBatchService, messageRecorder IMessageRecorder, failedRepo repositories.IFailedMessageRepository, retry RetryPolicy, metrics *metrics.Metrics, logger *slog.Logger, size int, timeout time.Duration) sarama.ConsumerGroupHandler {
+	return batchConsumerService{consumerService{eventService, messageRecorder, failedRepo, retry, metrics, logger, 1}, batchService, size, timeout}
+}
+
+// ConsumeClaim flushes a batch when it is full or its timeout passes, and
+// flushes what is left once the claim ends. It ends the claim early when a
+// message could be neither handled nor dead lettered, as ConsumeClaim of
+// the consumer service does.
+func (obj batchConsumerService) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	batch := make([]*sarama.ConsumerMessage, 0, obj.size)
+	var timeout <-chan time.Time
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return obj.flush(session, claim, batch)
+			}
+			if len(batch) == 0 {
+				timeout = time.After(obj.timeout)
+			}
+			batch = append(batch, msg)
+			if len(batch) < obj.size {
+				continue
+			}
+		case <-timeout:
+		}
+		err := obj.flush(session, claim, batch)
+		if err != nil {
+			return err
+		}
+		batch = batch[:0]
+		timeout = nil
+	}
+}
+
+// flush applies batch and marks its offsets, or handles its messages one by
+// one when that fails, up to one that cannot be dead lettered.
+func (obj batchConsumerService) flush(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, batch []*sarama.ConsumerMessage) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	contexts := make([]context.Context, len(batch))
+	metadata := make([]events.Metadata, len(batch))
+	links := []trace.Link{}
+	messages := []BatchMessage{}
+	var err error
+	for i, msg := range batch {
+		contexts[i], metadata[i] = obj.messageContext(session, msg)
+		obj.record(contexts[i], msg)
+		links = append(links, trace.LinkFromContext(contexts[i]))
+
+		eventBytes, decodeErr := decodePayload(msg.Topic, metadata[i].ContentType, msg.Value)
+		if decodeErr != nil {
+			err = decodeErr
+			continue
+		}
+		messages = append(messages, BatchMessage{msg.Topic, eventBytes, Position{msg.Topic, msg.Partition, msg.Offset, msg.Timestamp}})
+	}
+
+	ctx, span := otel.Tracer("consumer/services").Start(session.Context(), claim.Topic()+" process batch",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", claim.Topic()),
+			attribute.Int64("messaging.kafka.partition", int64(claim.Partition())),
+			attribute.Int("messaging.batch.message_count", len(batch)),
+		),
+	)
+	start := time.Now()
+	if err == nil {
+		err = obj.batchService.HandleBatch(ctx, messages)
+	}
+	tracing.End(span, err)
+
+	outcome := metrics.OutcomeSuccess
+	if err != nil {
+		outcome = metrics.OutcomeError
+	}
+	if obj.metrics != nil {
+		obj.metrics.BatchSize.Observe(float64(len(batch)))
+		obj.metrics.BatchDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	}
+
+	if err != nil {
+		obj.logger.WarnContext(ctx, "batch failed, handling its messages one by one",
+			"topic", claim.Topic(), "partition", claim.Partition(), "messages", len(batch), "error", err)
+		for i, msg := range batch {
+			err = obj.process(contexts[i], claim, msg, metadata[i])
+			if err != nil {
+				return err
+			}
+			session.MarkMessage(msg, "")
+		}
+		return nil
+	}
+
+	if obj.metrics != nil {
+		for _, msg := range batch {
+			obj.metrics.MessagesConsumed.WithLabelValues(msg.Topic, metrics.OutcomeSuccess).Inc()
+		}
+		last := batch[len(batch)-1]
+		obj.metrics.ConsumerLag.WithLabelValues(last.Topic, strconv.Itoa(int(last.Partition))).Set(float64(lag(claim.HighWaterMarkOffset(), last.Offset)))
+	}
+	// the messages of a claim come in offset order, so marking the last
+	// marks them all
+	session.MarkMessage(batch[len(batch)-1], "")
+	return nil
+}
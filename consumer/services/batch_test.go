@@ -0,0 +1,328 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/metrics"
+	"consumer/repositories"
+	mockService "consumer/services/mock"
+	"context"
+	"errors"
+	"fmt"
+	"platform/logging"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// readModel is what a database holds after handling events.
+type readModel struct {
+	bankAccounts []repositories.BankAccount
+	history      map[string][]repositories.AccountEvent
+	snapshots    map[string]repositories.AccountSnapshot
+}
+
+func readBack(t *testing.T, db *gorm.DB, ids []string) readModel {
+	ctx := context.Background()
+	historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+	bankAccounts, err := repositories.NewAccountRepository(db, repositories.DefaultTable).FindAll(ctx)
+	assert.NoError(t, err)
+
+	obj := readModel{bankAccounts, map[string][]repositories.AccountEvent{}, map[string]repositories.AccountSnapshot{}}
+	for _, id := range ids {
+		obj.history[id], err = historyRepo.Events(ctx, id, 0, time.Time{})
+		assert.NoError(t, err)
+		snapshot, err := historyRepo.LatestSnapshot(ctx, id, time.Time{})
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			assert.NoError(t, err)
+		}
+		obj.snapshots[id] = snapshot
+	}
+	return obj
+}
+
+func batchMessages(values [][2]string) []BatchMessage {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	messages := []BatchMessage{}
+	for i, value := range values {
+		messages = append(messages, BatchMessage{value[0], []byte(value[1]), Position{value[0], 0, int64(i), day.Add(time.Duration(i) * time.Minute)}})
+	}
+	return messages
+}
+
+func Test_batchService_HandleBatch(t *testing.T) {
+	ids := []string{"123", "456", "789"}
+	opened := [][2]string{
+		{"OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`},
+		{"OpenAccountEvent", `{"ID":"456","AccountHolder":"Jane Doe","AccountType":2,"OpeningBalance":500}`},
+	}
+
+	tests := []struct {
+		name     string
+		messages [][2]string
+
+		wantError string
+	}{
+		{
+			name: "Test should fold each account's events the same as handling them one by one",
+			messages: [][2]string{
+				{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+				{"WithdrawFundEvent", `{"ID":"456","Amount":100}`},
+				{"OpenAccountEvent", `{"ID":"789","AccountHolder":"Max Doe","AccountType":1,"OpeningBalance":10}`},
+				{"AdjustBalanceEvent", `{"ID":"123","Amount":-20}`},
+				{"DepositFundEvent", `{"ID":"789","Amount":5}`},
+				{"WithdrawFundEvent", `{"ID":"123","Amount":80}`},
+			},
+		},
+		{
+			name: "Test should delete closed accounts and save reopened ones",
+			messages: [][2]string{
+				{"CloseAccountEvent", `{"ID":"123"}`},
+				{"DepositFundEvent", `{"ID":"456","Amount":100}`},
+				{"CloseAccountEvent", `{"ID":"456"}`},
+				{"OpenAccountEvent", `{"ID":"456","AccountHolder":"Jane Roe","AccountType":1,"OpeningBalance":50}`},
+			},
+		},
+		{
+			name: "Test should apply nothing when an event is for an account that is not open",
+			messages: [][2]string{
+				{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+				{"DepositFundEvent", `{"ID":"789","Amount":500}`},
+			},
+			wantError: "DepositFundEvent for account 789: record not found",
+		},
+		{
+			name: "Test should apply nothing when an event does not decode",
+			messages: [][2]string{
+				{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+				{"DepositFundEvent", `{"ID":"123","Amount":"five"}`},
+			},
+			wantError: "json: cannot unmarshal string",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			oneByOneDB := internal.OpenSQLiteDB(fmt.Sprintf("batch_one_by_one_%d", i))
+			batchDB := internal.OpenSQLiteDB(fmt.Sprintf("batch_%d", i))
+			batchService := NewBatchService(repositories.NewBatchRepository(batchDB, repositories.DefaultTable), repositories.NewHistoryRepository(batchDB, repositories.DefaultTable), repositories.NewTransactor(batchDB), Upcasters, 3, logging.Discard())
+			eventService := NewUpcastingEventService(
+				NewHistoryEventService(NewEventService(repositories.NewAccountRepository(oneByOneDB, repositories.DefaultTable), logging.Discard()), repositories.NewHistoryRepository(oneByOneDB, repositories.DefaultTable), repositories.NewTransactor(oneByOneDB), 3, logging.Discard()),
+				Upcasters,
+			)
+
+			assert.NoError(t, batchService.HandleBatch(ctx, batchMessages(opened)))
+			before := readBack(t, batchDB, ids)
+			err := batchService.HandleBatch(ctx, batchMessages(test.messages))
+
+			if test.wantError != "" {
+				assert.ErrorContains(t, err, test.wantError)
+				assert.Equal(t, before, readBack(t, batchDB, ids))
+				return
+			}
+			assert.NoError(t, err)
+			for _, message := range append(batchMessages(opened), batchMessages(test.messages)...) {
+				assert.NoError(t, eventService.Handle(ContextWithPosition(ctx, message.Position), message.Topic, message.EventBytes))
+			}
+			assert.Equal(t, readBack(t, oneByOneDB, ids), readBack(t, batchDB, ids))
+		})
+	}
+}
+
+// recordingBatchService records the batches it is given and fails those
+// whose first offset is in fail.
+type recordingBatchService struct {
+	mutex   *sync.Mutex
+	batches *[][]int64
+	fail    map[int64]bool
+	handled chan struct{}
+}
+
+func newRecordingBatchService(fail ...int64) recordingBatchService {
+	obj := recordingBatchService{&sync.Mutex{}, &[][]int64{}, map[int64]bool{}, make(chan struct{}, 100)}
+	for _, offset := range fail {
+		obj.fail[offset] = true
+	}
+	return obj
+}
+
+func (obj recordingBatchService) HandleBatch(ctx context.Context, messages []BatchMessage) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	defer func() { obj.handled <- struct{}{} }()
+
+	offsets := []int64{}
+	for _, message := range messages {
+		offsets = append(offsets, message.Position.Offset)
+	}
+	*obj.batches = append(*obj.batches, offsets)
+	if obj.fail[offsets[0]] {
+		return errors.New("deadlock found")
+	}
+	return nil
+}
+
+func (obj recordingBatchService) Batches() [][]int64 {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	return append([][]int64{}, *obj.batches...)
+}
+
+func depositMessages(count int) []*sarama.ConsumerMessage {
+	messages := []*sarama.ConsumerMessage{}
+	for offset := 0; offset < count; offset++ {
+		messages = append(messages, &sarama.ConsumerMessage{Topic: "DepositFundEvent", Offset: int64(offset), Value: []byte(`{"ID":"123","Amount":500}`)})
+	}
+	return messages
+}
+
+func Test_batchConsumerService_ConsumeClaim(t *testing.T) {
+	t.Run("Test should apply full batches and the rest once the claim ends", func(t *testing.T) {
+		batchService := newRecordingBatchService()
+		consumerMetrics := metrics.New()
+
+		marked, err := replayMessages(NewBatchConsumerService(nil, batchService, nil, nil, RetryPolicy{}, consumerMetrics, logging.Discard(), 2, time.Minute), depositMessages(5))
+
+		assert.NoError(t, err)
+		assert.Equal(t, [][]int64{{0, 1}, {2, 3}, {4}}, batchService.Batches())
+		assert.Equal(t, int64(5), marked["DepositFundEvent"][0])
+		assert.Equal(t, float64(5), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues("DepositFundEvent", metrics.OutcomeSuccess)))
+		assert.Equal(t, 1, testutil.CollectAndCount(consumerMetrics.BatchDuration))
+	})
+
+	t.Run("Test should apply a batch that is not full once its timeout passes", func(t *testing.T) {
+		batchService := newRecordingBatchService()
+		messages := make(chan *sarama.ConsumerMessage)
+		done := make(chan map[string]map[int32]int64)
+		go func() {
+			marked, _ := consumeMessages(NewBatchConsumerService(nil, batchService, nil, nil, RetryPolicy{}, nil, logging.Discard(), 100, 10*time.Millisecond), "DepositFundEvent", 0, messages)
+			done <- marked
+		}()
+
+		for _, msg := range depositMessages(2) {
+			messages <- msg
+		}
+		select {
+		case <-batchService.handled:
+		case <-time.After(time.Second):
+			t.Fatal("want the batch applied after its timeout")
+		}
+		close(messages)
+
+		assert.Equal(t, int64(2), (<-done)["DepositFundEvent"][0])
+		assert.Equal(t, [][]int64{{0, 1}}, batchService.Batches())
+	})
+
+	t.Run("Test should handle a failed batch message by message", func(t *testing.T) {
+		batchService := newRecordingBatchService(2)
+		mockEventService := mockService.NewIEventService(t)
+		mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil).Times(2)
+		consumerMetrics := metrics.New()
+
+		marked, err := replayMessages(NewBatchConsumerService(mockEventService, batchService, nil, nil, RetryPolicy{}, consumerMetrics, logging.Discard(), 2, time.Minute), depositMessages(4))
+
+		assert.NoError(t, err)
+		assert.Equal(t, [][]int64{{0, 1}, {2, 3}}, batchService.Batches())
+		assert.Equal(t, int64(4), marked["DepositFundEvent"][0])
+		assert.Equal(t, float64(4), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues("DepositFundEvent", metrics.OutcomeSuccess)))
+		assert.Equal(t, 2, testutil.CollectAndCount(consumerMetrics.BatchDuration))
+	})
+
+	t.Run("Test should handle a batch with a message that does not decode message by message", func(t *testing.T) {
+		batchService := newRecordingBatchService()
+		mockEventService := mockService.NewIEventService(t)
+		mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil).Once()
+		messages := depositMessages(2)
+		messages[1].Headers = []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/xml")}}
+		consumerMetrics := metrics.New()
+
+		marked, err := replayMessages(NewBatchConsumerService(mockEventService, batchService, nil, nil, RetryPolicy{}, consumerMetrics, logging.Discard(), 2, time.Minute), messages)
+
+		assert.NoError(t, err)
+		assert.Empty(t, batchService.Batches())
+		assert.Equal(t, int64(2), marked["DepositFundEvent"][0])
+		assert.Equal(t, float64(1), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues("DepositFundEvent", metrics.OutcomeDecodeError)))
+	})
+}
+
+// Benchmark_ConsumeClaim compares handling deposits one by one with
+// applying them in batches, both on SQLite with history enabled.
+func Benchmark_ConsumeClaim(b *testing.B) {
+	const accounts = 20
+	messages := []*sarama.ConsumerMessage{}
+	for i := 0; i < 1000; i++ {
+		value := fmt.Sprintf(`{"ID":"%d","Amount":1}`, i%accounts)
+		messages = append(messages, &sarama.ConsumerMessage{Topic: "DepositFundEvent", Offset: int64(i), Value: []byte(value)})
+	}
+
+	newDB := func(b *testing.B, name string) *gorm.DB {
+		db := internal.OpenSQLiteDB(name)
+		accountRepo := repositories.NewAccountRepository(db, repositories.DefaultTable)
+		for id := 0; id < accounts; id++ {
+			err := accountRepo.Save(context.Background(), repositories.BankAccount{ID: fmt.Sprint(id), AccountHolder: "John Doe", AccountType: 1})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		return db
+	}
+
+	b.Run("per message", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			db := newDB(b, fmt.Sprintf("benchmark_per_message_%d", i))
+			historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+			eventService := NewUpcastingEventService(NewHistoryEventService(NewEventService(repositories.NewAccountRepository(db, repositories.DefaultTable), logging.Discard()), historyRepo, repositories.NewTransactor(db), 100, logging.Discard()), Upcasters)
+			b.StartTimer()
+
+			_, err := replayMessages(NewConsumerService(eventService, nil, nil, RetryPolicy{}, nil, logging.Discard(), 1), messages)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	for _, size := range []int{10, 100} {
+		b.Run(fmt.Sprintf("batch of %d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				db := newDB(b, fmt.Sprintf("benchmark_batch_%d_%d", size, i))
+				historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+				batchService := NewBatchService(repositories.NewBatchRepository(db, repositories.DefaultTable), historyRepo, repositories.NewTransactor(db), Upcasters, 100, logging.Discard())
+				b.StartTimer()
+
+				_, err := replayMessages(NewBatchConsumerService(nil, batchService, nil, nil, RetryPolicy{}, nil, logging.Discard(), size, time.Second), messages)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Test_batchService_HandleBatch_redelivered(t *testing.T) {
+	ctx := context.Background()
+	db := internal.OpenSQLiteDB("batch_redelivered")
+	historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+	batchService := NewBatchService(repositories.NewBatchRepository(db, repositories.DefaultTable), historyRepo, repositories.NewTransactor(db), Upcasters, 0, logging.Discard())
+	messages := batchMessages([][2]string{
+		{"OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":50}`},
+	})
+	assert.NoError(t, batchService.HandleBatch(ctx, messages[:2]))
+
+	err := batchService.HandleBatch(ctx, messages)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []repositories.BankAccount{{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1550}}, readBack(t, db, nil).bankAccounts)
+	accountEvents, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, accountEvents, 3)
+}
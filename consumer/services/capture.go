@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Shopify/sarama"
+)
+
+// CapturedMessage is the JSONL fixture form of a consumed Kafka message.
+type CapturedMessage struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       string
+	Headers   map[string]string
+	Value     json.RawMessage `json:",omitempty"`
+	// ValueBase64 holds binary payloads, such as protobuf, instead of Value.
+	ValueBase64 []byte `json:",omitempty"`
+}
+
+func NewCapturedMessage(msg *sarama.ConsumerMessage) CapturedMessage {
+	headers := map[string]string{}
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+
+	captured := CapturedMessage{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       string(msg.Key),
+		Headers:   headers,
+	}
+	switch {
+	case json.Valid(msg.Value):
+		captured.Value = msg.Value
+	case utf8.Valid(msg.Value):
+		// keep malformed text payloads readable as a JSON string
+		captured.Value, _ = json.Marshal(string(msg.Value))
+	default:
+		captured.ValueBase64 = msg.Value
+	}
+
+	return captured
+}
+
+func (obj CapturedMessage) ConsumerMessage() *sarama.ConsumerMessage {
+	headers := []*sarama.RecordHeader{}
+	for key, value := range obj.Headers {
+		headers = append(headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+
+	value := []byte(obj.Value)
+	text := ""
+	if obj.ValueBase64 != nil {
+		value = obj.ValueBase64
+	} else if json.Unmarshal(obj.Value, &text) == nil {
+		value = []byte(text)
+	}
+
+	return &sarama.ConsumerMessage{
+		Topic:     obj.Topic,
+		Partition: obj.Partition,
+		Offset:    obj.Offset,
+		Key:       []byte(obj.Key),
+		Headers:   headers,
+		Value:     value,
+	}
+}
+
+// ReadCapturedMessages parses a JSONL fixture written by a message recorder.
+func ReadCapturedMessages(r io.Reader) ([]CapturedMessage, error) {
+	messages := []CapturedMessage{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		message := CapturedMessage{}
+		err := json.Unmarshal([]byte(line), &message)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, scanner.Err()
+}
+
+type IMessageRecorder interface {
+	Record(msg *sarama.ConsumerMessage) error
+}
+
+type messageRecorder struct {
+	mutex   *sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewMessageRecorder writes every recorded message to w as one JSON line.
+// It is safe to share across the goroutines sarama runs per claim.
+func NewMessageRecorder(w io.Writer) IMessageRecorder {
+	return messageRecorder{&sync.Mutex{}, json.NewEncoder(w)}
+}
+
+func (obj messageRecorder) Record(msg *sarama.ConsumerMessage) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	return obj.encoder.Encode(NewCapturedMessage(msg))
+}
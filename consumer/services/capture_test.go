@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"consumer/internal"
+	"consumer/repositories"
+	"context"
+	"encoding/json"
+	"os"
+	"platform/logging"
+	"sort"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_messageRecorder_Record(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockMessage *sarama.ConsumerMessage
+		wantLine    string
+	}{
+		{
+			name: "Test should keep json value as json",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:     "DepositFundEvent",
+				Partition: 1,
+				Offset:    42,
+				Key:       []byte("acc-1"),
+				Headers:   []*sarama.RecordHeader{{Key: []byte("event-type"), Value: []byte("DepositFundEvent")}},
+				Value:     []byte(`{"ID":"acc-1","Amount":500}`),
+			},
+			wantLine: `{"Topic":"DepositFundEvent","Partition":1,"Offset":42,"Key":"acc-1","Headers":{"event-type":"DepositFundEvent"},"Value":{"ID":"acc-1","Amount":500}}`,
+		},
+		{
+			name: "Test should keep malformed value as json string",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic: "DepositFundEvent",
+				Value: []byte(`{"ID":`),
+			},
+			wantLine: `{"Topic":"DepositFundEvent","Partition":0,"Offset":0,"Key":"","Headers":{},"Value":"{\"ID\":"}`,
+		},
+		{
+			name: "Test should keep binary value as base64",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:   "CloseAccountEvent",
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/x-protobuf")}},
+				Value:   []byte{0x0a, 0x03, '1', '2', '3', 0xff},
+			},
+			wantLine: `{"Topic":"CloseAccountEvent","Partition":0,"Offset":0,"Key":"","Headers":{"content-type":"application/x-protobuf"},"ValueBase64":"CgMxMjP/"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+
+			err := NewMessageRecorder(buffer).Record(test.mockMessage)
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, test.wantLine, buffer.String())
+
+			messages, err := ReadCapturedMessages(buffer)
+			assert.NoError(t, err)
+			assert.Equal(t, test.mockMessage.Value, messages[0].ConsumerMessage().Value)
+		})
+	}
+}
+
+func Test_consumerService_ReplayFixture(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockFixture string
+		wantGolden  string
+	}{
+		{
+			name:        "Test should match golden bond_banks state after replaying account lifecycle",
+			mockFixture: "testdata/account_lifecycle.jsonl",
+			wantGolden:  "testdata/account_lifecycle.golden.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixture, err := os.Open(test.mockFixture)
+			assert.NoError(t, err)
+			defer fixture.Close()
+			captured, err := ReadCapturedMessages(fixture)
+			assert.NoError(t, err)
+
+			messages := []*sarama.ConsumerMessage{}
+			for _, message := range captured {
+				messages = append(messages, message.ConsumerMessage())
+			}
+
+			accountRepo := repositories.NewAccountRepository(internal.OpenSQLiteDB(test.mockFixture), repositories.DefaultTable)
+			consumerService := NewConsumerService(NewEventService(accountRepo, logging.Discard()), nil, nil, RetryPolicy{}, nil, logging.Discard(), 1)
+
+			markedOffsets, err := replayMessages(consumerService, messages)
+			assert.NoError(t, err)
+
+			bankAccounts, err := accountRepo.FindAll(context.Background())
+			assert.NoError(t, err)
+			sort.Slice(bankAccounts, func(i, j int) bool { return bankAccounts[i].ID < bankAccounts[j].ID })
+
+			snapshot, err := json.MarshalIndent(map[string]interface{}{
+				"bond_banks":    bankAccounts,
+				"markedOffsets": markedOffsets,
+			}, "", "  ")
+			assert.NoError(t, err)
+
+			assertGolden(t, test.wantGolden, append(snapshot, '\n'))
+		})
+	}
+}
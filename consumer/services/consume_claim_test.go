@@ -0,0 +1,157 @@
+package services
+
+import (
+	mockService "consumer/services/mock"
+	"context"
+	"events"
+	"path/filepath"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_consumerService_ConsumeClaim(t *testing.T) {
+	mockEventService := mockService.NewIEventService(t)
+
+	clearAllMock := func() {
+		mockEventService.ClearAll()
+	}
+
+	protobufValue, _ := events.ProtobufCodec{}.Encode(events.DepositFundEvent{ID: "123", Amount: 500})
+	avroCodec := events.AvroCodec{Registry: events.NewFileSchemaRegistry(filepath.Join(t.TempDir(), "registry.json"))}
+	events.RegisterCodec(avroCodec)
+	avroValue, _ := avroCodec.Encode(events.DepositFundEvent{ID: "123", Amount: 500})
+
+	tests := []struct {
+		name        string
+		mockMessage *sarama.ConsumerMessage
+
+		wantServiceOrRepoCallWithAndResponse func()
+		wantServiceOrRepoCallTimes           map[string]map[string]int
+		wantMarkedOffset                     int64
+	}{
+		{
+			name: "Test should hand json payload through when content type header is missing",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:  "DepositFundEvent",
+				Offset: 4,
+				Value:  []byte(`{"ID":"123","Amount":500}`),
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+			},
+			wantMarkedOffset: 5,
+		},
+		{
+			name: "Test should pass header metadata to handler in context",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:  "DepositFundEvent",
+				Offset: 4,
+				Headers: []*sarama.RecordHeader{
+					{Key: []byte("request-id"), Value: []byte("request")},
+					{Key: []byte("correlation-id"), Value: []byte("correlation")},
+					{Key: []byte("traceparent"), Value: []byte("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")},
+				},
+				Value: []byte(`{"ID":"123","Amount":500}`),
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.MatchedBy(func(ctx context.Context) bool {
+					return events.MetadataFromContext(ctx) == events.Metadata{
+						RequestID:     "request",
+						CorrelationID: "correlation",
+						Traceparent:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+					}
+				}), "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+			},
+			wantMarkedOffset: 5,
+		},
+		{
+			name: "Test should pass message position to handler in context",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:     "DepositFundEvent",
+				Partition: 2,
+				Offset:    4,
+				Timestamp: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+				Value:     []byte(`{"ID":"123","Amount":500}`),
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.MatchedBy(func(ctx context.Context) bool {
+					return PositionFromContext(ctx) == Position{"DepositFundEvent", 2, 4, time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+				}), "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+			},
+			wantMarkedOffset: 5,
+		},
+		{
+			name: "Test should decode protobuf payload into json",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:   "DepositFundEvent",
+				Offset:  4,
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte(events.ContentTypeProtobuf)}},
+				Value:   protobufValue,
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+			},
+			wantMarkedOffset: 5,
+		},
+		{
+			name: "Test should decode avro payload into json",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:   "DepositFundEvent",
+				Offset:  4,
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte(events.ContentTypeAvro)}},
+				Value:   avroValue,
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+			},
+			wantMarkedOffset: 5,
+		},
+		{
+			name: "Test should skip message but still mark it when content type is unsupported",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:   "DepositFundEvent",
+				Offset:  4,
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/xml")}},
+				Value:   []byte(`<event/>`),
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventService": {
+					"Handle": 0,
+				},
+			},
+			wantMarkedOffset: 5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer clearAllMock()
+
+			if test.wantServiceOrRepoCallWithAndResponse != nil {
+				test.wantServiceOrRepoCallWithAndResponse()
+			}
+
+			consumerService := NewConsumerService(mockEventService, nil, nil, RetryPolicy{}, nil, logging.Discard(), 1)
+			markedOffsets, err := replayMessages(consumerService, []*sarama.ConsumerMessage{test.mockMessage})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantMarkedOffset, markedOffsets[test.mockMessage.Topic][test.mockMessage.Partition])
+
+			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
+				for methodName, times := range serviceCallTimes {
+					switch serviceName {
+					case "eventService":
+						mockEventService.AssertNumberOfCalls(t, methodName, times)
+					default:
+						t.Errorf("service %s or method %s not found", serviceName, methodName)
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,13 +1,56 @@
 package services
 
-import "github.com/Shopify/sarama"
+import (
+	"consumer/metrics"
+	"consumer/repositories"
+	"context"
+	"encoding/json"
+	"events"
+	"log/slog"
+	"platform/logging"
+	"platform/tracing"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetryPolicy is how often a message whose handling fails is handled again
+// before the consumer gives up on it.
+type RetryPolicy struct {
+	// Attempts is how many times a message is handled at most; 1 or less
+	// does not retry.
+	Attempts int
+	// Backoff is the wait before the first retry, doubled before each
+	// next one.
+	Backoff time.Duration
+}
 
 type consumerService struct {
-	eventService IEventService
+	eventService    IEventService
+	messageRecorder IMessageRecorder
+	failedRepo      repositories.IFailedMessageRepository
+	retry           RetryPolicy
+	metrics         *metrics.Metrics
+	logger          *slog.Logger
+	workers         int
 }
 
-func NewConsumerService(eventService IEventService) sarama.ConsumerGroupHandler {
-	return consumerService{eventService}
+// NewConsumerService builds the consumer group handler. messageRecorder,
+// failedRepo and metrics are optional; when set, every message is captured
+// before it is handled and counted after, and one that still fails after
+// the attempts of retry, or does not decode, is dead lettered to
+// failedRepo. Each claim handles up to workers messages at once, in order
+// per account.
+func NewConsumerService(eventService IEventService, messageRecorder IMessageRecorder, failedRepo repositories.IFailedMessageRepository, retry RetryPolicy, metrics *metrics.Metrics, logger *slog.Logger, workers int) sarama.ConsumerGroupHandler {
+	if workers < 1 {
+		workers = 1
+	}
+	return consumerService{eventService, messageRecorder, failedRepo, retry, metrics, logger, workers}
 }
 
 func (obj consumerService) Setup(sarama.ConsumerGroupSession) error {
@@ -18,11 +61,245 @@ func (obj consumerService) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
 }
 
+// ConsumeClaim hands the messages to a worker pool and returns once the
+// claim ends and every message in flight has been handled, so the offsets
+// are marked before the session commits them. It ends the claim early with
+// the error of a message that could be neither handled nor dead lettered,
+// which ends the session, so the message is consumed again from the
+// committed offset.
 func (obj consumerService) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for msg := range claim.Messages() {
-		obj.eventService.Handle(msg.Topic, msg.Value)
-		session.MarkMessage(msg, "")
+	pool := newWorkerPool(obj.workers,
+		func(msg *sarama.ConsumerMessage) error {
+			defer obj.trackInFlight(msg, -1)
+			return obj.handle(session, claim, msg)
+		},
+		func(msg *sarama.ConsumerMessage) {
+			session.MarkMessage(msg, "")
+		},
+	)
+consume:
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				break consume
+			}
+			key := ""
+			if obj.workers > 1 {
+				key = messageKey(msg)
+			}
+			obj.trackInFlight(msg, 1)
+			pool.dispatch(key, msg)
+		case <-pool.failed():
+			break consume
+		}
+	}
+	pool.close()
+
+	err := pool.err()
+	if err != nil && obj.metrics != nil {
+		// the skipped messages are no longer in flight either
+		obj.metrics.InFlight.WithLabelValues(claim.Topic(), strconv.Itoa(int(claim.Partition()))).Set(0)
+	}
+	return err
+}
+
+func (obj consumerService) trackInFlight(msg *sarama.ConsumerMessage, delta float64) {
+	if obj.metrics != nil {
+		obj.metrics.InFlight.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Add(delta)
+	}
+}
+
+// messageKey is the key of msg or, since the producer sets none, the ID of
+// the account its event is for. Messages that do not decode share the empty
+// key.
+func messageKey(msg *sarama.ConsumerMessage) string {
+	if len(msg.Key) > 0 {
+		return string(msg.Key)
+	}
+
+	contentType := ""
+	for _, header := range msg.Headers {
+		if string(header.Key) == events.ContentTypeHeader {
+			contentType = string(header.Value)
+		}
+	}
+	eventBytes, err := decodePayload(msg.Topic, contentType, msg.Value)
+	if err != nil {
+		return ""
+	}
+	account := struct{ ID string }{}
+	json.Unmarshal(eventBytes, &account)
+	return account.ID
+}
+
+// handle decodes and handles one message, recording its outcome.
+func (obj consumerService) handle(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, msg *sarama.ConsumerMessage) error {
+	ctx, metadata := obj.messageContext(session, msg)
+	obj.record(ctx, msg)
+	return obj.process(ctx, claim, msg, metadata)
+}
+
+// messageContext carries the trace, metadata and position of msg, and logs
+// with them.
+func (obj consumerService) messageContext(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) (context.Context, events.Metadata) {
+	headers := map[string]string{}
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	metadata := events.MetadataFromHeaders(headers)
+	ctx := otel.GetTextMapPropagator().Extract(session.Context(), propagation.MapCarrier(headers))
+	ctx = events.ContextWithMetadata(ctx, metadata)
+	ctx = ContextWithPosition(ctx, Position{msg.Topic, msg.Partition, msg.Offset, msg.Timestamp})
+	ctx = logging.ContextWith(ctx,
+		"topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset,
+		"request_id", metadata.RequestID,
+		"correlation_id", metadata.CorrelationID,
+	)
+	return ctx, metadata
+}
+
+// record captures msg when a recorder is set.
+func (obj consumerService) record(ctx context.Context, msg *sarama.ConsumerMessage) {
+	if obj.messageRecorder != nil {
+		err := obj.messageRecorder.Record(msg)
+		if err != nil {
+			obj.logger.WarnContext(ctx, "message not captured", "error", err)
+		}
+	}
+}
+
+// process decodes and handles msg, which is already captured. It returns
+// an error only when msg failed and could not be dead lettered, so it must
+// not be marked.
+func (obj consumerService) process(ctx context.Context, claim sarama.ConsumerGroupClaim, msg *sarama.ConsumerMessage, metadata events.Metadata) error {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", msg.Topic),
+			attribute.Int64("messaging.kafka.partition", int64(msg.Partition)),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+		),
+	)
+
+	start := time.Now()
+	outcome := metrics.OutcomeSuccess
+	attempts := 1
+	eventBytes, err := decodePayload(msg.Topic, metadata.ContentType, msg.Value)
+	if err != nil {
+		outcome = metrics.OutcomeDecodeError
+	} else {
+		attempts, err = obj.handleWithRetries(ctx, msg.Topic, eventBytes)
+		if err != nil {
+			outcome = metrics.OutcomeError
+		}
 	}
+	var deadLetterErr error
+	if err != nil {
+		obj.logger.ErrorContext(ctx, "message handling failed", "outcome", outcome, "attempts", attempts, "error", err)
+		deadLetterErr = obj.deadLetter(ctx, msg, attempts, err)
+	}
+	tracing.End(span, err)
+
+	if obj.metrics != nil {
+		obj.metrics.HandlerDuration.WithLabelValues(msg.Topic).Observe(time.Since(start).Seconds())
+		obj.metrics.MessagesConsumed.WithLabelValues(msg.Topic, outcome).Inc()
+		obj.metrics.ConsumerLag.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Set(float64(lag(claim.HighWaterMarkOffset(), msg.Offset)))
+	}
+	return deadLetterErr
+}
+
+// handleWithRetries handles the event up to the attempts of the retry
+// policy, backing off between them, and returns how many it made. It stops
+// retrying once ctx is done, as when the claim ends.
+func (obj consumerService) handleWithRetries(ctx context.Context, topic string, eventBytes []byte) (attempts int, err error) {
+	backoff := obj.retry.Backoff
+	for attempts = 1; ; attempts++ {
+		err = obj.eventService.Handle(ctx, topic, eventBytes)
+		if err == nil || attempts >= obj.retry.Attempts {
+			return attempts, err
+		}
 
+		obj.logger.WarnContext(ctx, "message handling failed, retrying", "attempt", attempts, "backoff", backoff, "error", err)
+		if obj.metrics != nil {
+			obj.metrics.Retries.WithLabelValues(topic).Inc()
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return attempts, err
+		}
+		backoff *= 2
+	}
+}
+
+// deadLetter records msg as failed with the error of its last attempt, so
+// it can be redriven, when a failed message repository is set. msg must
+// not be marked when that fails, or it would be lost.
+func (obj consumerService) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, attempts int, err error) error {
+	if obj.failedRepo == nil {
+		return nil
+	}
+
+	headers := map[string]string{}
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	// recorded even when the claim is ending, as the message is only
+	// marked once it is
+	saveErr := obj.failedRepo.Save(context.WithoutCancel(ctx), repositories.FailedMessage{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		FailedAt:  time.Now(),
+	})
+	if saveErr != nil {
+		obj.logger.ErrorContext(ctx, "message not dead lettered, it will be consumed again", "error", saveErr)
+		return saveErr
+	}
+	if obj.metrics != nil {
+		obj.metrics.DeadLettered.WithLabelValues(msg.Topic).Inc()
+	}
 	return nil
 }
+
+// lag is the number of messages after offset up to the high water mark,
+// which is the offset the next produced message will get.
+func lag(highWaterMark int64, offset int64) int64 {
+	if highWaterMark <= offset {
+		return 0
+	}
+	return highWaterMark - offset - 1
+}
+
+// decodePayload returns the message value as JSON, transcoding it first when
+// its content-type header names another codec, so handlers read both
+// encodings during a migration.
+func decodePayload(topic string, contentType string, value []byte) ([]byte, error) {
+	codec, err := events.CodecFor(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if codec.ContentType() == events.ContentTypeJSON {
+		return value, nil
+	}
+
+	event, err := events.New(topic)
+	if err != nil {
+		return nil, err
+	}
+	err = codec.Decode(value, event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(event)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"context"
+	"events"
+	"platform/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_eventService_Handle_Contract(t *testing.T) {
+	// Schema samples use "sample" for every string and 1.5 for every number.
+	tests := []struct {
+		name             string
+		mockTopic        string
+		mockRecords      []repositories.BankAccount
+		wantBankAccounts []repositories.BankAccount
+	}{
+		{
+			name:      "Test should open account from schema sample of OpenAccountEvent",
+			mockTopic: "OpenAccountEvent",
+			wantBankAccounts: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "sample", AccountType: 1, Balance: 1.5},
+			},
+		},
+		{
+			name:      "Test should deposit from schema sample of DepositFundEvent",
+			mockTopic: "DepositFundEvent",
+			mockRecords: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 10},
+			},
+			wantBankAccounts: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 11.5},
+			},
+		},
+		{
+			name:      "Test should withdraw from schema sample of WithdrawFundEvent",
+			mockTopic: "WithdrawFundEvent",
+			mockRecords: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 10},
+			},
+			wantBankAccounts: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 8.5},
+			},
+		},
+		{
+			name:      "Test should close account from schema sample of CloseAccountEvent",
+			mockTopic: "CloseAccountEvent",
+			mockRecords: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 10},
+			},
+			wantBankAccounts: []repositories.BankAccount{},
+		},
+		{
+			name:      "Test should adjust balance from schema sample of AdjustBalanceEvent",
+			mockTopic: "AdjustBalanceEvent",
+			mockRecords: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 10},
+			},
+			wantBankAccounts: []repositories.BankAccount{
+				{ID: "sample", AccountHolder: "John Doe", AccountType: 1, Balance: 11.5},
+			},
+		},
+	}
+
+	assert.Len(t, tests, len(events.Topics), "every topic in events.Topics needs a contract case")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := events.CommittedSchema(test.mockTopic)
+			assert.NoError(t, err)
+			sample := schema.Sample()
+			assert.NoError(t, schema.Validate(sample))
+
+			accountRepo := repositories.NewAccountRepository(internal.OpenSQLiteDB("contract_" + test.mockTopic), repositories.DefaultTable)
+			for _, record := range test.mockRecords {
+				assert.NoError(t, accountRepo.Save(context.Background(), record))
+			}
+
+			NewEventService(accountRepo, logging.Discard()).Handle(context.Background(), test.mockTopic, sample)
+
+			bankAccounts, err := accountRepo.FindAll(context.Background())
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, test.wantBankAccounts, bankAccounts)
+		})
+	}
+}
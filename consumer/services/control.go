@@ -0,0 +1,414 @@
+package services
+
+import (
+	"consumer/metrics"
+	"context"
+	"log/slog"
+	"platform/health"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// PauseState is what consumption is paused for.
+type PauseState struct {
+	// All is set by pausing without a topic.
+	All        bool               `json:"all"`
+	Topics     []string           `json:"topics"`
+	Partitions map[string][]int32 `json:"partitions"`
+	// AutoPause is why every partition is paused automatically, empty
+	// while it is not.
+	AutoPause string `json:"autoPause,omitempty"`
+	// Paused lists the claimed partitions paused now, for any reason.
+	Paused map[string][]int32 `json:"paused"`
+}
+
+type IConsumerControl interface {
+	// Pause stops consuming partitions of topic, every one of them when
+	// none is given, or every claimed partition when topic is empty.
+	Pause(topic string, partitions []int32)
+	// Resume undoes Pause with the same arguments; an empty topic undoes
+	// every Pause.
+	Resume(topic string, partitions []int32)
+	// AutoPause pauses every partition for reason, until it is called with
+	// an empty reason. It is separate from Pause, so neither undoes the
+	// other.
+	AutoPause(reason string)
+	State() PauseState
+	// Handler wraps handler so it only gets messages of partitions that are
+	// not paused, at most as fast as the rate limit allows.
+	Handler(handler sarama.ConsumerGroupHandler) sarama.ConsumerGroupHandler
+}
+
+// pauser is the part of sarama.ConsumerGroup that stops fetching.
+type pauser interface {
+	Pause(partitions map[string][]int32)
+	Resume(partitions map[string][]int32)
+}
+
+type consumerControl struct {
+	mutex      *sync.Mutex
+	group      pauser
+	limiter    *rateLimiter
+	metrics    *metrics.Metrics
+	logger     *slog.Logger
+	claims     map[string]map[int32]bool
+	all        bool
+	topics     map[string]bool
+	partitions map[string]map[int32]bool
+	autoPause  string
+	// changed is closed and replaced whenever the state changes, waking
+	// the claims waiting out a pause.
+	changed chan struct{}
+}
+
+// NewConsumerControl pauses the partitions of group. Pausing stops fetching,
+// and holds back the messages already fetched, so a paused partition hands
+// nothing more to the handler. messagesPerSecond limits consumption across
+// every partition, letting up to burst messages through at once; 0 disables
+// the limit. metrics is optional.
+func NewConsumerControl(group pauser, messagesPerSecond float64, burst int, metrics *metrics.Metrics, logger *slog.Logger) IConsumerControl {
+	var limiter *rateLimiter
+	if messagesPerSecond > 0 {
+		limiter = newRateLimiter(messagesPerSecond, burst)
+	}
+	return &consumerControl{
+		mutex:      &sync.Mutex{},
+		group:      group,
+		limiter:    limiter,
+		metrics:    metrics,
+		logger:     logger,
+		claims:     map[string]map[int32]bool{},
+		topics:     map[string]bool{},
+		partitions: map[string]map[int32]bool{},
+		changed:    make(chan struct{}),
+	}
+}
+
+func (obj *consumerControl) Pause(topic string, partitions []int32) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	switch {
+	case topic == "":
+		obj.all = true
+	case len(partitions) == 0:
+		obj.topics[topic] = true
+	default:
+		if obj.partitions[topic] == nil {
+			obj.partitions[topic] = map[int32]bool{}
+		}
+		for _, partition := range partitions {
+			obj.partitions[topic][partition] = true
+		}
+	}
+	obj.logger.Info("consumption paused", "topic", topic, "partitions", partitions)
+	obj.apply()
+}
+
+func (obj *consumerControl) Resume(topic string, partitions []int32) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	switch {
+	case topic == "":
+		obj.all = false
+		obj.topics = map[string]bool{}
+		obj.partitions = map[string]map[int32]bool{}
+	case len(partitions) == 0:
+		delete(obj.topics, topic)
+		delete(obj.partitions, topic)
+	default:
+		for _, partition := range partitions {
+			delete(obj.partitions[topic], partition)
+		}
+		if len(obj.partitions[topic]) == 0 {
+			delete(obj.partitions, topic)
+		}
+	}
+	obj.logger.Info("consumption resumed", "topic", topic, "partitions", partitions)
+	obj.apply()
+}
+
+func (obj *consumerControl) AutoPause(reason string) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if reason == obj.autoPause {
+		return
+	}
+	switch {
+	case reason == "":
+		obj.logger.Info("consumption resumed automatically")
+	case obj.autoPause == "":
+		obj.logger.Warn("consumption paused automatically", "reason", reason)
+	}
+	obj.autoPause = reason
+	if obj.metrics != nil {
+		autoPaused := 0.0
+		if reason != "" {
+			autoPaused = 1
+		}
+		obj.metrics.AutoPaused.Set(autoPaused)
+	}
+	obj.apply()
+}
+
+func (obj *consumerControl) State() PauseState {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	state := PauseState{
+		All:        obj.all,
+		Topics:     []string{},
+		Partitions: map[string][]int32{},
+		AutoPause:  obj.autoPause,
+		Paused:     map[string][]int32{},
+	}
+	for topic := range obj.topics {
+		state.Topics = append(state.Topics, topic)
+	}
+	sort.Strings(state.Topics)
+	for topic, partitions := range obj.partitions {
+		state.Partitions[topic] = sortedPartitions(partitions, func(int32) bool { return true })
+	}
+	for topic, partitions := range obj.claims {
+		paused := sortedPartitions(partitions, func(partition int32) bool { return obj.paused(topic, partition) })
+		if len(paused) > 0 {
+			state.Paused[topic] = paused
+		}
+	}
+	return state
+}
+
+func sortedPartitions(partitions map[int32]bool, keep func(partition int32) bool) []int32 {
+	result := []int32{}
+	for partition := range partitions {
+		if keep(partition) {
+			result = append(result, partition)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// paused must be called with the lock held.
+func (obj *consumerControl) paused(topic string, partition int32) bool {
+	return obj.all || obj.autoPause != "" || obj.topics[topic] || obj.partitions[topic][partition]
+}
+
+// apply pauses and resumes the claimed partitions to match the state, and
+// wakes the claims waiting out a pause. It must be called with the lock
+// held.
+func (obj *consumerControl) apply() {
+	paused := map[string][]int32{}
+	resumed := map[string][]int32{}
+	for topic, partitions := range obj.claims {
+		for partition := range partitions {
+			value := 0.0
+			if obj.paused(topic, partition) {
+				paused[topic] = append(paused[topic], partition)
+				value = 1
+			} else {
+				resumed[topic] = append(resumed[topic], partition)
+			}
+			if obj.metrics != nil {
+				obj.metrics.Paused.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(value)
+			}
+		}
+	}
+	obj.group.Pause(paused)
+	obj.group.Resume(resumed)
+
+	close(obj.changed)
+	obj.changed = make(chan struct{})
+}
+
+// claim records a partition of a new session, whose partition consumer
+// starts out fetching.
+func (obj *consumerControl) claim(topic string, partition int32) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if obj.claims[topic] == nil {
+		obj.claims[topic] = map[int32]bool{}
+	}
+	obj.claims[topic][partition] = true
+	obj.apply()
+}
+
+// wait returns once the partition is not paused, or false when ctx is done
+// first.
+func (obj *consumerControl) wait(ctx context.Context, topic string, partition int32) bool {
+	for {
+		obj.mutex.Lock()
+		paused := obj.paused(topic, partition)
+		changed := obj.changed
+		obj.mutex.Unlock()
+		if !paused {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (obj *consumerControl) Handler(handler sarama.ConsumerGroupHandler) sarama.ConsumerGroupHandler {
+	return controlledHandler{handler, obj}
+}
+
+type controlledHandler struct {
+	sarama.ConsumerGroupHandler
+	control *consumerControl
+}
+
+// Setup forgets the claims of the previous session, whose partitions may
+// now belong to another member.
+func (obj controlledHandler) Setup(session sarama.ConsumerGroupSession) error {
+	obj.control.mutex.Lock()
+	obj.control.claims = map[string]map[int32]bool{}
+	if obj.control.metrics != nil {
+		obj.control.metrics.Paused.Reset()
+	}
+	obj.control.mutex.Unlock()
+	return obj.ConsumerGroupHandler.Setup(session)
+}
+
+// ConsumeClaim hands the handler a claim whose messages wait out pauses and
+// the rate limit. When the session ends during a wait, the messages left
+// are dropped unmarked, so the next session gets them again.
+func (obj controlledHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	obj.control.claim(claim.Topic(), claim.Partition())
+
+	messages := make(chan *sarama.ConsumerMessage)
+	go func() {
+		defer close(messages)
+		ctx := session.Context()
+		for msg := range claim.Messages() {
+			if !obj.control.forward(ctx, claim, msg, messages) {
+				// dropping one message means dropping every later one,
+				// or marking them would skip it
+				for range claim.Messages() {
+				}
+				return
+			}
+		}
+	}()
+	return obj.ConsumerGroupHandler.ConsumeClaim(session, controlledClaim{claim, messages})
+}
+
+// forward sends msg on messages once its partition is not paused and the
+// rate limit allows, returning false when the session ends first.
+func (obj *consumerControl) forward(ctx context.Context, claim sarama.ConsumerGroupClaim, msg *sarama.ConsumerMessage, messages chan<- *sarama.ConsumerMessage) bool {
+	if !obj.wait(ctx, claim.Topic(), claim.Partition()) || !obj.limit(ctx) {
+		return false
+	}
+	select {
+	case messages <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type controlledClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (obj controlledClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return obj.messages
+}
+
+// limit waits for the rate limiter, returning false when ctx is done first.
+func (obj *consumerControl) limit(ctx context.Context) bool {
+	if obj.limiter == nil {
+		return true
+	}
+	delay := obj.limiter.reserve(time.Now())
+	if delay <= 0 {
+		return true
+	}
+	if obj.metrics != nil {
+		obj.metrics.RateLimitWait.Add(delay.Seconds())
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// rateLimiter spaces messages evenly at a rate, letting up to burst through
+// at once after a quiet spell.
+type rateLimiter struct {
+	mutex    *sync.Mutex
+	interval time.Duration
+	burst    int
+	// next is when the next message is due without a burst.
+	next time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{&sync.Mutex{}, time.Duration(float64(time.Second) / perSecond), burst, time.Time{}}
+}
+
+// reserve takes the next slot and returns how long after now it is, 0 for
+// a slot of the burst.
+func (obj *rateLimiter) reserve(now time.Time) time.Duration {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	earliest := now.Add(-time.Duration(obj.burst-1) * obj.interval)
+	if obj.next.Before(earliest) {
+		obj.next = earliest
+	}
+	delay := obj.next.Sub(now)
+	obj.next = obj.next.Add(obj.interval)
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// WatchHealth runs check every interval until ctx is done, pausing
+// consumption automatically while it fails, e.g. while the database is down
+// for maintenance.
+func WatchHealth(ctx context.Context, control IConsumerControl, name string, check health.Check, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		err := check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			control.AutoPause(name + ": " + err.Error())
+		} else {
+			control.AutoPause("")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"consumer/metrics"
+	mockService "consumer/services/mock"
+	"context"
+	"errors"
+	"platform/logging"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakePauser keeps the partitions the consumer group would not fetch.
+type fakePauser struct {
+	mutex  *sync.Mutex
+	paused map[string]map[int32]bool
+}
+
+func newFakePauser() fakePauser {
+	return fakePauser{&sync.Mutex{}, map[string]map[int32]bool{}}
+}
+
+func (obj fakePauser) Pause(partitions map[string][]int32) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	for topic, partitions := range partitions {
+		if obj.paused[topic] == nil {
+			obj.paused[topic] = map[int32]bool{}
+		}
+		for _, partition := range partitions {
+			obj.paused[topic][partition] = true
+		}
+	}
+}
+
+func (obj fakePauser) Resume(partitions map[string][]int32) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	for topic, partitions := range partitions {
+		for _, partition := range partitions {
+			delete(obj.paused[topic], partition)
+		}
+	}
+}
+
+func (obj fakePauser) Paused() map[string][]int32 {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	result := map[string][]int32{}
+	for topic, partitions := range obj.paused {
+		paused := sortedPartitions(partitions, func(int32) bool { return true })
+		if len(paused) > 0 {
+			result[topic] = paused
+		}
+	}
+	return result
+}
+
+func Test_consumerControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(control IConsumerControl)
+
+		wantState PauseState
+	}{
+		{
+			name:   "Test should pause every partition without a topic",
+			change: func(control IConsumerControl) { control.Pause("", nil) },
+			wantState: PauseState{All: true, Topics: []string{}, Partitions: map[string][]int32{},
+				Paused: map[string][]int32{"DepositFundEvent": {0, 1}, "WithdrawFundEvent": {0}}},
+		},
+		{
+			name:   "Test should pause every partition of a topic",
+			change: func(control IConsumerControl) { control.Pause("DepositFundEvent", nil) },
+			wantState: PauseState{Topics: []string{"DepositFundEvent"}, Partitions: map[string][]int32{},
+				Paused: map[string][]int32{"DepositFundEvent": {0, 1}}},
+		},
+		{
+			name: "Test should pause only the partitions given",
+			change: func(control IConsumerControl) {
+				control.Pause("DepositFundEvent", []int32{1, 7})
+			},
+			wantState: PauseState{Topics: []string{}, Partitions: map[string][]int32{"DepositFundEvent": {1, 7}},
+				Paused: map[string][]int32{"DepositFundEvent": {1}}},
+		},
+		{
+			name: "Test should resume a partition paused on its own",
+			change: func(control IConsumerControl) {
+				control.Pause("DepositFundEvent", []int32{0, 1})
+				control.Pause("WithdrawFundEvent", nil)
+				control.Resume("DepositFundEvent", []int32{0})
+			},
+			wantState: PauseState{Topics: []string{"WithdrawFundEvent"}, Partitions: map[string][]int32{"DepositFundEvent": {1}},
+				Paused: map[string][]int32{"DepositFundEvent": {1}, "WithdrawFundEvent": {0}}},
+		},
+		{
+			name: "Test should resume everything without a topic",
+			change: func(control IConsumerControl) {
+				control.Pause("", nil)
+				control.Pause("DepositFundEvent", []int32{0})
+				control.Resume("", nil)
+			},
+			wantState: PauseState{Topics: []string{}, Partitions: map[string][]int32{}, Paused: map[string][]int32{}},
+		},
+		{
+			name: "Test should keep an automatic pause when resuming by hand",
+			change: func(control IConsumerControl) {
+				control.AutoPause("db: connection refused")
+				control.Resume("", nil)
+			},
+			wantState: PauseState{Topics: []string{}, Partitions: map[string][]int32{}, AutoPause: "db: connection refused",
+				Paused: map[string][]int32{"DepositFundEvent": {0, 1}, "WithdrawFundEvent": {0}}},
+		},
+		{
+			name: "Test should keep a pause by hand when resuming automatically",
+			change: func(control IConsumerControl) {
+				control.Pause("WithdrawFundEvent", nil)
+				control.AutoPause("db: connection refused")
+				control.AutoPause("")
+			},
+			wantState: PauseState{Topics: []string{"WithdrawFundEvent"}, Partitions: map[string][]int32{},
+				Paused: map[string][]int32{"WithdrawFundEvent": {0}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := newFakePauser()
+			consumerMetrics := metrics.New()
+			control := NewConsumerControl(group, 0, 1, consumerMetrics, logging.Discard())
+			control.(*consumerControl).claim("DepositFundEvent", 0)
+			control.(*consumerControl).claim("DepositFundEvent", 1)
+			control.(*consumerControl).claim("WithdrawFundEvent", 0)
+
+			test.change(control)
+
+			assert.Equal(t, test.wantState, control.State())
+			assert.Equal(t, test.wantState.Paused, group.Paused())
+			for _, claim := range []struct {
+				topic     string
+				partition string
+				index     int32
+			}{{"DepositFundEvent", "0", 0}, {"DepositFundEvent", "1", 1}, {"WithdrawFundEvent", "0", 0}} {
+				want := 0.0
+				for _, partition := range test.wantState.Paused[claim.topic] {
+					if partition == claim.index {
+						want = 1
+					}
+				}
+				assert.Equal(t, want, testutil.ToFloat64(consumerMetrics.Paused.WithLabelValues(claim.topic, claim.partition)), "%v/%v", claim.topic, claim.partition)
+			}
+			wantAutoPaused := 0.0
+			if test.wantState.AutoPause != "" {
+				wantAutoPaused = 1
+			}
+			assert.Equal(t, wantAutoPaused, testutil.ToFloat64(consumerMetrics.AutoPaused))
+		})
+	}
+}
+
+func Test_consumerControl_Handler(t *testing.T) {
+	handled := make(chan struct{}, 10)
+	mockEventService := mockService.NewIEventService(t)
+	mockEventService.On("Handle", mock.Anything, "DepositFundEvent", mock.Anything).Return(nil).Run(func(mock.Arguments) {
+		handled <- struct{}{}
+	})
+	group := newFakePauser()
+	control := NewConsumerControl(group, 0, 1, nil, logging.Discard())
+	control.Pause("DepositFundEvent", []int32{3})
+
+	messages := make(chan *sarama.ConsumerMessage, 10)
+	done := make(chan map[string]map[int32]int64)
+	go func() {
+		marked, _ := consumeMessages(control.Handler(NewConsumerService(mockEventService, nil, nil, RetryPolicy{}, nil, logging.Discard(), 1)), "DepositFundEvent", 3, messages)
+		done <- marked
+	}()
+	for offset := int64(0); offset < 2; offset++ {
+		messages <- &sarama.ConsumerMessage{Topic: "DepositFundEvent", Partition: 3, Offset: offset, Value: []byte(`{"ID":"123","Amount":500}`)}
+	}
+
+	// fetched messages wait out the pause too
+	select {
+	case <-handled:
+		t.Fatal("want no message handled while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, map[string][]int32{"DepositFundEvent": {3}}, group.Paused())
+
+	control.Resume("DepositFundEvent", nil)
+	close(messages)
+
+	assert.Equal(t, int64(2), (<-done)["DepositFundEvent"][3])
+	assert.Len(t, handled, 2)
+	assert.Empty(t, group.Paused())
+}
+
+func Test_rateLimiter_reserve(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(10, 2)
+
+	delays := []time.Duration{}
+	for i := 0; i < 4; i++ {
+		delays = append(delays, limiter.reserve(now))
+	}
+
+	// a burst of 2 goes through at once, then one every 100ms
+	assert.Equal(t, []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond}, delays)
+	assert.Equal(t, time.Duration(0), limiter.reserve(now.Add(time.Second)))
+	assert.Equal(t, time.Duration(0), limiter.reserve(now.Add(time.Second)))
+	assert.Equal(t, 100*time.Millisecond, limiter.reserve(now.Add(time.Second)))
+}
+
+func Test_consumerControl_limit(t *testing.T) {
+	consumerMetrics := metrics.New()
+	control := NewConsumerControl(newFakePauser(), 100, 1, consumerMetrics, logging.Discard()).(*consumerControl)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.True(t, control.limit(context.Background()))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+	assert.Greater(t, testutil.ToFloat64(consumerMetrics.RateLimitWait), 0.0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	control.limiter.reserve(time.Now().Add(time.Second))
+	assert.False(t, control.limit(ctx))
+}
+
+func Test_WatchHealth(t *testing.T) {
+	group := newFakePauser()
+	control := NewConsumerControl(group, 0, 1, nil, logging.Discard())
+	results := []error{nil, errors.New("connection refused"), errors.New("connection refused"), nil}
+	states := make(chan string, len(results))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	check := func(ctx context.Context) error {
+		if calls > 0 {
+			states <- control.State().AutoPause
+		}
+		if calls == len(results) {
+			cancel()
+			return nil
+		}
+		calls++
+		return results[calls-1]
+	}
+	WatchHealth(ctx, control, "db", check, time.Millisecond)
+	close(states)
+
+	seen := []string{}
+	for state := range states {
+		seen = append(seen, state)
+	}
+	assert.Equal(t, []string{"", "db: connection refused", "db: connection refused", ""}, seen)
+}
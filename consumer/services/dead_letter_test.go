@@ -0,0 +1,168 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/metrics"
+	"consumer/repositories"
+	mockService "consumer/services/mock"
+	"context"
+	"errors"
+	"fmt"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_consumerService_ConsumeClaim_deadLetter(t *testing.T) {
+	retry := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	withdrawal := &sarama.ConsumerMessage{
+		Topic:     "WithdrawFundEvent",
+		Partition: 2,
+		Offset:    7,
+		Timestamp: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		Key:       []byte("123"),
+		Headers:   []*sarama.RecordHeader{{Key: []byte("request-id"), Value: []byte("request")}},
+		Value:     []byte(`{"ID":"123","Amount":100}`),
+	}
+
+	tests := []struct {
+		name    string
+		message *sarama.ConsumerMessage
+		errors  []error
+
+		wantCalls        int
+		wantOutcome      string
+		wantRetries      float64
+		wantDeadLettered []repositories.FailedMessage
+	}{
+		{
+			name:        "Test should retry a failed message until it is handled",
+			message:     withdrawal,
+			errors:      []error{errors.New("record not found"), nil},
+			wantCalls:   2,
+			wantOutcome: metrics.OutcomeSuccess,
+			wantRetries: 1,
+		},
+		{
+			name:        "Test should dead letter a message failing every attempt",
+			message:     withdrawal,
+			errors:      []error{errors.New("record not found"), errors.New("record not found"), errors.New("database is locked")},
+			wantCalls:   3,
+			wantOutcome: metrics.OutcomeError,
+			wantRetries: 2,
+			wantDeadLettered: []repositories.FailedMessage{{
+				Topic:     "WithdrawFundEvent",
+				Partition: 2,
+				Offset:    7,
+				Timestamp: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+				Key:       []byte("123"),
+				Value:     []byte(`{"ID":"123","Amount":100}`),
+				Headers:   map[string]string{"request-id": "request"},
+				Error:     "database is locked",
+				Attempts:  3,
+			}},
+		},
+		{
+			name: "Test should dead letter a message that does not decode without retrying",
+			message: &sarama.ConsumerMessage{
+				Topic:   "WithdrawFundEvent",
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/xml")}},
+				Value:   []byte(`<event/>`),
+			},
+			wantOutcome: metrics.OutcomeDecodeError,
+			wantDeadLettered: []repositories.FailedMessage{{
+				Topic:     "WithdrawFundEvent",
+				Timestamp: time.Time{}.UTC(),
+				Value:     []byte(`<event/>`),
+				Headers:   map[string]string{"content-type": "application/xml"},
+				Error:     `unsupported content type "application/xml"`,
+				Attempts:  1,
+			}},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockEventService := mockService.NewIEventService(t)
+			for _, err := range test.errors {
+				mockEventService.On("Handle", mock.Anything, test.message.Topic, test.message.Value).Return(err).Once()
+			}
+			failedRepo := repositories.NewFailedMessageRepository(internal.OpenSQLiteDB(fmt.Sprintf("dead_letter_%d", i)), repositories.DefaultTable)
+			consumerMetrics := metrics.New()
+
+			marked, err := replayMessages(NewConsumerService(mockEventService, nil, failedRepo, retry, consumerMetrics, logging.Discard(), 1), []*sarama.ConsumerMessage{test.message})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.message.Offset+1, marked[test.message.Topic][test.message.Partition])
+			mockEventService.AssertNumberOfCalls(t, "Handle", test.wantCalls)
+			assert.Equal(t, float64(1), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues(test.message.Topic, test.wantOutcome)))
+			assert.Equal(t, test.wantRetries, testutil.ToFloat64(consumerMetrics.Retries.WithLabelValues(test.message.Topic)))
+			assert.Equal(t, float64(len(test.wantDeadLettered)), testutil.ToFloat64(consumerMetrics.DeadLettered.WithLabelValues(test.message.Topic)))
+			deadLettered, err := failedRepo.FindAll(context.Background())
+			assert.NoError(t, err)
+			for i := range deadLettered {
+				assert.WithinDuration(t, time.Now(), deadLettered[i].FailedAt, time.Minute)
+				deadLettered[i].FailedAt = time.Time{}
+			}
+			if test.wantDeadLettered == nil {
+				test.wantDeadLettered = []repositories.FailedMessage{}
+			}
+			assert.Equal(t, test.wantDeadLettered, deadLettered)
+		})
+	}
+}
+
+// failingFailedMessageRepository fails to dead letter a message.
+type failingFailedMessageRepository struct {
+	repositories.IFailedMessageRepository
+}
+
+func (obj failingFailedMessageRepository) Save(ctx context.Context, message repositories.FailedMessage) error {
+	return errors.New("database is locked")
+}
+
+func Test_consumerService_ConsumeClaim_deadLetterFailed(t *testing.T) {
+	message := func(offset int64) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Topic: "DepositFundEvent", Offset: offset, Value: []byte(fmt.Sprintf(`{"ID":"123","Amount":%d}`, offset))}
+	}
+	messages := []*sarama.ConsumerMessage{message(6), message(7), message(8)}
+
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("Test should not mark a message that could not be dead lettered, workers %d", workers), func(t *testing.T) {
+			mockEventService := mockService.NewIEventService(t)
+			mockEventService.On("Handle", mock.Anything, "DepositFundEvent", messages[0].Value).Return(nil).Once()
+			mockEventService.On("Handle", mock.Anything, "DepositFundEvent", messages[1].Value).Return(errors.New("database is locked")).Times(2)
+			mockEventService.On("Handle", mock.Anything, "DepositFundEvent", messages[2].Value).Return(nil).Maybe()
+			consumerMetrics := metrics.New()
+			consumerService := NewConsumerService(mockEventService, nil, failingFailedMessageRepository{}, RetryPolicy{Attempts: 2, Backoff: time.Millisecond}, consumerMetrics, logging.Discard(), workers)
+
+			marked, err := replayMessages(consumerService, messages)
+
+			assert.EqualError(t, err, "database is locked")
+			// the claim ends before offset 7, so it is consumed again
+			assert.Equal(t, int64(7), marked["DepositFundEvent"][0])
+			assert.Equal(t, float64(0), testutil.ToFloat64(consumerMetrics.DeadLettered.WithLabelValues("DepositFundEvent")))
+			assert.Equal(t, float64(0), testutil.ToFloat64(consumerMetrics.InFlight.WithLabelValues("DepositFundEvent", "0")))
+		})
+	}
+}
+
+func Test_consumerService_handleWithRetries(t *testing.T) {
+	t.Run("Test should stop retrying once the claim ends", func(t *testing.T) {
+		mockEventService := mockService.NewIEventService(t)
+		mockEventService.On("Handle", mock.Anything, "DepositFundEvent", mock.Anything).Return(errors.New("record not found")).Once()
+		consumerService := NewConsumerService(mockEventService, nil, nil, RetryPolicy{Attempts: 3, Backoff: time.Hour}, nil, logging.Discard(), 1).(consumerService)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		attempts, err := consumerService.handleWithRetries(ctx, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`))
+
+		assert.EqualError(t, err, "record not found")
+		assert.Equal(t, 1, attempts)
+	})
+}
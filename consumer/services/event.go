@@ -2,99 +2,138 @@ package services
 
 import (
 	"consumer/repositories"
+	"context"
 	"encoding/json"
 	"events"
-	"log"
+	"fmt"
+	"log/slog"
+	"platform/tracing"
 	"reflect"
+
+	"go.opentelemetry.io/otel"
 )
 
 type IEventService interface {
-	Handle(topic string, eventBytes []byte)
+	Handle(ctx context.Context, topic string, eventBytes []byte) error
 }
 
 type eventService struct {
 	accountRepo repositories.IAccountRepository
+	logger      *slog.Logger
 }
 
-func NewEventService(accountRepo repositories.IAccountRepository) IEventService {
-	return eventService{accountRepo}
+func NewEventService(accountRepo repositories.IAccountRepository, logger *slog.Logger) IEventService {
+	return eventService{accountRepo, logger}
 }
 
-func (obj eventService) Handle(topic string, eventBytes []byte) {
+func (obj eventService) Handle(ctx context.Context, topic string, eventBytes []byte) error {
+	var handle func(ctx context.Context, eventBytes []byte) (events.Event, error)
 	switch topic {
 	case reflect.TypeOf(events.OpenAccountEvent{}).Name():
-		event := &events.OpenAccountEvent{}
-		err := json.Unmarshal(eventBytes, event)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		bankAccount := repositories.BankAccount{
-			ID:            event.ID,
-			AccountHolder: event.AccountHolder,
-			AccountType:   event.AccountType,
-			Balance:       event.OpeningBalance,
-		}
-		err = obj.accountRepo.Save(bankAccount)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		log.Printf("[%v] %#v", topic, event)
+		handle = obj.openAccount
 	case reflect.TypeOf(events.DepositFundEvent{}).Name():
-		event := &events.DepositFundEvent{}
-		err := json.Unmarshal(eventBytes, event)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		bankAccount, err := obj.accountRepo.FindByID(event.ID)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		bankAccount.Balance += event.Amount
-
-		err = obj.accountRepo.Save(bankAccount)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		log.Printf("[%v] %#v", topic, event)
+		handle = obj.depositFund
 	case reflect.TypeOf(events.WithdrawFundEvent{}).Name():
-		event := &events.WithdrawFundEvent{}
-		err := json.Unmarshal(eventBytes, event)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		bankAccount, err := obj.accountRepo.FindByID(event.ID)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		bankAccount.Balance -= event.Amount
-
-		err = obj.accountRepo.Save(bankAccount)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		log.Printf("[%v] %#v", topic, event)
+		handle = obj.withdrawFund
 	case reflect.TypeOf(events.CloseAccountEvent{}).Name():
-		event := &events.CloseAccountEvent{}
-		err := json.Unmarshal(eventBytes, event)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		err = obj.accountRepo.Delete(event.ID)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-		log.Printf("[%v] %#v", topic, event)
+		handle = obj.closeAccount
+	case reflect.TypeOf(events.AdjustBalanceEvent{}).Name():
+		handle = obj.adjustBalance
 	default:
-		log.Println("no event Service")
+		return fmt.Errorf("no event service for topic %v", topic)
+	}
+
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "eventService.Handle "+topic)
+	event, err := handle(ctx, eventBytes)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	obj.logger.InfoContext(ctx, "event handled", "account_id", accountID(event), "event", event)
+	return nil
+}
+
+func accountID(event events.Event) string {
+	switch event := event.(type) {
+	case *events.OpenAccountEvent:
+		return event.ID
+	case *events.DepositFundEvent:
+		return event.ID
+	case *events.WithdrawFundEvent:
+		return event.ID
+	case *events.CloseAccountEvent:
+		return event.ID
+	case *events.AdjustBalanceEvent:
+		return event.ID
+	}
+	return ""
+}
+
+func (obj eventService) openAccount(ctx context.Context, eventBytes []byte) (events.Event, error) {
+	event := &events.OpenAccountEvent{}
+	err := json.Unmarshal(eventBytes, event)
+	if err != nil {
+		return nil, err
+	}
+	bankAccount := repositories.BankAccount{
+		ID:            event.ID,
+		AccountHolder: event.AccountHolder,
+		AccountType:   event.AccountType,
+		Balance:       event.OpeningBalance,
+	}
+	return event, obj.accountRepo.Save(ctx, bankAccount)
+}
+
+func (obj eventService) depositFund(ctx context.Context, eventBytes []byte) (events.Event, error) {
+	event := &events.DepositFundEvent{}
+	err := json.Unmarshal(eventBytes, event)
+	if err != nil {
+		return nil, err
+	}
+	bankAccount, err := obj.accountRepo.FindByID(ctx, event.ID)
+	if err != nil {
+		return nil, err
 	}
+	bankAccount.Balance += event.Amount
+
+	return event, obj.accountRepo.Save(ctx, bankAccount)
+}
+
+func (obj eventService) withdrawFund(ctx context.Context, eventBytes []byte) (events.Event, error) {
+	event := &events.WithdrawFundEvent{}
+	err := json.Unmarshal(eventBytes, event)
+	if err != nil {
+		return nil, err
+	}
+	bankAccount, err := obj.accountRepo.FindByID(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	bankAccount.Balance -= event.Amount
+
+	return event, obj.accountRepo.Save(ctx, bankAccount)
+}
+
+func (obj eventService) closeAccount(ctx context.Context, eventBytes []byte) (events.Event, error) {
+	event := &events.CloseAccountEvent{}
+	err := json.Unmarshal(eventBytes, event)
+	if err != nil {
+		return nil, err
+	}
+	return event, obj.accountRepo.Delete(ctx, event.ID)
+}
+
+func (obj eventService) adjustBalance(ctx context.Context, eventBytes []byte) (events.Event, error) {
+	event := &events.AdjustBalanceEvent{}
+	err := json.Unmarshal(eventBytes, event)
+	if err != nil {
+		return nil, err
+	}
+	bankAccount, err := obj.accountRepo.FindByID(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	bankAccount.Balance += event.Amount
+
+	return event, obj.accountRepo.Save(ctx, bankAccount)
 }
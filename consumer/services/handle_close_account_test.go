@@ -0,0 +1,113 @@
+package services
+
+import (
+	"consumer/internal"
+	mockRepo "consumer/repositories/mock"
+	"context"
+	"errors"
+	"events"
+	"platform/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_eventService_Handle_CloseAccountEvent(t *testing.T) {
+	mockAccountRepo := mockRepo.NewIAccountRepository(t)
+
+	clearAllMock := func() {
+		mockAccountRepo.ClearAll()
+	}
+	tests := []struct {
+		name        string
+		mockTopic   string
+		mockPayload []byte
+
+		wantServiceOrRepoCallWithAndResponse func()
+		wantServiceOrRepoCallTimes           map[string]map[string]int
+		wantErr                              string
+	}{
+		{
+			name:        "Test should not delete when event payload is malformed json",
+			mockTopic:   "CloseAccountEvent",
+			mockPayload: []byte(`{"ID":`),
+			wantErr:     "unexpected end of JSON input",
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"Delete": 0,
+				},
+			},
+		},
+		{
+			name:        "Test should stop when delete of account repository return error",
+			mockTopic:   "CloseAccountEvent",
+			mockPayload: internal.MarshalJSONData(events.CloseAccountEvent{ID: "123"}),
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockAccountRepo.On("Delete", mock.Anything, "123").Return(errors.New("error"))
+			},
+			wantErr: "error",
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"Delete": 1,
+				},
+			},
+		},
+		{
+			name:        "Test should delete bank account when event is valid",
+			mockTopic:   "CloseAccountEvent",
+			mockPayload: internal.MarshalJSONData(events.CloseAccountEvent{ID: "123"}),
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockAccountRepo.On("Delete", mock.Anything, "123").Return(nil)
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"Delete": 1,
+				},
+			},
+		},
+		{
+			name:        "Test should return error when topic is unknown",
+			mockTopic:   "UnknownEvent",
+			mockPayload: internal.MarshalJSONData(events.CloseAccountEvent{ID: "123"}),
+			wantErr:     "no event service for topic UnknownEvent",
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"Delete":   0,
+					"FindByID": 0,
+					"Save":     0,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer clearAllMock()
+
+			if test.wantServiceOrRepoCallWithAndResponse != nil {
+				test.wantServiceOrRepoCallWithAndResponse()
+			}
+
+			eventService := NewEventService(mockAccountRepo, logging.Discard())
+			err := eventService.Handle(context.Background(), test.mockTopic, test.mockPayload)
+
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
+				for methodName, times := range serviceCallTimes {
+					switch serviceName {
+					case "accountRepository":
+						mockAccountRepo.AssertNumberOfCalls(t, methodName, times)
+					default:
+						t.Errorf("service %s or method %s not found", serviceName, methodName)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	mockRepo "consumer/repositories/mock"
+	"context"
+	"errors"
+	"events"
+	"platform/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func Test_eventService_Handle_DepositFundEvent(t *testing.T) {
+	mockAccountRepo := mockRepo.NewIAccountRepository(t)
+
+	clearAllMock := func() {
+		mockAccountRepo.ClearAll()
+	}
+	tests := []struct {
+		name        string
+		mockTopic   string
+		mockPayload []byte
+
+		wantServiceOrRepoCallWithAndResponse func()
+		wantServiceOrRepoCallTimes           map[string]map[string]int
+		wantErr                              string
+	}{
+		{
+			name:        "Test should not touch account repository when event payload is malformed json",
+			mockTopic:   "DepositFundEvent",
+			mockPayload: []byte(`{"ID":`),
+			wantErr:     "unexpected end of JSON input",
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"FindByID": 0,
+					"Save":     0,
+				},
+			},
+		},
+		{
+			name:        "Test should not save when account does not exist",
+			mockTopic:   "DepositFundEvent",
+			mockPayload: internal.MarshalJSONData(events.DepositFundEvent{ID: "123", Amount: 500}),
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockAccountRepo.On("FindByID", mock.Anything, "123").Return(repositories.BankAccount{}, gorm.ErrRecordNotFound)
+			},
+			wantErr: "record not found",
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"FindByID": 1,
+					"Save":     0,
+				},
+			},
+		},
+		{
+			name:        "Test should stop when save of account repository return error",
+			mockTopic:   "DepositFundEvent",
+			mockPayload: internal.MarshalJSONData(events.DepositFundEvent{ID: "123", Amount: 500}),
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockAccountRepo.On("FindByID", mock.Anything, "123").Return(repositories.BankAccount{
+					ID:            "123",
+					AccountHolder: "John Doe",
+					AccountType:   1,
+					Balance:       1000,
+				}, nil)
+				mockAccountRepo.On("Save", mock.Anything, repositories.BankAccount{
+					ID:            "123",
+					AccountHolder: "John Doe",
+					AccountType:   1,
+					Balance:       1500,
+				}).Return(errors.New("error"))
+			},
+			wantErr: "error",
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"FindByID": 1,
+					"Save":     1,
+				},
+			},
+		},
+		{
+			name:        "Test should save bank account with increased balance when account exists",
+			mockTopic:   "DepositFundEvent",
+			mockPayload: internal.MarshalJSONData(events.DepositFundEvent{ID: "123", Amount: 500}),
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockAccountRepo.On("FindByID", mock.Anything, "123").Return(repositories.BankAccount{
+					ID:            "123",
+					AccountHolder: "John Doe",
+					AccountType:   1,
+					Balance:       1000,
+				}, nil)
+				mockAccountRepo.On("Save", mock.Anything, repositories.BankAccount{
+					ID:            "123",
+					AccountHolder: "John Doe",
+					AccountType:   1,
+					Balance:       1500,
+				}).Return(nil)
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"accountRepository": {
+					"FindByID": 1,
+					"Save":     1,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer clearAllMock()
+
+			if test.wantServiceOrRepoCallWithAndResponse != nil {
+				test.wantServiceOrRepoCallWithAndResponse()
+			}
+
+			eventService := NewEventService(mockAccountRepo, logging.Discard())
+			err := eventService.Handle(context.Background(), test.mockTopic, test.mockPayload)
+
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
+				for methodName, times := range serviceCallTimes {
+					switch serviceName {
+					case "accountRepository":
+						mockAccountRepo.AssertNumberOfCalls(t, methodName, times)
+					default:
+						t.Errorf("service %s or method %s not found", serviceName, methodName)
+					}
+				}
+			}
+		})
+	}
+}
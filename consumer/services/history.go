@@ -0,0 +1,218 @@
+package services
+
+import (
+	"consumer/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"fmt"
+	"log/slog"
+	"platform/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
+)
+
+// ErrNoHistory means the account had no events by the requested time.
+var ErrNoHistory = errors.New("account has no history")
+
+// AccountState is an account rebuilt from its history.
+type AccountState struct {
+	ID            string
+	AccountHolder string
+	AccountType   int
+	Balance       float64
+	Closed        bool
+	// Sequence is the last event applied, 0 for none.
+	Sequence   int
+	OccurredAt time.Time
+}
+
+// apply folds one event of the account's history into the state and
+// returns it decoded.
+func (obj *AccountState) apply(accountEvent repositories.AccountEvent) (events.Event, error) {
+	event, err := events.New(accountEvent.EventType)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(accountEvent.Data), event)
+	if err != nil {
+		return nil, err
+	}
+
+	switch event := event.(type) {
+	case *events.OpenAccountEvent:
+		obj.AccountHolder = event.AccountHolder
+		obj.AccountType = event.AccountType
+		obj.Balance = event.OpeningBalance
+	case *events.DepositFundEvent:
+		obj.Balance += event.Amount
+	case *events.WithdrawFundEvent:
+		obj.Balance -= event.Amount
+	case *events.CloseAccountEvent:
+		obj.Closed = true
+	case *events.AdjustBalanceEvent:
+		obj.Balance += event.Amount
+	default:
+		return nil, fmt.Errorf("account %v: unknown event %v", accountEvent.AccountID, accountEvent.EventType)
+	}
+	obj.ID = accountEvent.AccountID
+	obj.Sequence = accountEvent.Sequence
+	obj.OccurredAt = accountEvent.OccurredAt
+	return event, nil
+}
+
+type IHistoryService interface {
+	// BalanceAsOf rebuilds the account from the nearest snapshot at or
+	// before asOf and the events after it, or returns ErrNoHistory.
+	BalanceAsOf(ctx context.Context, id string, asOf time.Time) (AccountState, error)
+}
+
+type historyService struct {
+	historyRepo repositories.IHistoryRepository
+}
+
+func NewHistoryService(historyRepo repositories.IHistoryRepository) IHistoryService {
+	return historyService{historyRepo}
+}
+
+func (obj historyService) BalanceAsOf(ctx context.Context, id string, asOf time.Time) (state AccountState, err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "historyService.BalanceAsOf")
+	defer func() { tracing.End(span, err) }()
+
+	state, err = rebuild(ctx, obj.historyRepo, id, asOf, 0)
+	if err != nil {
+		return AccountState{}, err
+	}
+	if state.Sequence == 0 {
+		return AccountState{}, ErrNoHistory
+	}
+	return state, nil
+}
+
+// rebuild replays the account's events up to asOf, and up to sequence
+// upTo unless it is 0, on top of the latest snapshot before them.
+func rebuild(ctx context.Context, historyRepo repositories.IHistoryRepository, id string, asOf time.Time, upTo int) (AccountState, error) {
+	state := AccountState{}
+	snapshot, err := historyRepo.LatestSnapshot(ctx, id, asOf)
+	switch {
+	case err == nil:
+		state = AccountState{snapshot.AccountID, snapshot.AccountHolder, snapshot.AccountType, snapshot.Balance, snapshot.Closed, snapshot.Sequence, snapshot.OccurredAt}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return AccountState{}, err
+	}
+
+	accountEvents, err := historyRepo.Events(ctx, id, state.Sequence, asOf)
+	if err != nil {
+		return AccountState{}, err
+	}
+	for _, accountEvent := range accountEvents {
+		if upTo > 0 && accountEvent.Sequence > upTo {
+			break
+		}
+		_, err = state.apply(accountEvent)
+		if err != nil {
+			return AccountState{}, err
+		}
+	}
+	return state, nil
+}
+
+type historyEventService struct {
+	next          IEventService
+	historyRepo   repositories.IHistoryRepository
+	transactor    repositories.ITransactor
+	snapshotEvery int
+	logger        *slog.Logger
+}
+
+// NewHistoryEventService appends every event next handled to the account's
+// history in the same transaction, and in it snapshots the account every
+// snapshotEvery events, so a balance query replays at most that many. Zero
+// disables snapshots. A message whose position is already in the history
+// was handled before and is skipped.
+func NewHistoryEventService(next IEventService, historyRepo repositories.IHistoryRepository, transactor repositories.ITransactor, snapshotEvery int, logger *slog.Logger) IEventService {
+	return historyEventService{next, historyRepo, transactor, snapshotEvery, logger}
+}
+
+func (obj historyEventService) Handle(ctx context.Context, topic string, eventBytes []byte) error {
+	event, err := events.New(topic)
+	if err != nil {
+		return fmt.Errorf("no event service for topic %v", topic)
+	}
+	err = json.Unmarshal(eventBytes, event)
+	if err != nil {
+		return err
+	}
+
+	position := PositionFromContext(ctx)
+	occurredAt := position.Timestamp
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	accountEvent := repositories.AccountEvent{
+		AccountID:  accountID(event),
+		EventType:  topic,
+		Data:       string(eventBytes),
+		OccurredAt: occurredAt,
+		Topic:      position.Topic,
+		Partition:  position.Partition,
+		Offset:     position.Offset,
+	}
+	skipped := false
+	err = obj.transactor.Transaction(ctx, func(ctx context.Context) error {
+		kafkaPosition := repositories.Position{Topic: position.Topic, Partition: position.Partition, Offset: position.Offset}
+		recorded, err := obj.historyRepo.Recorded(ctx, []repositories.Position{kafkaPosition})
+		if err != nil {
+			return err
+		}
+		if recorded[kafkaPosition] {
+			skipped = true
+			return nil
+		}
+
+		err = obj.next.Handle(ctx, topic, eventBytes)
+		if err != nil {
+			return err
+		}
+		accountEvent, err = obj.historyRepo.Append(ctx, accountEvent)
+		if err != nil {
+			return err
+		}
+		return snapshot(ctx, obj.historyRepo, accountEvent, obj.snapshotEvery)
+	})
+	if err != nil {
+		return err
+	}
+	if skipped {
+		obj.logger.InfoContext(ctx, "event already handled", "account_id", accountEvent.AccountID, "topic", position.Topic, "partition", position.Partition, "offset", position.Offset)
+	}
+	return nil
+}
+
+// snapshot saves the account's state after accountEvent when its sequence is
+// a multiple of snapshotEvery. It runs in the transaction that appended
+// accountEvent, so a snapshot is never ahead of the history.
+func snapshot(ctx context.Context, historyRepo repositories.IHistoryRepository, accountEvent repositories.AccountEvent, snapshotEvery int) error {
+	if snapshotEvery == 0 || accountEvent.Sequence%snapshotEvery != 0 {
+		return nil
+	}
+	state, err := rebuild(ctx, historyRepo, accountEvent.AccountID, time.Time{}, accountEvent.Sequence)
+	if err != nil {
+		return err
+	}
+	return historyRepo.SaveSnapshot(ctx, repositories.AccountSnapshot{
+		AccountID:     state.ID,
+		Sequence:      state.Sequence,
+		AccountHolder: state.AccountHolder,
+		AccountType:   state.AccountType,
+		Balance:       state.Balance,
+		Closed:        state.Closed,
+		OccurredAt:    state.OccurredAt,
+		Topic:         accountEvent.Topic,
+		Partition:     accountEvent.Partition,
+		Offset:        accountEvent.Offset,
+	})
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_historyService_BalanceAsOf(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	messages := []struct {
+		topic string
+		value string
+	}{
+		{"OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+		{"WithdrawFundEvent", `{"ID":"123","Amount":200}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":50}`},
+		{"CloseAccountEvent", `{"ID":"123"}`},
+	}
+
+	tests := []struct {
+		name string
+		asOf time.Time
+
+		wantState AccountState
+		wantError error
+	}{
+		{
+			name:      "Test should return no history before the account was opened",
+			asOf:      day.Add(-time.Second),
+			wantError: ErrNoHistory,
+		},
+		{
+			name:      "Test should return the opening balance right after opening",
+			asOf:      day,
+			wantState: AccountState{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000, Sequence: 1, OccurredAt: day},
+		},
+		{
+			name:      "Test should replay events after the nearest snapshot",
+			asOf:      day.Add(150 * time.Minute),
+			wantState: AccountState{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1300, Sequence: 3, OccurredAt: day.Add(2 * time.Hour)},
+		},
+		{
+			name:      "Test should return the balance of a closed account",
+			asOf:      day.Add(24 * time.Hour),
+			wantState: AccountState{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1350, Closed: true, Sequence: 5, OccurredAt: day.Add(4 * time.Hour)},
+		},
+	}
+
+	for snapshotEvery, wantSnapshots := range map[int]int64{0: 0, 2: 2} {
+		db := internal.OpenSQLiteDB(fmt.Sprintf("history_snapshot_every_%d", snapshotEvery))
+		historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+		eventService := NewHistoryEventService(NewEventService(repositories.NewAccountRepository(db, repositories.DefaultTable), logging.Discard()), historyRepo, repositories.NewTransactor(db), snapshotEvery, logging.Discard())
+		for i, message := range messages {
+			ctx := ContextWithPosition(context.Background(), Position{message.topic, 0, int64(i), day.Add(time.Duration(i) * time.Hour)})
+			assert.NoError(t, eventService.Handle(ctx, message.topic, []byte(message.value)))
+		}
+
+		var snapshots int64
+		assert.NoError(t, db.Table("bond_banks_snapshots").Count(&snapshots).Error)
+		assert.Equal(t, wantSnapshots, snapshots)
+
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%v with snapshots every %d events", test.name, snapshotEvery), func(t *testing.T) {
+				state, err := NewHistoryService(historyRepo).BalanceAsOf(context.Background(), "123", test.asOf)
+
+				assert.Equal(t, test.wantError, err)
+				assert.Equal(t, test.wantState, state)
+			})
+		}
+	}
+}
+
+// failingHistoryRepository fails to append to the history.
+type failingHistoryRepository struct {
+	repositories.IHistoryRepository
+}
+
+func (obj failingHistoryRepository) Append(ctx context.Context, event repositories.AccountEvent) (repositories.AccountEvent, error) {
+	return repositories.AccountEvent{}, errors.New("disk full")
+}
+
+// failingSnapshotRepository fails to save snapshots.
+type failingSnapshotRepository struct {
+	repositories.IHistoryRepository
+}
+
+func (obj failingSnapshotRepository) SaveSnapshot(ctx context.Context, snapshot repositories.AccountSnapshot) error {
+	return errors.New("disk full")
+}
+
+func Test_historyEventService_Handle(t *testing.T) {
+	ctx := context.Background()
+	opened := []byte(`{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`)
+	deposited := []byte(`{"ID":"123","Amount":500}`)
+
+	t.Run("Test should skip a redelivered message", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("history_event_redelivered")
+		accountRepo := repositories.NewAccountRepository(db, repositories.DefaultTable)
+		historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+		eventService := NewHistoryEventService(NewEventService(accountRepo, logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard())
+
+		assert.NoError(t, eventService.Handle(ContextWithPosition(ctx, Position{Topic: "OpenAccountEvent"}), "OpenAccountEvent", opened))
+		for i := 0; i < 2; i++ {
+			assert.NoError(t, eventService.Handle(ContextWithPosition(ctx, Position{Topic: "DepositFundEvent", Partition: 1, Offset: 7}), "DepositFundEvent", deposited))
+		}
+
+		bankAccount, err := accountRepo.FindByID(ctx, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1500), bankAccount.Balance)
+		accountEvents, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, accountEvents, 2)
+	})
+
+	t.Run("Test should not change the read model when the history append fails", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("history_event_append_error")
+		accountRepo := repositories.NewAccountRepository(db, repositories.DefaultTable)
+		historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+		assert.NoError(t, NewHistoryEventService(NewEventService(accountRepo, logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard()).
+			Handle(ContextWithPosition(ctx, Position{Topic: "OpenAccountEvent"}), "OpenAccountEvent", opened))
+		eventService := NewHistoryEventService(NewEventService(accountRepo, logging.Discard()), failingHistoryRepository{historyRepo}, repositories.NewTransactor(db), 0, logging.Discard())
+
+		err := eventService.Handle(ContextWithPosition(ctx, Position{Topic: "DepositFundEvent"}), "DepositFundEvent", deposited)
+
+		assert.EqualError(t, err, "disk full")
+		bankAccount, err := accountRepo.FindByID(ctx, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1000), bankAccount.Balance)
+	})
+
+	t.Run("Test should not append the event when the snapshot fails", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("history_event_snapshot_error")
+		accountRepo := repositories.NewAccountRepository(db, repositories.DefaultTable)
+		historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+		assert.NoError(t, NewHistoryEventService(NewEventService(accountRepo, logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard()).
+			Handle(ContextWithPosition(ctx, Position{Topic: "OpenAccountEvent"}), "OpenAccountEvent", opened))
+		eventService := NewHistoryEventService(NewEventService(accountRepo, logging.Discard()), failingSnapshotRepository{historyRepo}, repositories.NewTransactor(db), 2, logging.Discard())
+
+		err := eventService.Handle(ContextWithPosition(ctx, Position{Topic: "DepositFundEvent"}), "DepositFundEvent", deposited)
+
+		assert.EqualError(t, err, "disk full")
+		bankAccount, err := accountRepo.FindByID(ctx, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1000), bankAccount.Balance)
+		accountEvents, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+		assert.NoError(t, err)
+		assert.Len(t, accountEvents, 1)
+	})
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+type ILogReader interface {
+	// Read calls handle with every message of the topics, partition by
+	// partition, from the oldest one up to the newest one. committed is set
+	// for the messages before the offset the consumer group has committed,
+	// which it has handled or dead lettered.
+	Read(ctx context.Context, topics []string, handle func(msg *sarama.ConsumerMessage, committed bool) error) error
+}
+
+type kafkaLogReader struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	group  string
+}
+
+// NewKafkaLogReader reads the whole log and tells which messages group has
+// committed, so messages it is still handling are not mistaken for drift.
+func NewKafkaLogReader(client sarama.Client, admin sarama.ClusterAdmin, group string) ILogReader {
+	return kafkaLogReader{client, admin, group}
+}
+
+func (obj kafkaLogReader) Read(ctx context.Context, topics []string, handle func(msg *sarama.ConsumerMessage, committed bool) error) error {
+	topicPartitions := map[string][]int32{}
+	for _, topic := range topics {
+		partitions, err := obj.client.Partitions(topic)
+		if err != nil {
+			return err
+		}
+		topicPartitions[topic] = partitions
+	}
+	committed, err := obj.admin.ListConsumerGroupOffsets(obj.group, topicPartitions)
+	if err != nil {
+		return err
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(obj.client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	for _, topic := range topics {
+		for _, partition := range topicPartitions[topic] {
+			committedOffset := int64(-1)
+			if block := committed.GetBlock(topic, partition); block != nil {
+				committedOffset = block.Offset
+			}
+			err = obj.readPartition(ctx, consumer, topic, partition, committedOffset, handle)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readPartition reads the partition from its oldest message up to its
+// newest one. committedOffset is the offset of the next message the group
+// will consume, or -1 before it commits any.
+func (obj kafkaLogReader) readPartition(ctx context.Context, consumer sarama.Consumer, topic string, partition int32, committedOffset int64, handle func(msg *sarama.ConsumerMessage, committed bool) error) error {
+	oldest, err := obj.client.GetOffset(topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return err
+	}
+	end, err := obj.client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return err
+	}
+	if oldest >= end {
+		return nil
+	}
+
+	partitionConsumer, err := consumer.ConsumePartition(topic, partition, oldest)
+	if err != nil {
+		return err
+	}
+	defer partitionConsumer.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-partitionConsumer.Messages():
+			// sarama closes the messages when it gives up on the partition,
+			// e.g. once retention deleted the offset being read
+			if !ok {
+				return fmt.Errorf("reading %v/%v stopped before offset %v", topic, partition, end)
+			}
+			err = handle(msg, msg.Offset < committedOffset)
+			if err != nil {
+				return err
+			}
+			// offsets may have gaps after compaction or transactions
+			if msg.Offset+1 >= end {
+				return nil
+			}
+		}
+	}
+}
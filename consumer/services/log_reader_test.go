@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_kafkaLogReader_Read(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("OpenAccountEvent", 0, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 0, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 1, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "consumer", broker),
+		// the group committed up to offset 2 of the first partitions and
+		// nothing of the last
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("consumer", "OpenAccountEvent", 0, 2, "", sarama.ErrNoError).
+			SetOffset("consumer", "DepositFundEvent", 0, 2, "", sarama.ErrNoError).
+			SetOffset("consumer", "DepositFundEvent", 1, -1, "", sarama.ErrNoError),
+		// kafka 1.0.0 lists offsets with v1 and fetches with v4
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("OpenAccountEvent", 0, sarama.OffsetOldest, 0).
+			SetOffset("DepositFundEvent", 0, sarama.OffsetOldest, 1).
+			SetOffset("DepositFundEvent", 1, sarama.OffsetOldest, 0).
+			SetOffset("OpenAccountEvent", 0, sarama.OffsetNewest, 3).
+			SetOffset("DepositFundEvent", 0, sarama.OffsetNewest, 2).
+			SetOffset("DepositFundEvent", 1, sarama.OffsetNewest, 1),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetVersion(4).
+			SetMessage("OpenAccountEvent", 0, 0, sarama.StringEncoder("open 0")).
+			SetMessage("OpenAccountEvent", 0, 1, sarama.StringEncoder("open 1")).
+			SetMessage("OpenAccountEvent", 0, 2, sarama.StringEncoder("open 2")).
+			SetMessage("DepositFundEvent", 0, 1, sarama.StringEncoder("deposit 1")).
+			SetMessage("DepositFundEvent", 1, 0, sarama.StringEncoder("deposit 0")).
+			SetHighWaterMark("OpenAccountEvent", 0, 3).
+			SetHighWaterMark("DepositFundEvent", 0, 2).
+			SetHighWaterMark("DepositFundEvent", 1, 1),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V1_0_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	assert.NoError(t, err)
+	defer client.Close()
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	assert.NoError(t, err)
+
+	var values []string
+	err = NewKafkaLogReader(client, admin, "consumer").Read(context.Background(), []string{"OpenAccountEvent", "DepositFundEvent"}, func(msg *sarama.ConsumerMessage, committed bool) error {
+		values = append(values, fmt.Sprintf("%s %v", msg.Value, committed))
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"open 0 true", "open 1 true", "open 2 false", "deposit 1 true", "deposit 0 false"}, values)
+}
+
+func Test_kafkaLogReader_Read_stopped(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	fetch := &sarama.FetchResponse{Version: 4}
+	fetch.AddError("OpenAccountEvent", 0, sarama.ErrOffsetOutOfRange)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("OpenAccountEvent", 0, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "consumer", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("consumer", "OpenAccountEvent", 0, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("OpenAccountEvent", 0, sarama.OffsetOldest, 0).
+			SetOffset("OpenAccountEvent", 0, sarama.OffsetNewest, 3),
+		// retention deleted the messages between listing and fetching them
+		"FetchRequest": sarama.NewMockWrapper(fetch),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V1_0_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	assert.NoError(t, err)
+	defer client.Close()
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	assert.NoError(t, err)
+
+	err = NewKafkaLogReader(client, admin, "consumer").Read(context.Background(), []string{"OpenAccountEvent"}, func(msg *sarama.ConsumerMessage, committed bool) error {
+		return nil
+	})
+
+	assert.EqualError(t, err, "reading OpenAccountEvent/0 stopped before offset 3")
+}
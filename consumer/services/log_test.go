@@ -0,0 +1,44 @@
+package services
+
+import (
+	"bytes"
+	mockService "consumer/services/mock"
+	"encoding/json"
+	"errors"
+	"platform/logging"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_consumerService_ConsumeClaim_log(t *testing.T) {
+	mockEventService := mockService.NewIEventService(t)
+	mockEventService.On("Handle", mock.Anything, "WithdrawFundEvent", mock.Anything).Return(errors.New("record not found"))
+
+	output := &bytes.Buffer{}
+	logger, err := logging.New(output, logging.Config{})
+	assert.NoError(t, err)
+
+	_, err = replayMessages(NewConsumerService(mockEventService, nil, nil, RetryPolicy{}, nil, logger, 1), []*sarama.ConsumerMessage{
+		{
+			Topic:     "WithdrawFundEvent",
+			Partition: 2,
+			Offset:    7,
+			Headers:   []*sarama.RecordHeader{{Key: []byte("request-id"), Value: []byte("request")}},
+			Value:     []byte(`{"ID":"123","Amount":100}`),
+		},
+	})
+	assert.NoError(t, err)
+
+	record := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "message handling failed", record["msg"])
+	assert.Equal(t, "WithdrawFundEvent", record["topic"])
+	assert.Equal(t, float64(2), record["partition"])
+	assert.Equal(t, float64(7), record["offset"])
+	assert.Equal(t, "request", record["request_id"])
+	assert.Equal(t, "record not found", record["error"])
+}
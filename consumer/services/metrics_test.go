@@ -0,0 +1,52 @@
+package services
+
+import (
+	"consumer/metrics"
+	mockService "consumer/services/mock"
+	"errors"
+	"platform/logging"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_consumerService_ConsumeClaim_metrics(t *testing.T) {
+	mockEventService := mockService.NewIEventService(t)
+	mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+	mockEventService.On("Handle", mock.Anything, "WithdrawFundEvent", mock.Anything).Return(errors.New("record not found"))
+
+	consumerMetrics := metrics.New()
+	consumerService := NewConsumerService(mockEventService, nil, nil, RetryPolicy{}, consumerMetrics, logging.Discard(), 1)
+
+	_, err := replayMessages(consumerService, []*sarama.ConsumerMessage{
+		{Topic: "DepositFundEvent", Partition: 0, Offset: 0, Value: []byte(`{"ID":"123","Amount":500}`)},
+		{Topic: "DepositFundEvent", Partition: 0, Offset: 1, Value: []byte(`{"ID":"123","Amount":500}`)},
+		{Topic: "WithdrawFundEvent", Partition: 0, Offset: 2, Value: []byte(`{"ID":"456","Amount":100}`)},
+		{
+			Topic:     "DepositFundEvent",
+			Partition: 0,
+			Offset:    3,
+			Headers:   []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/xml")}},
+			Value:     []byte(`<event/>`),
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues("DepositFundEvent", metrics.OutcomeSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues("WithdrawFundEvent", metrics.OutcomeError)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(consumerMetrics.MessagesConsumed.WithLabelValues("DepositFundEvent", metrics.OutcomeDecodeError)))
+	assert.Equal(t, 2, testutil.CollectAndCount(consumerMetrics.HandlerDuration))
+
+	// the fake claim's high water mark is one past the last offset replayed
+	assert.Equal(t, float64(0), testutil.ToFloat64(consumerMetrics.ConsumerLag.WithLabelValues("DepositFundEvent", "0")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(consumerMetrics.ConsumerLag.WithLabelValues("WithdrawFundEvent", "0")))
+}
+
+func Test_lag(t *testing.T) {
+	assert.Equal(t, int64(9), lag(10, 0))
+	assert.Equal(t, int64(0), lag(10, 9))
+	assert.Equal(t, int64(0), lag(0, 4))
+}
@@ -0,0 +1,7 @@
+package mockService
+
+import "github.com/stretchr/testify/mock"
+
+func (m *IEventService) ClearAll() {
+	m.Mock = mock.Mock{}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/Shopify/sarama"
+)
+
+// workerQueue is how many messages a worker holds before dispatching to it
+// blocks, so one slow account holds up the others only once its worker's
+// queue is full.
+const workerQueue = 64
+
+// workerPool handles the messages of a claim on several goroutines.
+// Messages with the same key go to the same worker, so they are handled in
+// the order they arrived.
+type workerPool struct {
+	queues  []chan *sarama.ConsumerMessage
+	tracker *offsetTracker
+	wg      *sync.WaitGroup
+	failure *poolFailure
+}
+
+// newWorkerPool starts workers that call handle for each message, and calls
+// mark with the newest message every older one of which has been handled.
+// Once handle fails, the workers skip the messages left in their queues, so
+// nothing is marked past the failed message.
+func newWorkerPool(workers int, handle func(msg *sarama.ConsumerMessage) error, mark func(msg *sarama.ConsumerMessage)) workerPool {
+	obj := workerPool{
+		queues:  make([]chan *sarama.ConsumerMessage, workers),
+		tracker: newOffsetTracker(mark),
+		wg:      &sync.WaitGroup{},
+		failure: &poolFailure{failed: make(chan struct{})},
+	}
+	for i := range obj.queues {
+		queue := make(chan *sarama.ConsumerMessage, workerQueue)
+		obj.queues[i] = queue
+		obj.wg.Add(1)
+		go func() {
+			defer obj.wg.Done()
+			for msg := range queue {
+				if obj.err() != nil {
+					continue
+				}
+				err := handle(msg)
+				if err != nil {
+					obj.failure.set(err)
+					continue
+				}
+				obj.tracker.complete(msg)
+			}
+		}()
+	}
+	return obj
+}
+
+// failed is closed once handle fails.
+func (obj workerPool) failed() <-chan struct{} {
+	return obj.failure.failed
+}
+
+// err is the first error handle returned.
+func (obj workerPool) err() error {
+	select {
+	case <-obj.failure.failed:
+		return obj.failure.err
+	default:
+		return nil
+	}
+}
+
+// dispatch queues msg on the worker for key. Messages must be dispatched in
+// offset order.
+func (obj workerPool) dispatch(key string, msg *sarama.ConsumerMessage) {
+	obj.tracker.add(msg)
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	obj.queues[hash.Sum32()%uint32(len(obj.queues))] <- msg
+}
+
+// close waits for the workers to handle every queued message.
+func (obj workerPool) close() {
+	for _, queue := range obj.queues {
+		close(queue)
+	}
+	obj.wg.Wait()
+}
+
+// poolFailure keeps the first error of a pool's workers.
+type poolFailure struct {
+	once   sync.Once
+	err    error
+	failed chan struct{}
+}
+
+func (obj *poolFailure) set(err error) {
+	obj.once.Do(func() {
+		obj.err = err
+		close(obj.failed)
+	})
+}
+
+// offsetTracker marks a message only once it and every message dispatched
+// before it have been handled, so a committed offset never skips a message
+// still in flight.
+type offsetTracker struct {
+	mutex   *sync.Mutex
+	pending []*sarama.ConsumerMessage
+	handled map[int64]bool
+	mark    func(msg *sarama.ConsumerMessage)
+}
+
+func newOffsetTracker(mark func(msg *sarama.ConsumerMessage)) *offsetTracker {
+	return &offsetTracker{&sync.Mutex{}, nil, map[int64]bool{}, mark}
+}
+
+func (obj *offsetTracker) add(msg *sarama.ConsumerMessage) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	obj.pending = append(obj.pending, msg)
+}
+
+// complete records msg as handled and marks the newest message of the
+// handled prefix, under the lock so marks never go backwards.
+func (obj *offsetTracker) complete(msg *sarama.ConsumerMessage) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	obj.handled[msg.Offset] = true
+	var last *sarama.ConsumerMessage
+	for len(obj.pending) > 0 && obj.handled[obj.pending[0].Offset] {
+		last = obj.pending[0]
+		delete(obj.handled, last.Offset)
+		obj.pending = obj.pending[1:]
+	}
+	if last != nil {
+		obj.mark(last)
+	}
+}
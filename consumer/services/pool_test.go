@@ -0,0 +1,212 @@
+package services
+
+import (
+	"consumer/metrics"
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"fmt"
+	"math/rand"
+	"platform/logging"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_offsetTracker(t *testing.T) {
+	marked := []int64{}
+	tracker := newOffsetTracker(func(msg *sarama.ConsumerMessage) {
+		marked = append(marked, msg.Offset)
+	})
+	messages := []*sarama.ConsumerMessage{}
+	for offset := int64(10); offset < 15; offset++ {
+		msg := &sarama.ConsumerMessage{Offset: offset}
+		messages = append(messages, msg)
+		tracker.add(msg)
+	}
+
+	for _, i := range []int{2, 0, 1, 4, 3} {
+		tracker.complete(messages[i])
+	}
+
+	// 12 waits for 10, and 14 for 13
+	assert.Equal(t, []int64{10, 12, 14}, marked)
+	assert.Empty(t, tracker.pending)
+	assert.Empty(t, tracker.handled)
+}
+
+func Test_workerPool(t *testing.T) {
+	const count = 500
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	delays := make([]time.Duration, count)
+	for i := range delays {
+		delays[i] = time.Duration(random.Intn(300)) * time.Microsecond
+	}
+
+	mutex := sync.Mutex{}
+	handled := map[int64]bool{}
+	order := map[string][]int64{}
+	marked := []int64{}
+	pool := newWorkerPool(8,
+		func(msg *sarama.ConsumerMessage) error {
+			time.Sleep(delays[msg.Offset])
+			mutex.Lock()
+			defer mutex.Unlock()
+			handled[msg.Offset] = true
+			order[string(msg.Key)] = append(order[string(msg.Key)], msg.Offset)
+			return nil
+		},
+		func(msg *sarama.ConsumerMessage) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for offset := int64(0); offset <= msg.Offset; offset++ {
+				if !handled[offset] {
+					t.Errorf("marked offset %v before offset %v was handled", msg.Offset, offset)
+				}
+			}
+			marked = append(marked, msg.Offset)
+		},
+	)
+	for offset := int64(0); offset < count; offset++ {
+		key := fmt.Sprint(random.Intn(20))
+		pool.dispatch(key, &sarama.ConsumerMessage{Offset: offset, Key: []byte(key)})
+	}
+	pool.close()
+
+	assert.Len(t, handled, count)
+	for key, offsets := range order {
+		assert.IsIncreasing(t, offsets, "account %v", key)
+	}
+	assert.IsIncreasing(t, marked)
+	assert.Equal(t, int64(count-1), marked[len(marked)-1])
+	assert.NoError(t, pool.err())
+}
+
+func Test_workerPool_failure(t *testing.T) {
+	handled := []int64{}
+	marked := []int64{}
+	pool := newWorkerPool(1,
+		func(msg *sarama.ConsumerMessage) error {
+			handled = append(handled, msg.Offset)
+			if msg.Offset == 2 {
+				return errors.New("database is locked")
+			}
+			return nil
+		},
+		func(msg *sarama.ConsumerMessage) {
+			marked = append(marked, msg.Offset)
+		},
+	)
+	for offset := int64(0); offset < 5; offset++ {
+		pool.dispatch("", &sarama.ConsumerMessage{Offset: offset})
+	}
+	pool.close()
+
+	// the messages after the failed one are skipped
+	assert.Equal(t, []int64{0, 1, 2}, handled)
+	assert.Equal(t, []int64{0, 1}, marked)
+	assert.EqualError(t, pool.err(), "database is locked")
+	select {
+	case <-pool.failed():
+	default:
+		t.Error("failed is not closed")
+	}
+}
+
+// orderingEventService records the accounts' events in the order they were
+// handled, each after a random delay.
+type orderingEventService struct {
+	mutex  *sync.Mutex
+	random *rand.Rand
+	order  map[string][]float64
+}
+
+func (obj orderingEventService) Handle(ctx context.Context, topic string, eventBytes []byte) error {
+	event := events.DepositFundEvent{}
+	err := json.Unmarshal(eventBytes, &event)
+	if err != nil {
+		return err
+	}
+
+	obj.mutex.Lock()
+	delay := time.Duration(obj.random.Intn(300)) * time.Microsecond
+	obj.mutex.Unlock()
+	time.Sleep(delay)
+
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	obj.order[event.ID] = append(obj.order[event.ID], event.Amount)
+	return nil
+}
+
+func Test_consumerService_ConsumeClaim_workers(t *testing.T) {
+	eventService := orderingEventService{&sync.Mutex{}, rand.New(rand.NewSource(time.Now().UnixNano())), map[string][]float64{}}
+	consumerMetrics := metrics.New()
+	messages := []*sarama.ConsumerMessage{}
+	for offset := 0; offset < 300; offset++ {
+		// amounts count up per account, so each account's order shows
+		value := fmt.Sprintf(`{"ID":"%v","Amount":%v}`, offset%7, offset/7)
+		messages = append(messages, &sarama.ConsumerMessage{Topic: "DepositFundEvent", Partition: 1, Offset: int64(offset), Value: []byte(value)})
+	}
+
+	markedOffsets, err := replayMessages(NewConsumerService(eventService, nil, nil, RetryPolicy{}, consumerMetrics, logging.Discard(), 4), messages)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), markedOffsets["DepositFundEvent"][1])
+	assert.Len(t, eventService.order, 7)
+	for id, amounts := range eventService.order {
+		assert.IsIncreasing(t, amounts, "account %v", id)
+	}
+	assert.Equal(t, float64(0), testutil.ToFloat64(consumerMetrics.InFlight.WithLabelValues("DepositFundEvent", "1")))
+}
+
+func Test_messageKey(t *testing.T) {
+	protobufValue, _ := events.ProtobufCodec{}.Encode(events.DepositFundEvent{ID: "123", Amount: 500})
+
+	tests := []struct {
+		name        string
+		mockMessage *sarama.ConsumerMessage
+
+		wantKey string
+	}{
+		{
+			name:        "Test should use the message key when set",
+			mockMessage: &sarama.ConsumerMessage{Topic: "DepositFundEvent", Key: []byte("456"), Value: []byte(`{"ID":"123","Amount":500}`)},
+			wantKey:     "456",
+		},
+		{
+			name:        "Test should use the account id of a json payload",
+			mockMessage: &sarama.ConsumerMessage{Topic: "DepositFundEvent", Value: []byte(`{"ID":"123","Amount":500}`)},
+			wantKey:     "123",
+		},
+		{
+			name: "Test should use the account id of a protobuf payload",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:   "DepositFundEvent",
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte(events.ContentTypeProtobuf)}},
+				Value:   protobufValue,
+			},
+			wantKey: "123",
+		},
+		{
+			name: "Test should use the empty key when payload does not decode",
+			mockMessage: &sarama.ConsumerMessage{
+				Topic:   "DepositFundEvent",
+				Headers: []*sarama.RecordHeader{{Key: []byte("content-type"), Value: []byte("application/xml")}},
+				Value:   []byte(`<event/>`),
+			},
+			wantKey: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantKey, messageKey(test.mockMessage))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// Position is where the message being handled was read from.
+type Position struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	// Timestamp is the message timestamp, zero when the broker is older
+	// than Kafka 0.10.
+	Timestamp time.Time
+}
+
+type positionKey struct{}
+
+func ContextWithPosition(ctx context.Context, position Position) context.Context {
+	return context.WithValue(ctx, positionKey{}, position)
+}
+
+func PositionFromContext(ctx context.Context) Position {
+	position, _ := ctx.Value(positionKey{}).(Position)
+	return position
+}
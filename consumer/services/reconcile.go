@@ -0,0 +1,274 @@
+package services
+
+import (
+	"consumer/repositories"
+	"context"
+	"errors"
+	"events"
+	"log/slog"
+	"math"
+	"platform/logging"
+	"platform/tracing"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// Discrepancy kinds.
+const (
+	// DiscrepancyBalance is an account whose stored balance differs from
+	// the one its events add up to.
+	DiscrepancyBalance = "balance_mismatch"
+	// DiscrepancyDetails is an account whose holder or type differs.
+	DiscrepancyDetails = "details_mismatch"
+	// DiscrepancyMissing is an account open in the log but not stored.
+	DiscrepancyMissing = "missing"
+	// DiscrepancyUnexpected is a stored account that the log never opened
+	// or has closed.
+	DiscrepancyUnexpected = "unexpected"
+)
+
+// AdjustmentReason is the reason of the adjustment events reconciliation
+// emits.
+const AdjustmentReason = "reconciliation"
+
+// balanceTolerance absorbs the rounding of adding the same amounts in
+// another order.
+const balanceTolerance = 1e-6
+
+// ReconciledAccount is one side of a discrepancy.
+type ReconciledAccount struct {
+	AccountHolder string  `json:"accountHolder"`
+	AccountType   int     `json:"accountType"`
+	Balance       float64 `json:"balance"`
+}
+
+func reconciledAccount(bankAccount repositories.BankAccount) *ReconciledAccount {
+	return &ReconciledAccount{bankAccount.AccountHolder, bankAccount.AccountType, bankAccount.Balance}
+}
+
+// Discrepancy is one account where the read model disagrees with the log.
+// Expected is absent when the log has no such open account, and Actual when
+// the read model has none.
+type Discrepancy struct {
+	AccountID string             `json:"accountId"`
+	Kind      string             `json:"kind"`
+	Expected  *ReconciledAccount `json:"expected,omitempty"`
+	Actual    *ReconciledAccount `json:"actual,omitempty"`
+	// Difference is the expected minus the stored balance.
+	Difference float64 `json:"difference"`
+	// Adjusted is set once an AdjustBalanceEvent for Difference was sent.
+	Adjusted bool `json:"adjusted"`
+	// AdjustmentPending is set when an adjustment for the account is in the
+	// log but not applied yet, so no other one is sent.
+	AdjustmentPending bool `json:"adjustmentPending"`
+}
+
+// SkippedMessage is a message of the log that could not be replayed, e.g.
+// a deposit to an account that was never opened.
+type SkippedMessage struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Error     string `json:"error"`
+}
+
+// ReconciliationReport is the outcome of a reconciliation run.
+type ReconciliationReport struct {
+	// RunID is the correlation ID of the adjustments of the run.
+	RunID       string    `json:"runId"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Messages is the number of messages read from the log.
+	Messages int `json:"messages"`
+	// Pending is the number of messages the read model has not applied
+	// yet, in flight or dead lettered, which are left out of the expected
+	// read model.
+	Pending int `json:"pending"`
+	// Accounts is the number of accounts open according to the log.
+	Accounts      int              `json:"accounts"`
+	Skipped       []SkippedMessage `json:"skipped"`
+	Discrepancies []Discrepancy    `json:"discrepancies"`
+}
+
+type IReconciliationService interface {
+	// Reconcile replays the log into an empty read model and compares it
+	// with the stored one. With adjust set it emits an AdjustBalanceEvent
+	// for every balance mismatch without a pending adjustment.
+	Reconcile(ctx context.Context, adjust bool) (ReconciliationReport, error)
+}
+
+type reconciliationService struct {
+	logReader   ILogReader
+	accountRepo repositories.IAccountRepository
+	historyRepo repositories.IHistoryRepository
+	failedRepo  repositories.IFailedMessageRepository
+	adjuster    IBalanceAdjuster
+	logger      *slog.Logger
+}
+
+// NewReconciliationService builds the reconciliation. The history and the
+// failed messages tell which messages the read model has applied, as it
+// may be ahead of the committed offsets and behind them by the dead
+// lettered messages. adjuster is only used to adjust and may be nil
+// otherwise.
+func NewReconciliationService(logReader ILogReader, accountRepo repositories.IAccountRepository, historyRepo repositories.IHistoryRepository, failedRepo repositories.IFailedMessageRepository, adjuster IBalanceAdjuster, logger *slog.Logger) IReconciliationService {
+	return reconciliationService{logReader, accountRepo, historyRepo, failedRepo, adjuster, logger}
+}
+
+func (obj reconciliationService) Reconcile(ctx context.Context, adjust bool) (report ReconciliationReport, err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "reconciliationService.Reconcile")
+	defer func() { tracing.End(span, err) }()
+
+	if adjust && obj.adjuster == nil {
+		return ReconciliationReport{}, errors.New("adjusting needs a balance adjuster")
+	}
+	report = ReconciliationReport{RunID: uuid.NewString(), GeneratedAt: time.Now().UTC(), Skipped: []SkippedMessage{}, Discrepancies: []Discrepancy{}}
+
+	expected, pendingAdjustments, err := obj.replay(ctx, &report)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	actual, err := obj.accountRepo.FindAll(ctx)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	report.Accounts = len(expected)
+	report.Discrepancies = compareAccounts(expected, actual)
+	for i, discrepancy := range report.Discrepancies {
+		report.Discrepancies[i].AdjustmentPending = pendingAdjustments[discrepancy.AccountID]
+	}
+
+	if !adjust {
+		return report, nil
+	}
+	// an adjustment in the log is applied once, so a run only adjusts an
+	// account while none is pending and a second run adds no other
+	ctx = events.ContextWithMetadata(ctx, events.Metadata{CorrelationID: report.RunID})
+	for i, discrepancy := range report.Discrepancies {
+		if discrepancy.Kind != DiscrepancyBalance {
+			continue
+		}
+		if discrepancy.AdjustmentPending {
+			obj.logger.InfoContext(ctx, "balance adjustment pending", "account_id", discrepancy.AccountID)
+			continue
+		}
+		err = obj.adjuster.Adjust(ctx, events.AdjustBalanceEvent{ID: discrepancy.AccountID, Amount: discrepancy.Difference, Reason: AdjustmentReason})
+		if err != nil {
+			return report, err
+		}
+		report.Discrepancies[i].Adjusted = true
+		obj.logger.InfoContext(ctx, "balance adjusted", "account_id", discrepancy.AccountID, "amount", discrepancy.Difference, "run_id", report.RunID)
+	}
+	return report, nil
+}
+
+// replay reads the log and handles the messages the read model has
+// applied in the order they were produced, by timestamp across topics, with
+// the consumer's own handlers against an in-memory read model. Those are
+// the committed messages but the dead lettered ones, and the later ones in
+// the history. Adjustments are left out, as they correct the stored read
+// model rather than the log; it returns the accounts with one pending.
+func (obj reconciliationService) replay(ctx context.Context, report *ReconciliationReport) ([]repositories.BankAccount, map[string]bool, error) {
+	failedMessages, err := obj.failedRepo.FindAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending := map[repositories.Position]bool{}
+	for _, message := range failedMessages {
+		pending[repositories.Position{Topic: message.Topic, Partition: message.Partition, Offset: message.Offset}] = true
+	}
+
+	var read []*sarama.ConsumerMessage
+	var uncommitted []repositories.Position
+	err = obj.logReader.Read(ctx, events.Topics, func(msg *sarama.ConsumerMessage, committed bool) error {
+		report.Messages++
+		read = append(read, msg)
+		if !committed {
+			uncommitted = append(uncommitted, repositories.Position{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	applied := map[repositories.Position]bool{}
+	if len(uncommitted) > 0 {
+		applied, err = obj.historyRepo.Recorded(ctx, uncommitted)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, position := range uncommitted {
+		pending[position] = !applied[position]
+	}
+
+	adjustTopic := reflect.TypeOf(events.AdjustBalanceEvent{}).Name()
+	pendingAdjustments := map[string]bool{}
+	var messages []*sarama.ConsumerMessage
+	for _, msg := range read {
+		switch {
+		case pending[repositories.Position{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset}]:
+			report.Pending++
+			if msg.Topic == adjustTopic {
+				pendingAdjustments[messageKey(msg)] = true
+			}
+		case msg.Topic != adjustTopic:
+			messages = append(messages, msg)
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Timestamp.Before(messages[j].Timestamp) })
+
+	expectedRepo := repositories.NewMemoryAccountRepository()
+	eventService := NewUpcastingEventService(NewEventService(expectedRepo, logging.Discard()), Upcasters)
+	for _, msg := range messages {
+		headers := map[string]string{}
+		for _, header := range msg.Headers {
+			headers[string(header.Key)] = string(header.Value)
+		}
+		eventBytes, err := decodePayload(msg.Topic, events.MetadataFromHeaders(headers).ContentType, msg.Value)
+		if err == nil {
+			err = eventService.Handle(ctx, msg.Topic, eventBytes)
+		}
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedMessage{msg.Topic, msg.Partition, msg.Offset, err.Error()})
+		}
+	}
+	expected, err := expectedRepo.FindAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expected, pendingAdjustments, nil
+}
+
+// compareAccounts lists the discrepancies between the expected and the
+// stored accounts, ordered by account ID.
+func compareAccounts(expected, actual []repositories.BankAccount) []Discrepancy {
+	stored := map[string]repositories.BankAccount{}
+	for _, bankAccount := range actual {
+		stored[bankAccount.ID] = bankAccount
+	}
+
+	discrepancies := []Discrepancy{}
+	for _, want := range expected {
+		got, ok := stored[want.ID]
+		delete(stored, want.ID)
+		switch {
+		case !ok:
+			discrepancies = append(discrepancies, Discrepancy{AccountID: want.ID, Kind: DiscrepancyMissing, Expected: reconciledAccount(want), Difference: want.Balance})
+		case math.Abs(want.Balance-got.Balance) > balanceTolerance:
+			discrepancies = append(discrepancies, Discrepancy{AccountID: want.ID, Kind: DiscrepancyBalance, Expected: reconciledAccount(want), Actual: reconciledAccount(got), Difference: want.Balance - got.Balance})
+		case want.AccountHolder != got.AccountHolder || want.AccountType != got.AccountType:
+			discrepancies = append(discrepancies, Discrepancy{AccountID: want.ID, Kind: DiscrepancyDetails, Expected: reconciledAccount(want), Actual: reconciledAccount(got)})
+		}
+	}
+	for _, got := range stored {
+		discrepancies = append(discrepancies, Discrepancy{AccountID: got.ID, Kind: DiscrepancyUnexpected, Actual: reconciledAccount(got), Difference: -got.Balance})
+	}
+
+	sort.SliceStable(discrepancies, func(i, j int) bool { return discrepancies[i].AccountID < discrepancies[j].AccountID })
+	return discrepancies
+}
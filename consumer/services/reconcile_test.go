@@ -0,0 +1,251 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"context"
+	"errors"
+	"events"
+	"fmt"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLogReader reads messages, which the group committed but for the
+// uncommitted offsets.
+type fakeLogReader struct {
+	messages    []*sarama.ConsumerMessage
+	uncommitted map[int64]bool
+}
+
+func (obj fakeLogReader) Read(ctx context.Context, topics []string, handle func(msg *sarama.ConsumerMessage, committed bool) error) error {
+	for _, msg := range obj.messages {
+		err := handle(msg, !obj.uncommitted[msg.Offset])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeBalanceAdjuster struct {
+	events         []events.AdjustBalanceEvent
+	correlationIDs []string
+	err            error
+}
+
+func (obj *fakeBalanceAdjuster) Adjust(ctx context.Context, event events.AdjustBalanceEvent) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	obj.events = append(obj.events, event)
+	obj.correlationIDs = append(obj.correlationIDs, events.MetadataFromContext(ctx).CorrelationID)
+	return nil
+}
+
+func Test_reconciliationService_Reconcile(t *testing.T) {
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	message := func(minute int, topic, value string) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Topic: topic, Offset: int64(minute), Timestamp: day.Add(time.Duration(minute) * time.Minute), Value: []byte(value)}
+	}
+	// read topic by topic, so deposits come before the open they follow
+	log := fakeLogReader{messages: []*sarama.ConsumerMessage{
+		message(0, "OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`),
+		message(1, "OpenAccountEvent", `{"ID":"456","AccountHolder":"Jane Doe","AccountType":2,"OpeningBalance":2000}`),
+		message(2, "OpenAccountEvent", `{"ID":"789","AccountHolder":"Jim Doe","AccountType":1,"OpeningBalance":300}`),
+		message(3, "DepositFundEvent", `{"ID":"123","Amount":500}`),
+		message(5, "DepositFundEvent", `{"ID":"999","Amount":50}`),
+		message(4, "WithdrawFundEvent", `{"ID":"456","Amount":100}`),
+		message(6, "CloseAccountEvent", `{"ID":"789"}`),
+		message(7, "AdjustBalanceEvent", `{"ID":"123","Amount":500,"Reason":"reconciliation"}`),
+	}}
+
+	tests := []struct {
+		name       string
+		mockStored []repositories.BankAccount
+		mockAdjust bool
+		mockErr    error
+
+		wantDiscrepancies []Discrepancy
+		wantAdjustments   []events.AdjustBalanceEvent
+		wantError         error
+	}{
+		{
+			name: "Test should report no discrepancies when the read model matches the log",
+			mockStored: []repositories.BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1500},
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 1900},
+			},
+			wantDiscrepancies: []Discrepancy{},
+		},
+		{
+			name: "Test should report every kind of discrepancy",
+			mockStored: []repositories.BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+				{ID: "789", AccountHolder: "Jim Doe", AccountType: 1, Balance: 300},
+			},
+			wantDiscrepancies: []Discrepancy{
+				{
+					AccountID:  "123",
+					Kind:       DiscrepancyBalance,
+					Expected:   &ReconciledAccount{"John Doe", 1, 1500},
+					Actual:     &ReconciledAccount{"John Doe", 1, 1000},
+					Difference: 500,
+				},
+				{AccountID: "456", Kind: DiscrepancyMissing, Expected: &ReconciledAccount{"Jane Doe", 2, 1900}, Difference: 1900},
+				{AccountID: "789", Kind: DiscrepancyUnexpected, Actual: &ReconciledAccount{"Jim Doe", 1, 300}, Difference: -300},
+			},
+		},
+		{
+			name: "Test should report a changed holder",
+			mockStored: []repositories.BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1500},
+				{ID: "456", AccountHolder: "Jane Roe", AccountType: 2, Balance: 1900},
+			},
+			wantDiscrepancies: []Discrepancy{
+				{AccountID: "456", Kind: DiscrepancyDetails, Expected: &ReconciledAccount{"Jane Doe", 2, 1900}, Actual: &ReconciledAccount{"Jane Roe", 2, 1900}},
+			},
+		},
+		{
+			name: "Test should adjust balance mismatches only",
+			mockStored: []repositories.BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+				{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 2000},
+				{ID: "789", AccountHolder: "Jim Doe", AccountType: 1, Balance: 300},
+			},
+			mockAdjust: true,
+			wantDiscrepancies: []Discrepancy{
+				{
+					AccountID:  "123",
+					Kind:       DiscrepancyBalance,
+					Expected:   &ReconciledAccount{"John Doe", 1, 1500},
+					Actual:     &ReconciledAccount{"John Doe", 1, 1000},
+					Difference: 500,
+					Adjusted:   true,
+				},
+				{
+					AccountID:  "456",
+					Kind:       DiscrepancyBalance,
+					Expected:   &ReconciledAccount{"Jane Doe", 2, 1900},
+					Actual:     &ReconciledAccount{"Jane Doe", 2, 2000},
+					Difference: -100,
+					Adjusted:   true,
+				},
+				{AccountID: "789", Kind: DiscrepancyUnexpected, Actual: &ReconciledAccount{"Jim Doe", 1, 300}, Difference: -300},
+			},
+			wantAdjustments: []events.AdjustBalanceEvent{
+				{ID: "123", Amount: 500, Reason: AdjustmentReason},
+				{ID: "456", Amount: -100, Reason: AdjustmentReason},
+			},
+		},
+		{
+			name: "Test should stop when an adjustment is rejected",
+			mockStored: []repositories.BankAccount{
+				{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000},
+			},
+			mockAdjust: true,
+			mockErr:    errors.New("error"),
+			wantError:  errors.New("error"),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("reconcile_%d", i))
+			historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+			failedRepo := repositories.NewFailedMessageRepository(db, repositories.DefaultTable)
+			accountRepo := repositories.NewMemoryAccountRepository()
+			for _, bankAccount := range test.mockStored {
+				assert.NoError(t, accountRepo.Save(context.Background(), bankAccount))
+			}
+			adjuster := &fakeBalanceAdjuster{err: test.mockErr}
+
+			report, err := NewReconciliationService(log, accountRepo, historyRepo, failedRepo, adjuster, logging.Discard()).Reconcile(context.Background(), test.mockAdjust)
+
+			assert.Equal(t, test.wantError, err)
+			if test.wantError != nil {
+				return
+			}
+			assert.NotEmpty(t, report.RunID)
+			assert.Equal(t, len(log.messages), report.Messages)
+			assert.Equal(t, 0, report.Pending)
+			assert.Equal(t, 2, report.Accounts)
+			assert.Equal(t, []SkippedMessage{
+				{Topic: "DepositFundEvent", Offset: 5, Error: "record not found"},
+			}, report.Skipped)
+			assert.Equal(t, test.wantDiscrepancies, report.Discrepancies)
+			assert.Equal(t, test.wantAdjustments, adjuster.events)
+			for _, correlationID := range adjuster.correlationIDs {
+				assert.Equal(t, report.RunID, correlationID)
+			}
+		})
+	}
+
+	t.Run("Test should need a balance adjuster to adjust", func(t *testing.T) {
+		db := internal.OpenSQLiteDB("reconcile_no_producer")
+		historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+		failedRepo := repositories.NewFailedMessageRepository(db, repositories.DefaultTable)
+
+		_, err := NewReconciliationService(log, repositories.NewMemoryAccountRepository(), historyRepo, failedRepo, nil, logging.Discard()).Reconcile(context.Background(), true)
+
+		assert.EqualError(t, err, "adjusting needs a balance adjuster")
+	})
+}
+
+func Test_reconciliationService_Reconcile_pending(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	message := func(minute int, topic, value string) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Topic: topic, Offset: int64(minute), Timestamp: day.Add(time.Duration(minute) * time.Minute), Value: []byte(value)}
+	}
+	log := fakeLogReader{
+		messages: []*sarama.ConsumerMessage{
+			message(0, "OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`),
+			message(1, "OpenAccountEvent", `{"ID":"456","AccountHolder":"Jane Doe","AccountType":2,"OpeningBalance":1000}`),
+			// dead lettered
+			message(2, "DepositFundEvent", `{"ID":"123","Amount":500}`),
+			// applied before the group committed it
+			message(3, "DepositFundEvent", `{"ID":"123","Amount":200}`),
+			// in flight
+			message(4, "DepositFundEvent", `{"ID":"123","Amount":300}`),
+			message(5, "AdjustBalanceEvent", `{"ID":"456","Amount":-100,"Reason":"reconciliation"}`),
+		},
+		uncommitted: map[int64]bool{3: true, 4: true, 5: true},
+	}
+
+	for _, adjust := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Test should leave out the messages not applied yet and not adjust twice, adjust %v", adjust), func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("reconcile_pending_%v", adjust))
+			historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+			failedRepo := repositories.NewFailedMessageRepository(db, repositories.DefaultTable)
+			accountRepo := repositories.NewMemoryAccountRepository()
+			assert.NoError(t, accountRepo.Save(ctx, repositories.BankAccount{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1200}))
+			assert.NoError(t, accountRepo.Save(ctx, repositories.BankAccount{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 1100}))
+			_, err := historyRepo.Append(ctx, repositories.AccountEvent{AccountID: "123", EventType: "DepositFundEvent", Data: `{"ID":"123","Amount":200}`, OccurredAt: day, Topic: "DepositFundEvent", Offset: 3})
+			assert.NoError(t, err)
+			assert.NoError(t, failedRepo.Save(ctx, repositories.FailedMessage{Topic: "DepositFundEvent", Offset: 2, Timestamp: day, Value: []byte(`{"ID":"123","Amount":500}`), Headers: map[string]string{}, Error: "error", Attempts: 3, FailedAt: day}))
+			adjuster := &fakeBalanceAdjuster{}
+
+			report, err := NewReconciliationService(log, accountRepo, historyRepo, failedRepo, adjuster, logging.Discard()).Reconcile(ctx, adjust)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 6, report.Messages)
+			assert.Equal(t, 3, report.Pending)
+			assert.Equal(t, []Discrepancy{
+				{
+					AccountID:         "456",
+					Kind:              DiscrepancyBalance,
+					Expected:          &ReconciledAccount{"Jane Doe", 2, 1000},
+					Actual:            &ReconciledAccount{"Jane Doe", 2, 1100},
+					Difference:        -100,
+					AdjustmentPending: true,
+				},
+			}, report.Discrepancies)
+			assert.Empty(t, adjuster.events)
+		})
+	}
+}
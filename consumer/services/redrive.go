@@ -0,0 +1,99 @@
+package services
+
+import (
+	"consumer/repositories"
+	"context"
+	"errors"
+	"events"
+	"fmt"
+	"log/slog"
+	"platform/logging"
+	"platform/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+)
+
+// ErrFailedMessageNotFound means no message was dead lettered at the
+// position.
+var ErrFailedMessageNotFound = errors.New("failed message not found")
+
+// ErrRedriveFailed means the redriven message failed again; its error is
+// recorded in place of the previous one.
+var ErrRedriveFailed = errors.New("message failed again")
+
+type IRedriveService interface {
+	// FailedMessages lists the dead lettered messages by position.
+	FailedMessages(ctx context.Context) ([]repositories.FailedMessage, error)
+	// Redrive handles the message dead lettered at position again and
+	// forgets it once handled, or returns ErrFailedMessageNotFound or
+	// ErrRedriveFailed.
+	Redrive(ctx context.Context, position repositories.Position) error
+}
+
+type redriveService struct {
+	eventService IEventService
+	failedRepo   repositories.IFailedMessageRepository
+	logger       *slog.Logger
+}
+
+// NewRedriveService handles dead lettered messages with eventService as if
+// they were read again from their topic, partition and offset, so the
+// history skips one whose event was applied after all instead of applying
+// it twice.
+func NewRedriveService(eventService IEventService, failedRepo repositories.IFailedMessageRepository, logger *slog.Logger) IRedriveService {
+	return redriveService{eventService, failedRepo, logger}
+}
+
+func (obj redriveService) FailedMessages(ctx context.Context) (messages []repositories.FailedMessage, err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "redriveService.FailedMessages")
+	defer func() { tracing.End(span, err) }()
+
+	return obj.failedRepo.FindAll(ctx)
+}
+
+func (obj redriveService) Redrive(ctx context.Context, position repositories.Position) (err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "redriveService.Redrive")
+	defer func() { tracing.End(span, err) }()
+
+	message, err := obj.failedRepo.FindByPosition(ctx, position)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrFailedMessageNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	metadata := events.MetadataFromHeaders(message.Headers)
+	handleCtx := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(message.Headers))
+	handleCtx = events.ContextWithMetadata(handleCtx, metadata)
+	handleCtx = ContextWithPosition(handleCtx, Position{message.Topic, message.Partition, message.Offset, message.Timestamp})
+	handleCtx = logging.ContextWith(handleCtx,
+		"topic", message.Topic,
+		"partition", message.Partition,
+		"offset", message.Offset,
+		"request_id", metadata.RequestID,
+		"correlation_id", metadata.CorrelationID,
+	)
+
+	eventBytes, handleErr := decodePayload(message.Topic, metadata.ContentType, message.Value)
+	if handleErr == nil {
+		handleErr = obj.eventService.Handle(handleCtx, message.Topic, eventBytes)
+	}
+	if handleErr != nil {
+		message.Error = handleErr.Error()
+		message.Attempts++
+		message.FailedAt = time.Now()
+		err = obj.failedRepo.Save(ctx, message)
+		if err != nil {
+			return err
+		}
+		obj.logger.WarnContext(handleCtx, "redriven message failed again", "attempts", message.Attempts, "error", handleErr)
+		return fmt.Errorf("%w: %v", ErrRedriveFailed, handleErr)
+	}
+
+	obj.logger.InfoContext(handleCtx, "message redriven")
+	return obj.failedRepo.Delete(ctx, position)
+}
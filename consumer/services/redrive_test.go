@@ -0,0 +1,110 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"context"
+	"fmt"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_redriveService_Redrive(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	deposit := repositories.FailedMessage{
+		Topic:     "DepositFundEvent",
+		Partition: 1,
+		Offset:    5,
+		Timestamp: day,
+		Value:     []byte(`{"ID":"123","Amount":500}`),
+		Headers:   map[string]string{},
+		Error:     "record not found",
+		Attempts:  3,
+		FailedAt:  day,
+	}
+	position := repositories.Position{Topic: "DepositFundEvent", Partition: 1, Offset: 5}
+
+	tests := []struct {
+		name      string
+		open      bool
+		handled   bool
+		position  repositories.Position
+		wantError string
+
+		wantBalance  float64
+		wantHistory  []repositories.Position
+		wantAttempts int
+	}{
+		{
+			name:        "Test should handle a dead lettered message at its position and forget it",
+			open:        true,
+			position:    position,
+			wantBalance: 1500,
+			wantHistory: []repositories.Position{{Topic: "OpenAccountEvent"}, position},
+		},
+		{
+			name:        "Test should forget a message already applied without applying it again",
+			open:        true,
+			handled:     true,
+			position:    position,
+			wantBalance: 1500,
+			wantHistory: []repositories.Position{{Topic: "OpenAccountEvent"}, position},
+		},
+		{
+			name:         "Test should keep a message that fails again with its new error",
+			position:     position,
+			wantError:    "message failed again: record not found",
+			wantAttempts: 4,
+		},
+		{
+			name:         "Test should return not found for a position without a failed message",
+			position:     repositories.Position{Topic: "DepositFundEvent", Partition: 0, Offset: 5},
+			wantError:    "failed message not found",
+			wantAttempts: 3,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := internal.OpenSQLiteDB(fmt.Sprintf("redrive_%d", i))
+			accountRepo := repositories.NewAccountRepository(db, repositories.DefaultTable)
+			historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+			failedRepo := repositories.NewFailedMessageRepository(db, repositories.DefaultTable)
+			eventService := NewHistoryEventService(NewEventService(accountRepo, logging.Discard()), historyRepo, repositories.NewTransactor(db), 0, logging.Discard())
+			if test.open {
+				assert.NoError(t, eventService.Handle(ContextWithPosition(ctx, Position{Topic: "OpenAccountEvent", Timestamp: day}), "OpenAccountEvent", []byte(`{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`)))
+			}
+			if test.handled {
+				assert.NoError(t, eventService.Handle(ContextWithPosition(ctx, Position{"DepositFundEvent", 1, 5, day}), "DepositFundEvent", deposit.Value))
+			}
+			assert.NoError(t, failedRepo.Save(ctx, deposit))
+
+			err := NewRedriveService(eventService, failedRepo, logging.Discard()).Redrive(ctx, test.position)
+
+			failed, findErr := failedRepo.FindAll(ctx)
+			assert.NoError(t, findErr)
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+				assert.Len(t, failed, 1)
+				assert.Equal(t, test.wantAttempts, failed[0].Attempts)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Empty(t, failed)
+			bankAccount, err := accountRepo.FindByID(ctx, "123")
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantBalance, bankAccount.Balance)
+			accountEvents, err := historyRepo.Events(ctx, "123", 0, time.Time{})
+			assert.NoError(t, err)
+			history := []repositories.Position{}
+			for _, accountEvent := range accountEvents {
+				history = append(history, repositories.Position{Topic: accountEvent.Topic, Partition: accountEvent.Partition, Offset: accountEvent.Offset})
+			}
+			assert.Equal(t, test.wantHistory, history)
+		})
+	}
+}
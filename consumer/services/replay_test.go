@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConsumerGroupSession struct {
+	mutex  *sync.Mutex
+	marked map[string]map[int32]int64
+}
+
+func (obj fakeConsumerGroupSession) Claims() map[string][]int32 { return nil }
+func (obj fakeConsumerGroupSession) MemberID() string           { return "replay" }
+func (obj fakeConsumerGroupSession) GenerationID() int32        { return 0 }
+func (obj fakeConsumerGroupSession) Commit()                    {}
+func (obj fakeConsumerGroupSession) Context() context.Context   { return context.Background() }
+
+func (obj fakeConsumerGroupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if obj.marked[topic] == nil {
+		obj.marked[topic] = map[int32]int64{}
+	}
+	obj.marked[topic][partition] = offset
+}
+
+func (obj fakeConsumerGroupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	obj.MarkOffset(topic, partition, offset, metadata)
+}
+
+func (obj fakeConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	obj.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+type fakeConsumerGroupClaim struct {
+	topic         string
+	partition     int32
+	messages      chan *sarama.ConsumerMessage
+	highWaterMark int64
+}
+
+func (obj fakeConsumerGroupClaim) Topic() string                            { return obj.topic }
+func (obj fakeConsumerGroupClaim) Partition() int32                         { return obj.partition }
+func (obj fakeConsumerGroupClaim) InitialOffset() int64                     { return 0 }
+func (obj fakeConsumerGroupClaim) HighWaterMarkOffset() int64               { return obj.highWaterMark }
+func (obj fakeConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage { return obj.messages }
+
+// replayMessages feeds messages, in order, through handler as a single claim
+// and returns the next offset marked per topic and partition, also when the
+// claim ends with an error.
+func replayMessages(handler sarama.ConsumerGroupHandler, messages []*sarama.ConsumerMessage) (map[string]map[int32]int64, error) {
+	session := fakeConsumerGroupSession{&sync.Mutex{}, map[string]map[int32]int64{}}
+	claim := fakeConsumerGroupClaim{messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	if len(messages) > 0 {
+		claim.topic = messages[0].Topic
+		claim.partition = messages[0].Partition
+	}
+	for _, msg := range messages {
+		claim.messages <- msg
+		if msg.Offset >= claim.highWaterMark {
+			claim.highWaterMark = msg.Offset + 1
+		}
+	}
+	close(claim.messages)
+	return consume(handler, session, claim)
+}
+
+// consumeMessages runs handler over messages as the claim of topic and
+// partition until the caller closes messages, so a test controls when each
+// one arrives. It returns the next offset marked per topic and partition.
+func consumeMessages(handler sarama.ConsumerGroupHandler, topic string, partition int32, messages chan *sarama.ConsumerMessage) (map[string]map[int32]int64, error) {
+	session := fakeConsumerGroupSession{&sync.Mutex{}, map[string]map[int32]int64{}}
+	return consume(handler, session, fakeConsumerGroupClaim{topic: topic, partition: partition, messages: messages})
+}
+
+func consume(handler sarama.ConsumerGroupHandler, session fakeConsumerGroupSession, claim fakeConsumerGroupClaim) (map[string]map[int32]int64, error) {
+	err := handler.Setup(session)
+	if err != nil {
+		return nil, err
+	}
+	err = handler.ConsumeClaim(session, claim)
+	if err != nil {
+		return session.marked, err
+	}
+
+	return session.marked, handler.Cleanup(session)
+}
+
+// assertGolden compares got with the golden file at path. Run the tests with
+// UPDATE_GOLDEN=1 to rewrite the golden file instead.
+func assertGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		assert.NoError(t, os.WriteFile(path, got, 0644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if assert.NoError(t, err, "golden file missing, run with UPDATE_GOLDEN=1") {
+		assert.Equal(t, string(want), string(got))
+	}
+}
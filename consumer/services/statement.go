@@ -0,0 +1,160 @@
+package services
+
+import (
+	"consumer/repositories"
+	"context"
+	"errors"
+	"events"
+	"fmt"
+	"platform/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// StatementLine is one event of a statement. Amount is signed, negative for
+// a withdrawal, and Balance is the running balance after it.
+type StatementLine struct {
+	Sequence   int       `json:"sequence"`
+	OccurredAt time.Time `json:"occurredAt"`
+	Type       string    `json:"type"`
+	Amount     float64   `json:"amount"`
+	Balance    float64   `json:"balance"`
+}
+
+// Statement is an account's activity from From to To, both included.
+type Statement struct {
+	AccountID      string          `json:"accountId"`
+	AccountHolder  string          `json:"accountHolder"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance float64         `json:"openingBalance"`
+	Lines          []StatementLine `json:"lines"`
+	ClosingBalance float64         `json:"closingBalance"`
+}
+
+// Statement line types.
+const (
+	LineOpen       = "open"
+	LineDeposit    = "deposit"
+	LineWithdrawal = "withdrawal"
+	LineClose      = "close"
+	LineAdjustment = "adjustment"
+)
+
+type IStatementService interface {
+	// Statement builds the account's statement for the period, or returns
+	// ErrNoHistory when the account had no events by its end.
+	Statement(ctx context.Context, id string, from, to time.Time) (Statement, error)
+}
+
+type statementService struct {
+	historyRepo repositories.IHistoryRepository
+}
+
+func NewStatementService(historyRepo repositories.IHistoryRepository) IStatementService {
+	return statementService{historyRepo}
+}
+
+func (obj statementService) Statement(ctx context.Context, id string, from, to time.Time) (statement Statement, err error) {
+	ctx, span := otel.Tracer("consumer/services").Start(ctx, "statementService.Statement")
+	defer func() { tracing.End(span, err) }()
+
+	// the opening balance is the one just before the period starts
+	state, err := rebuild(ctx, obj.historyRepo, id, from.Add(-time.Microsecond), 0)
+	if err != nil {
+		return Statement{}, err
+	}
+	statement = Statement{AccountID: id, From: from, To: to, OpeningBalance: state.Balance, Lines: []StatementLine{}}
+
+	accountEvents, err := obj.historyRepo.Events(ctx, id, state.Sequence, to)
+	if err != nil {
+		return Statement{}, err
+	}
+	for _, accountEvent := range accountEvents {
+		event, err := state.apply(accountEvent)
+		if err != nil {
+			return Statement{}, err
+		}
+		line := StatementLine{Sequence: accountEvent.Sequence, OccurredAt: accountEvent.OccurredAt, Balance: state.Balance}
+		switch event := event.(type) {
+		case *events.OpenAccountEvent:
+			line.Type, line.Amount = LineOpen, event.OpeningBalance
+		case *events.DepositFundEvent:
+			line.Type, line.Amount = LineDeposit, event.Amount
+		case *events.WithdrawFundEvent:
+			line.Type, line.Amount = LineWithdrawal, -event.Amount
+		case *events.CloseAccountEvent:
+			line.Type = LineClose
+		case *events.AdjustBalanceEvent:
+			line.Type, line.Amount = LineAdjustment, event.Amount
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	if state.Sequence == 0 {
+		return Statement{}, ErrNoHistory
+	}
+
+	statement.AccountHolder = state.AccountHolder
+	statement.ClosingBalance = state.Balance
+	return statement, nil
+}
+
+// ParsePeriod reads a statement period, either a month as 2006-01 or from
+// and to as RFC 3339 times or dates. A from date starts at the beginning of
+// that day and a to date ends at the end of it, both in UTC. Without to the
+// period ends now, and without anything it is the current month.
+func ParsePeriod(month, from, to string, now time.Time) (time.Time, time.Time, error) {
+	if month != "" {
+		if from != "" || to != "" {
+			return time.Time{}, time.Time{}, errors.New("month cannot be combined with from or to")
+		}
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("month %q is not a month like 2006-01", month)
+		}
+		return start, start.AddDate(0, 1, 0).Add(-time.Microsecond), nil
+	}
+
+	if from == "" {
+		if to != "" {
+			return time.Time{}, time.Time{}, errors.New("to needs from")
+		}
+		now = now.UTC()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0).Add(-time.Microsecond), nil
+	}
+
+	start, err := parseTime("from", from, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end := now.UTC()
+	if to != "" {
+		end, err = parseTime("to", to, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to %v is before from %v", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	return start, end, nil
+}
+
+// parseTime reads an RFC 3339 time or a date, meaning the start of that day
+// in UTC, or its end when endOfDay is set.
+func parseTime(name, value string, endOfDay bool) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%v %q is neither an RFC 3339 time nor a date", name, value)
+	}
+	if endOfDay {
+		return day.Add(24*time.Hour - time.Microsecond), nil
+	}
+	return day, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// StatementFormats are the formats WriteStatement knows.
+var StatementFormats = []string{"csv", "json", "text"}
+
+// StatementContentTypes maps each statement format to its media type.
+var StatementContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"json": "application/json",
+	"text": "text/plain; charset=utf-8",
+}
+
+// WriteStatement writes the statement to w as csv, json or text, the latter
+// being fixed-width columns for printing.
+func WriteStatement(w io.Writer, statement Statement, format string) error {
+	switch format {
+	case "csv":
+		return writeStatementCSV(w, statement)
+	case "json":
+		return json.NewEncoder(w).Encode(statement)
+	case "text":
+		return writeStatementText(w, statement)
+	}
+	return fmt.Errorf("format %q is not one of %v", format, strings.Join(StatementFormats, ", "))
+}
+
+// writeStatementCSV writes one row per line between the opening and
+// closing balances, dated at the start and end of the period.
+func writeStatementCSV(w io.Writer, statement Statement) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "type", "amount", "balance"})
+	writer.Write([]string{formatTime(statement.From), "opening balance", "", formatAmount(statement.OpeningBalance)})
+	for _, line := range statement.Lines {
+		writer.Write([]string{formatTime(line.OccurredAt), line.Type, formatAmount(line.Amount), formatAmount(line.Balance)})
+	}
+	writer.Write([]string{formatTime(statement.To), "closing balance", "", formatAmount(statement.ClosingBalance)})
+	writer.Flush()
+	return writer.Error()
+}
+
+// textRow lays out the columns of the text format, wide enough for an
+// RFC 3339 UTC time and a balance in the billions.
+const textRow = "%-20v  %-15v  %12v  %15v\n"
+
+func writeStatementText(w io.Writer, statement Statement) error {
+	fmt.Fprintf(w, "Statement for account %v (%v)\n", statement.AccountID, statement.AccountHolder)
+	fmt.Fprintf(w, "Period %v to %v\n\n", formatTime(statement.From), formatTime(statement.To))
+	fmt.Fprintf(w, textRow, "DATE", "TYPE", "AMOUNT", "BALANCE")
+	fmt.Fprintf(w, textRow, "", "opening balance", "", formatAmount(statement.OpeningBalance))
+	for _, line := range statement.Lines {
+		fmt.Fprintf(w, textRow, formatTime(line.OccurredAt), line.Type, formatAmount(line.Amount), formatAmount(line.Balance))
+	}
+	_, err := fmt.Fprintf(w, textRow, "", "closing balance", "", formatAmount(statement.ClosingBalance))
+	return err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
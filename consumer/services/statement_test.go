@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"consumer/internal"
+	"consumer/repositories"
+	"context"
+	"platform/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_statementService_Statement(t *testing.T) {
+	day := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	db := internal.OpenSQLiteDB("statement_service")
+	historyRepo := repositories.NewHistoryRepository(db, repositories.DefaultTable)
+	eventService := NewHistoryEventService(NewEventService(repositories.NewAccountRepository(db, repositories.DefaultTable), logging.Discard()), historyRepo, repositories.NewTransactor(db), 2, logging.Discard())
+	for i, message := range []struct{ topic, value string }{
+		{"OpenAccountEvent", `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":500}`},
+		{"WithdrawFundEvent", `{"ID":"123","Amount":200}`},
+		{"DepositFundEvent", `{"ID":"123","Amount":50}`},
+		{"CloseAccountEvent", `{"ID":"123"}`},
+	} {
+		ctx := ContextWithPosition(context.Background(), Position{Topic: message.topic, Offset: int64(i), Timestamp: day.AddDate(0, 0, 10*i)})
+		assert.NoError(t, eventService.Handle(ctx, message.topic, []byte(message.value)))
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+
+		wantStatement Statement
+		wantError     error
+	}{
+		{
+			name:      "Test should return no history before the account was opened",
+			from:      time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			to:        time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC),
+			wantError: ErrNoHistory,
+		},
+		{
+			name: "Test should start from a zero balance in the month the account was opened",
+			from: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+			wantStatement: Statement{
+				AccountID: "123", AccountHolder: "John Doe",
+				From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+				Lines: []StatementLine{
+					{Sequence: 1, OccurredAt: day, Type: LineOpen, Amount: 1000, Balance: 1000},
+					{Sequence: 2, OccurredAt: day.AddDate(0, 0, 10), Type: LineDeposit, Amount: 500, Balance: 1500},
+				},
+				ClosingBalance: 1500,
+			},
+		},
+		{
+			name: "Test should carry the balance before the period over as the opening balance",
+			from: day.AddDate(0, 0, 10),
+			to:   day.AddDate(0, 0, 30),
+			wantStatement: Statement{
+				AccountID: "123", AccountHolder: "John Doe",
+				From: day.AddDate(0, 0, 10), To: day.AddDate(0, 0, 30),
+				OpeningBalance: 1000,
+				Lines: []StatementLine{
+					{Sequence: 2, OccurredAt: day.AddDate(0, 0, 10), Type: LineDeposit, Amount: 500, Balance: 1500},
+					{Sequence: 3, OccurredAt: day.AddDate(0, 0, 20), Type: LineWithdrawal, Amount: -200, Balance: 1300},
+					{Sequence: 4, OccurredAt: day.AddDate(0, 0, 30), Type: LineDeposit, Amount: 50, Balance: 1350},
+				},
+				ClosingBalance: 1350,
+			},
+		},
+		{
+			name: "Test should return an empty statement for a period without activity",
+			from: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+			wantStatement: Statement{
+				AccountID: "123", AccountHolder: "John Doe",
+				From: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+				OpeningBalance: 1350,
+				Lines:          []StatementLine{},
+				ClosingBalance: 1350,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statement, err := NewStatementService(historyRepo).Statement(context.Background(), "123", test.from, test.to)
+
+			assert.Equal(t, test.wantError, err)
+			assert.Equal(t, test.wantStatement, statement)
+		})
+	}
+}
+
+func Test_ParsePeriod(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		month string
+		from  string
+		to    string
+
+		wantFrom  time.Time
+		wantTo    time.Time
+		wantError string
+	}{
+		{
+			name:     "Test should default to the current month",
+			wantFrom: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2024, 3, 31, 23, 59, 59, 999999000, time.UTC),
+		},
+		{
+			name:     "Test should cover a whole month",
+			month:    "2024-02",
+			wantFrom: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2024, 2, 29, 23, 59, 59, 999999000, time.UTC),
+		},
+		{
+			name:     "Test should include both dates",
+			from:     "2024-03-01",
+			to:       "2024-03-10",
+			wantFrom: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:   time.Date(2024, 3, 10, 23, 59, 59, 999999000, time.UTC),
+		},
+		{
+			name:     "Test should end now without to",
+			from:     "2024-03-01T08:00:00+01:00",
+			wantFrom: time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC),
+			wantTo:   now,
+		},
+		{
+			name:      "Test should reject a month with from",
+			month:     "2024-02",
+			from:      "2024-03-01",
+			wantError: "month cannot be combined with from or to",
+		},
+		{
+			name:      "Test should reject an invalid month",
+			month:     "March",
+			wantError: `month "March" is not a month like 2006-01`,
+		},
+		{
+			name:      "Test should reject to without from",
+			to:        "2024-03-01",
+			wantError: "to needs from",
+		},
+		{
+			name:      "Test should reject an invalid from",
+			from:      "yesterday",
+			wantError: `from "yesterday" is neither an RFC 3339 time nor a date`,
+		},
+		{
+			name:      "Test should reject a period ending before it starts",
+			from:      "2024-03-10",
+			to:        "2024-03-01",
+			wantError: "to 2024-03-01T23:59:59Z is before from 2024-03-10T00:00:00Z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from, to, err := ParsePeriod(test.month, test.from, test.to, now)
+
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantFrom, from)
+			assert.Equal(t, test.wantTo, to)
+		})
+	}
+}
+
+func Test_WriteStatement(t *testing.T) {
+	statement := Statement{
+		AccountID: "123", AccountHolder: "John Doe",
+		From: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 3, 31, 23, 59, 59, 999999000, time.UTC),
+		OpeningBalance: 1000,
+		Lines: []StatementLine{
+			{Sequence: 2, OccurredAt: time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC), Type: LineDeposit, Amount: 500, Balance: 1500},
+			{Sequence: 3, OccurredAt: time.Date(2024, 3, 21, 9, 0, 0, 0, time.UTC), Type: LineWithdrawal, Amount: -200, Balance: 1300},
+		},
+		ClosingBalance: 1300,
+	}
+
+	tests := []struct {
+		format string
+
+		wantOutput string
+		wantError  string
+	}{
+		{
+			format: "csv",
+			wantOutput: "date,type,amount,balance\n" +
+				"2024-03-01T00:00:00Z,opening balance,,1000.00\n" +
+				"2024-03-11T09:00:00Z,deposit,500.00,1500.00\n" +
+				"2024-03-21T09:00:00Z,withdrawal,-200.00,1300.00\n" +
+				"2024-03-31T23:59:59Z,closing balance,,1300.00\n",
+		},
+		{
+			format: "json",
+			wantOutput: `{"accountId":"123","accountHolder":"John Doe","from":"2024-03-01T00:00:00Z","to":"2024-03-31T23:59:59.999999Z",` +
+				`"openingBalance":1000,"lines":[` +
+				`{"sequence":2,"occurredAt":"2024-03-11T09:00:00Z","type":"deposit","amount":500,"balance":1500},` +
+				`{"sequence":3,"occurredAt":"2024-03-21T09:00:00Z","type":"withdrawal","amount":-200,"balance":1300}],` +
+				`"closingBalance":1300}` + "\n",
+		},
+		{
+			format: "text",
+			wantOutput: "Statement for account 123 (John Doe)\n" +
+				"Period 2024-03-01T00:00:00Z to 2024-03-31T23:59:59Z\n" +
+				"\n" +
+				"DATE                  TYPE                   AMOUNT          BALANCE\n" +
+				"                      opening balance                        1000.00\n" +
+				"2024-03-11T09:00:00Z  deposit                500.00          1500.00\n" +
+				"2024-03-21T09:00:00Z  withdrawal            -200.00          1300.00\n" +
+				"                      closing balance                        1300.00\n",
+		},
+		{
+			format:    "pdf",
+			wantError: `format "pdf" is not one of csv, json, text`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("Test should write "+test.format, func(t *testing.T) {
+			var output bytes.Buffer
+
+			err := WriteStatement(&output, statement, test.format)
+
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantOutput, output.String())
+		})
+	}
+}
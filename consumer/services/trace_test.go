@@ -0,0 +1,58 @@
+package services
+
+import (
+	"consumer/internal"
+	"consumer/repositories"
+	"platform/database"
+	"platform/logging"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Trace_Deposit_Fund(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	db := internal.OpenSQLiteDB("trace_deposit_fund")
+	assert.NoError(t, db.Use(database.NewTracingPlugin()))
+	accountRepo := repositories.NewAccountRepository(db, repositories.DefaultTable)
+	db.Table("bond_banks").Create(&repositories.BankAccount{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1000})
+	spanRecorder.Ended()
+
+	_, err := replayMessages(NewConsumerService(NewEventService(accountRepo, logging.Discard()), nil, nil, RetryPolicy{}, nil, logging.Discard(), 1), []*sarama.ConsumerMessage{
+		{
+			Topic:   "DepositFundEvent",
+			Headers: []*sarama.RecordHeader{{Key: []byte("traceparent"), Value: []byte("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")}},
+			Value:   []byte(`{"ID":"123","Amount":500}`),
+		},
+	})
+	assert.NoError(t, err)
+
+	spans := map[string][]sdktrace.ReadOnlySpan{}
+	for _, span := range spanRecorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	if !assert.Len(t, spans["DepositFundEvent process"], 1) || !assert.Len(t, spans["eventService.Handle DepositFundEvent"], 1) {
+		return
+	}
+	consumerSpan := spans["DepositFundEvent process"][0]
+	handlerSpan := spans["eventService.Handle DepositFundEvent"][0]
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", consumerSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", consumerSpan.Parent().SpanID().String())
+	assert.Equal(t, consumerSpan.SpanContext().SpanID(), handlerSpan.Parent().SpanID())
+
+	databaseSpans := append(spans["gorm.query"], spans["gorm.update"]...)
+	assert.Len(t, databaseSpans, 2)
+	for _, span := range databaseSpans {
+		assert.Equal(t, handlerSpan.SpanContext().TraceID(), span.SpanContext().TraceID())
+		assert.Equal(t, handlerSpan.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
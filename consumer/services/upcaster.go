@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Upcaster rewrites the decoded fields of an older event payload into the
+// current shape. It must leave a payload that is already current untouched,
+// because messages carry no schema version and every upcaster of a topic
+// runs on every message.
+type Upcaster func(fields map[string]json.RawMessage) error
+
+// Upcasters lists, per topic, the upcasters to run in order before the
+// payload reaches its handler. Register one whenever a non-backward
+// compatible change to an event in events is released.
+var Upcasters = map[string][]Upcaster{}
+
+type upcastingEventService struct {
+	eventService IEventService
+	upcasters    map[string][]Upcaster
+}
+
+// NewUpcastingEventService runs the upcasters registered for a topic before
+// handing the payload to eventService.
+func NewUpcastingEventService(eventService IEventService, upcasters map[string][]Upcaster) IEventService {
+	return upcastingEventService{eventService, upcasters}
+}
+
+func (obj upcastingEventService) Handle(ctx context.Context, topic string, eventBytes []byte) error {
+	eventBytes, err := upcastPayload(obj.upcasters[topic], eventBytes)
+	if err != nil {
+		return err
+	}
+	return obj.eventService.Handle(ctx, topic, eventBytes)
+}
+
+// upcastPayload runs upcasters on the payload, returning it as is when
+// there are none.
+func upcastPayload(upcasters []Upcaster, eventBytes []byte) ([]byte, error) {
+	if len(upcasters) == 0 {
+		return eventBytes, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	err := json.Unmarshal(eventBytes, &fields)
+	if err != nil {
+		// leave malformed payloads for the handler to reject
+		return eventBytes, nil
+	}
+
+	for _, upcast := range upcasters {
+		err = upcast(fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// RenameField returns an upcaster that moves an old field to its new name.
+func RenameField(from, to string) Upcaster {
+	return func(fields map[string]json.RawMessage) error {
+		value, ok := fields[from]
+		if !ok {
+			return nil
+		}
+		if _, ok := fields[to]; !ok {
+			fields[to] = value
+		}
+		delete(fields, from)
+		return nil
+	}
+}
+
+// DefaultField returns an upcaster that fills a field added later with value.
+func DefaultField(name string, value interface{}) Upcaster {
+	return func(fields map[string]json.RawMessage) error {
+		if _, ok := fields[name]; ok {
+			return nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fields[name] = data
+		return nil
+	}
+}
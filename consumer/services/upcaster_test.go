@@ -0,0 +1,108 @@
+package services
+
+import (
+	mockService "consumer/services/mock"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_upcastingEventService_Handle(t *testing.T) {
+	mockEventService := mockService.NewIEventService(t)
+
+	clearAllMock := func() {
+		mockEventService.ClearAll()
+	}
+	tests := []struct {
+		name          string
+		mockTopic     string
+		mockPayload   string
+		mockUpcasters map[string][]Upcaster
+
+		wantServiceOrRepoCallWithAndResponse func()
+		wantServiceOrRepoCallTimes           map[string]map[string]int
+	}{
+		{
+			name:          "Test should pass payload through when topic has no upcaster",
+			mockTopic:     "DepositFundEvent",
+			mockPayload:   `{"ID":"123","Amount":500}`,
+			mockUpcasters: map[string][]Upcaster{},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "DepositFundEvent", []byte(`{"ID":"123","Amount":500}`)).Return(nil)
+			},
+		},
+		{
+			name:        "Test should upcast old payload into current shape",
+			mockTopic:   "OpenAccountEvent",
+			mockPayload: `{"ID":"123","AccountHolder":"John Doe","AccountType":1,"Balance":1000}`,
+			mockUpcasters: map[string][]Upcaster{
+				"OpenAccountEvent": {RenameField("Balance", "OpeningBalance"), DefaultField("AccountType", 1)},
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "OpenAccountEvent", []byte(`{"AccountHolder":"John Doe","AccountType":1,"ID":"123","OpeningBalance":1000}`)).Return(nil)
+			},
+		},
+		{
+			name:        "Test should leave current payload untouched",
+			mockTopic:   "OpenAccountEvent",
+			mockPayload: `{"ID":"123","AccountHolder":"John Doe","AccountType":2,"OpeningBalance":1000}`,
+			mockUpcasters: map[string][]Upcaster{
+				"OpenAccountEvent": {RenameField("Balance", "OpeningBalance"), DefaultField("AccountType", 1)},
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "OpenAccountEvent", []byte(`{"AccountHolder":"John Doe","AccountType":2,"ID":"123","OpeningBalance":1000}`)).Return(nil)
+			},
+		},
+		{
+			name:        "Test should hand malformed payload to handler unchanged",
+			mockTopic:   "OpenAccountEvent",
+			mockPayload: `{"ID":`,
+			mockUpcasters: map[string][]Upcaster{
+				"OpenAccountEvent": {RenameField("Balance", "OpeningBalance")},
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventService.On("Handle", mock.Anything, "OpenAccountEvent", []byte(`{"ID":`)).Return(nil)
+			},
+		},
+		{
+			name:        "Test should drop message when upcaster return error",
+			mockTopic:   "OpenAccountEvent",
+			mockPayload: `{"ID":"123"}`,
+			mockUpcasters: map[string][]Upcaster{
+				"OpenAccountEvent": {func(map[string]json.RawMessage) error { return errors.New("error") }},
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventService": {
+					"Handle": 0,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer clearAllMock()
+
+			if test.wantServiceOrRepoCallWithAndResponse != nil {
+				test.wantServiceOrRepoCallWithAndResponse()
+			}
+
+			eventService := NewUpcastingEventService(mockEventService, test.mockUpcasters)
+			eventService.Handle(context.Background(), test.mockTopic, []byte(test.mockPayload))
+
+			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
+				for methodName, times := range serviceCallTimes {
+					switch serviceName {
+					case "eventService":
+						mockEventService.AssertNumberOfCalls(t, methodName, times)
+					default:
+						t.Errorf("service %s or method %s not found", serviceName, methodName)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+const ContentTypeAvro = "application/vnd.confluent.avro"
+
+type AvroSchema struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace"`
+	Fields    []AvroField `json:"fields"`
+}
+
+type AvroField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// GenerateAvroSchema describes event as an Avro record with one primitive
+// field per exported struct field.
+func GenerateAvroSchema(event Event) AvroSchema {
+	eventType := reflect.TypeOf(event)
+	schema := AvroSchema{Type: "record", Name: eventType.Name(), Namespace: "events", Fields: []AvroField{}}
+	for i := 0; i < eventType.NumField(); i++ {
+		field := eventType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		schema.Fields = append(schema.Fields, AvroField{field.Name, avroType(field.Type.Kind())})
+	}
+	return schema
+}
+
+func avroType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "long"
+	case reflect.Float64:
+		return "double"
+	default:
+		panic(fmt.Sprintf("events: unsupported avro field kind %v", kind))
+	}
+}
+
+// AvroCodec encodes events as Avro in the Confluent wire format: a zero magic
+// byte, the 4 byte big-endian schema ID, then the Avro binary record. Schemas
+// are registered under the "<topic>-value" subject.
+type AvroCodec struct {
+	Registry SchemaRegistry
+}
+
+func (AvroCodec) ContentType() string { return ContentTypeAvro }
+
+func (obj AvroCodec) Encode(event Event) ([]byte, error) {
+	value := reflect.Indirect(reflect.ValueOf(event))
+	schema := GenerateAvroSchema(value.Interface())
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := obj.Registry.Register(schema.Name+"-value", string(schemaJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 5)
+	binary.BigEndian.PutUint32(data[1:], uint32(id))
+	for _, field := range schema.Fields {
+		fieldValue := value.FieldByName(field.Name)
+		switch field.Type {
+		case "string":
+			data = appendAvroLong(data, int64(fieldValue.Len()))
+			data = append(data, fieldValue.String()...)
+		case "boolean":
+			if fieldValue.Bool() {
+				data = append(data, 1)
+			} else {
+				data = append(data, 0)
+			}
+		case "long":
+			data = appendAvroLong(data, fieldValue.Int())
+		case "double":
+			buffer := make([]byte, 8)
+			binary.LittleEndian.PutUint64(buffer, math.Float64bits(fieldValue.Float()))
+			data = append(data, buffer...)
+		}
+	}
+	return data, nil
+}
+
+// Decode reads the record with the writer schema named by the schema ID and
+// copies fields into event by name, so fields added or removed since the
+// message was written are tolerated.
+func (obj AvroCodec) Decode(data []byte, event Event) error {
+	pointer := reflect.ValueOf(event)
+	if pointer.Kind() != reflect.Ptr || pointer.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode avro into %T", event)
+	}
+	if len(data) < 5 || data[0] != 0 {
+		return errors.New("avro payload is missing the confluent wire format header")
+	}
+
+	schemaJSON, err := obj.Registry.GetSchema(int(binary.BigEndian.Uint32(data[1:5])))
+	if err != nil {
+		return err
+	}
+	writer := AvroSchema{}
+	err = json.Unmarshal([]byte(schemaJSON), &writer)
+	if err != nil {
+		return err
+	}
+
+	value := pointer.Elem()
+	data = data[5:]
+	for _, field := range writer.Fields {
+		var decoded interface{}
+		switch field.Type {
+		case "string":
+			length, n := binary.Varint(data)
+			if n <= 0 || length < 0 || int64(len(data)-n) < length {
+				return errors.New("avro string is truncated")
+			}
+			decoded = string(data[n : n+int(length)])
+			data = data[n+int(length):]
+		case "boolean":
+			if len(data) < 1 {
+				return errors.New("avro boolean is truncated")
+			}
+			decoded = data[0] == 1
+			data = data[1:]
+		case "long":
+			number, n := binary.Varint(data)
+			if n <= 0 {
+				return errors.New("avro long is truncated")
+			}
+			decoded = number
+			data = data[n:]
+		case "double":
+			if len(data) < 8 {
+				return errors.New("avro double is truncated")
+			}
+			decoded = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		default:
+			return fmt.Errorf("unsupported avro type %q", field.Type)
+		}
+
+		target := value.FieldByName(field.Name)
+		if !target.IsValid() || !target.CanSet() {
+			continue
+		}
+		switch decoded := decoded.(type) {
+		case string:
+			if target.Kind() == reflect.String {
+				target.SetString(decoded)
+			}
+		case bool:
+			if target.Kind() == reflect.Bool {
+				target.SetBool(decoded)
+			}
+		case int64:
+			if target.Kind() == reflect.Int || target.Kind() == reflect.Int32 || target.Kind() == reflect.Int64 {
+				target.SetInt(decoded)
+			}
+		case float64:
+			if target.Kind() == reflect.Float64 {
+				target.SetFloat(decoded)
+			}
+		}
+	}
+
+	return nil
+}
+
+// appendAvroLong writes a zig-zag varint, which is what binary.PutVarint does.
+func appendAvroLong(data []byte, number int64) []byte {
+	buffer := make([]byte, binary.MaxVarintLen64)
+	return append(data, buffer[:binary.PutVarint(buffer, number)]...)
+}
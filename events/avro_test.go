@@ -0,0 +1,162 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_AvroCodec_RoundTrip(t *testing.T) {
+	codec := AvroCodec{NewFileSchemaRegistry(filepath.Join(t.TempDir(), "registry.json"))}
+
+	mockEvents := []Event{
+		OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 2, OpeningBalance: 1000.5},
+		DepositFundEvent{ID: "123", Amount: 500.25},
+		WithdrawFundEvent{ID: "123", Amount: -200},
+		CloseAccountEvent{ID: "123"},
+		AdjustBalanceEvent{ID: "123", Amount: -150.5, Reason: "reconciliation"},
+	}
+
+	for i, event := range mockEvents {
+		name := reflect.TypeOf(event).Name()
+		t.Run("Test should round trip "+name+" in confluent wire format", func(t *testing.T) {
+			data, err := codec.Encode(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data[0] != 0 || int(data[4]) != i+1 {
+				t.Errorf("want magic byte 0 and schema id %v, got % x", i+1, data[:5])
+			}
+
+			decoded, _ := New(name)
+			err = codec.Decode(data, decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(event, reflect.ValueOf(decoded).Elem().Interface()) {
+				t.Errorf("want %#v, got %#v", event, decoded)
+			}
+		})
+	}
+
+	if len(mockEvents) != len(Registry) {
+		t.Errorf("every event in Registry needs a round trip case")
+	}
+}
+
+func Test_AvroCodec_Encode_RegistersOnce(t *testing.T) {
+	registrations := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registrations++
+		w.Write([]byte(`{"id":7}`))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	for name, registry := range map[string]SchemaRegistry{
+		"http": NewHTTPSchemaRegistry(server.URL, server.Client()),
+		"file": NewFileSchemaRegistry(path),
+	} {
+		codec := AvroCodec{registry}
+		for i := 0; i < 3; i++ {
+			_, err := codec.Encode(DepositFundEvent{ID: "123", Amount: float64(i)})
+			if err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+			if name == "file" {
+				// a registration it remembers never reads the file again
+				os.WriteFile(path, []byte("not json"), 0644)
+			}
+		}
+	}
+
+	if registrations != 1 {
+		t.Errorf("want 1 registration, got %v", registrations)
+	}
+}
+
+func Test_AvroCodec_Decode_WriterSchemaEvolution(t *testing.T) {
+	type DepositFundEventV2 struct {
+		ID       string
+		Amount   float64
+		Currency string
+	}
+	registry := NewFileSchemaRegistry(filepath.Join(t.TempDir(), "registry.json"))
+
+	data, err := AvroCodec{registry}.Encode(DepositFundEventV2{ID: "123", Amount: 500, Currency: "THB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &DepositFundEvent{}
+	err = AvroCodec{registry}.Decode(data, event)
+	if err != nil || event.ID != "123" || event.Amount != 500 {
+		t.Errorf("want fields written by newer producer to be skipped, got %#v, %v", event, err)
+	}
+
+	err = AvroCodec{registry}.Decode([]byte(`{"ID":"123"}`), event)
+	if err == nil {
+		t.Errorf("want error for payload without wire format header")
+	}
+}
+
+func Test_fileSchemaRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+
+	first, _ := NewFileSchemaRegistry(path).Register("DepositFundEvent-value", `{"a":1}`)
+	again, _ := NewFileSchemaRegistry(path).Register("DepositFundEvent-value", `{"a":1}`)
+	shared, _ := NewFileSchemaRegistry(path).Register("WithdrawFundEvent-value", `{"a":1}`)
+	second, _ := NewFileSchemaRegistry(path).Register("DepositFundEvent-value", `{"a":2}`)
+
+	if first != 1 || again != 1 || shared != 1 || second != 2 {
+		t.Errorf("want ids 1 1 1 2, got %v %v %v %v", first, again, shared, second)
+	}
+
+	schema, err := NewFileSchemaRegistry(path).GetSchema(2)
+	if err != nil || schema != `{"a":2}` {
+		t.Errorf("want persisted schema, got %q, %v", schema, err)
+	}
+	_, err = NewFileSchemaRegistry(path).GetSchema(3)
+	if err == nil {
+		t.Errorf("want error for unknown schema id")
+	}
+}
+
+func Test_httpSchemaRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/subjects/DepositFundEvent-value/versions":
+			body := map[string]string{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["schema"] != `{"a":1}` {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			w.Write([]byte(`{"id":7}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/schemas/ids/7":
+			w.Write([]byte(`{"schema":"{\"a\":1}"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := NewHTTPSchemaRegistry(server.URL, server.Client())
+
+	id, err := registry.Register("DepositFundEvent-value", `{"a":1}`)
+	if err != nil || id != 7 {
+		t.Errorf("want id 7, got %v, %v", id, err)
+	}
+	schema, err := registry.GetSchema(7)
+	if err != nil || schema != `{"a":1}` {
+		t.Errorf("want schema, got %q, %v", schema, err)
+	}
+	_, err = registry.GetSchema(8)
+	if err == nil {
+		t.Errorf("want error for unknown schema id")
+	}
+}
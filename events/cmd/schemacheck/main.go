@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"events"
+	"flag"
+	"fmt"
+	"os"
+)
+
+var allowed = map[string][]events.Compatibility{
+	"full":     {events.FullyCompatible},
+	"backward": {events.FullyCompatible, events.BackwardCompatible},
+	"forward":  {events.FullyCompatible, events.ForwardCompatible},
+	"none":     {events.FullyCompatible, events.BackwardCompatible, events.ForwardCompatible, events.Breaking},
+}
+
+func main() {
+	require := flag.String("require", "backward", "minimum compatibility: full, backward, forward or none")
+	flag.Parse()
+
+	accepted, ok := allowed[*require]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown -require %q\n", *require)
+		os.Exit(2)
+	}
+
+	report, err := events.CheckCompatibility()
+	if err != nil {
+		panic(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	for _, compatibility := range accepted {
+		if report.Compatibility == compatibility {
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "schema change is %v, %v required\n", report.Compatibility, *require)
+	os.Exit(1)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"events"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+func main() {
+	dir := flag.String("dir", "schemas", "directory to write event schemas to")
+	flag.Parse()
+
+	for _, event := range events.Registry {
+		data, err := json.MarshalIndent(events.GenerateSchema(event), "", "  ")
+		if err != nil {
+			panic(err)
+		}
+
+		path := filepath.Join(*dir, reflect.TypeOf(event).Name()+".json")
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	ContentTypeHeader = "content-type"
+
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Codec encodes events for the wire. Decode expects a pointer to an event.
+type Codec interface {
+	ContentType() string
+	Encode(event Event) ([]byte, error)
+	Decode(data []byte, event Event) error
+}
+
+var (
+	codecsMutex = &sync.RWMutex{}
+	codecs      = map[string]Codec{
+		ContentTypeJSON:     JSONCodec{},
+		ContentTypeProtobuf: ProtobufCodec{},
+	}
+)
+
+// RegisterCodec makes codec available to CodecFor, for codecs such as Avro
+// that need runtime dependencies.
+func RegisterCodec(codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+	codecs[codec.ContentType()] = codec
+}
+
+// CodecFor picks the codec for a content-type header value. Messages
+// produced before the header existed have none and are JSON.
+func CodecFor(contentType string) (Codec, error) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+
+	codecsMutex.RLock()
+	codec, ok := codecs[contentType]
+	codecsMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	return codec, nil
+}
+
+// New returns a pointer to a zero event for topic, ready to decode into.
+func New(topic string) (Event, error) {
+	for _, event := range Registry {
+		if reflect.TypeOf(event).Name() == topic {
+			return reflect.New(reflect.TypeOf(event)).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown event %q", topic)
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+func (JSONCodec) Encode(event Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (JSONCodec) Decode(data []byte, event Event) error {
+	return json.Unmarshal(data, event)
+}
+
+// ProtobufCodec encodes events in the protobuf wire format, using the
+// protobuf struct tag as the field number. There is no .proto definition:
+// strings are length delimited, ints varints and floats fixed64, and a
+// field number must never be reused once an event has been produced.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+func (ProtobufCodec) Encode(event Event) ([]byte, error) {
+	value := reflect.Indirect(reflect.ValueOf(event))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot encode %T as protobuf", event)
+	}
+
+	data := []byte{}
+	for i := 0; i < value.NumField(); i++ {
+		number, ok := fieldNumber(value.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		field := value.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() == "" {
+				continue
+			}
+			data = protowire.AppendTag(data, number, protowire.BytesType)
+			data = protowire.AppendString(data, field.String())
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			if field.Int() == 0 {
+				continue
+			}
+			data = protowire.AppendTag(data, number, protowire.VarintType)
+			data = protowire.AppendVarint(data, uint64(field.Int()))
+		case reflect.Bool:
+			if !field.Bool() {
+				continue
+			}
+			data = protowire.AppendTag(data, number, protowire.VarintType)
+			data = protowire.AppendVarint(data, 1)
+		case reflect.Float64:
+			if field.Float() == 0 {
+				continue
+			}
+			data = protowire.AppendTag(data, number, protowire.Fixed64Type)
+			data = protowire.AppendFixed64(data, math.Float64bits(field.Float()))
+		default:
+			return nil, fmt.Errorf("cannot encode field %v of kind %v as protobuf", value.Type().Field(i).Name, field.Kind())
+		}
+	}
+
+	return data, nil
+}
+
+func (ProtobufCodec) Decode(data []byte, event Event) error {
+	pointer := reflect.ValueOf(event)
+	if pointer.Kind() != reflect.Ptr || pointer.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode protobuf into %T", event)
+	}
+	value := pointer.Elem()
+
+	fields := map[protowire.Number]reflect.Value{}
+	for i := 0; i < value.NumField(); i++ {
+		if number, ok := fieldNumber(value.Type().Field(i)); ok {
+			fields[number] = value.Field(i)
+		}
+	}
+
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		field, known := fields[number]
+		if !known {
+			// skip fields added by newer producers
+			n = protowire.ConsumeFieldValue(number, wireType, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.String && wireType == protowire.BytesType:
+			var text string
+			text, n = protowire.ConsumeString(data)
+			field.SetString(text)
+		case (field.Kind() == reflect.Int || field.Kind() == reflect.Int32 || field.Kind() == reflect.Int64) && wireType == protowire.VarintType:
+			var number uint64
+			number, n = protowire.ConsumeVarint(data)
+			field.SetInt(int64(number))
+		case field.Kind() == reflect.Bool && wireType == protowire.VarintType:
+			var number uint64
+			number, n = protowire.ConsumeVarint(data)
+			field.SetBool(number != 0)
+		case field.Kind() == reflect.Float64 && wireType == protowire.Fixed64Type:
+			var bits uint64
+			bits, n = protowire.ConsumeFixed64(data)
+			field.SetFloat(math.Float64frombits(bits))
+		default:
+			return errors.New("protobuf wire type does not match field " + strconv.Itoa(int(number)))
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+
+	return nil
+}
+
+func fieldNumber(field reflect.StructField) (protowire.Number, bool) {
+	number, err := strconv.Atoi(field.Tag.Get("protobuf"))
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return protowire.Number(number), true
+}
@@ -0,0 +1,144 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func Test_Codec_RoundTrip(t *testing.T) {
+	mockEvents := []Event{
+		OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 2, OpeningBalance: 1000.5},
+		DepositFundEvent{ID: "123", Amount: 500.25},
+		WithdrawFundEvent{ID: "123", Amount: 200},
+		CloseAccountEvent{ID: "123"},
+		AdjustBalanceEvent{ID: "123", Amount: -150.5, Reason: "reconciliation"},
+		OpenAccountEvent{},
+	}
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeProtobuf} {
+		codec, err := CodecFor(contentType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, event := range mockEvents {
+			name := reflect.TypeOf(event).Name()
+			t.Run("Test should round trip "+name+" as "+contentType, func(t *testing.T) {
+				data, err := codec.Encode(event)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				decoded, err := New(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = codec.Decode(data, decoded)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !reflect.DeepEqual(event, reflect.ValueOf(decoded).Elem().Interface()) {
+					t.Errorf("want %#v, got %#v", event, decoded)
+				}
+			})
+		}
+	}
+
+	if len(mockEvents)-1 != len(Registry) {
+		t.Errorf("every event in Registry needs a round trip case")
+	}
+}
+
+func Test_CodecFor(t *testing.T) {
+	tests := []struct {
+		name             string
+		mockContentType  string
+		wantCodec        Codec
+		wantErrorMessage string
+	}{
+		{
+			name:      "Test should default to json when header is missing",
+			wantCodec: JSONCodec{},
+		},
+		{
+			name:            "Test should return protobuf codec for protobuf content type",
+			mockContentType: ContentTypeProtobuf,
+			wantCodec:       ProtobufCodec{},
+		},
+		{
+			name:             "Test should return error for unsupported content type",
+			mockContentType:  "application/xml",
+			wantErrorMessage: `unsupported content type "application/xml"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codec, err := CodecFor(test.mockContentType)
+
+			if test.wantErrorMessage != "" {
+				if err == nil || err.Error() != test.wantErrorMessage {
+					t.Errorf("want error %q, got %v", test.wantErrorMessage, err)
+				}
+				return
+			}
+			if codec != test.wantCodec {
+				t.Errorf("want %T, got %T", test.wantCodec, codec)
+			}
+		})
+	}
+}
+
+func Test_RegisterCodec_Concurrent(t *testing.T) {
+	wait := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wait.Add(2)
+		go func() {
+			defer wait.Done()
+			RegisterCodec(JSONCodec{})
+		}()
+		go func() {
+			defer wait.Done()
+			_, err := CodecFor(ContentTypeJSON)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wait.Wait()
+}
+
+func Test_ProtobufCodec_Decode_SkipsUnknownFields(t *testing.T) {
+	// DepositFundEvent{ID: "1", Amount: 0} followed by field 9 = varint 7
+	data := []byte{0x0a, 0x01, '1', 0x48, 0x07}
+
+	event := &DepositFundEvent{}
+	err := ProtobufCodec{}.Decode(data, event)
+
+	if err != nil || event.ID != "1" {
+		t.Errorf("want ID 1 and no error, got %#v, %v", event, err)
+	}
+}
+
+func Test_ProtobufFieldNumbers(t *testing.T) {
+	for _, event := range Registry {
+		eventType := reflect.TypeOf(event)
+		t.Run("Test should number every field once for "+eventType.Name(), func(t *testing.T) {
+			numbers := map[int]string{}
+			for i := 0; i < eventType.NumField(); i++ {
+				field := eventType.Field(i)
+				number, ok := fieldNumber(field)
+				if !ok {
+					t.Errorf("field %v has no protobuf number", field.Name)
+					continue
+				}
+				if other, ok := numbers[int(number)]; ok {
+					t.Errorf("fields %v and %v share protobuf number %v", other, field.Name, number)
+				}
+				numbers[int(number)] = field.Name
+			}
+		})
+	}
+}
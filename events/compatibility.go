@@ -0,0 +1,202 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Compatibility describes who can still read a message after a schema change.
+// Backward means new consumers read old messages; forward means old consumers
+// read new messages.
+type Compatibility int
+
+const (
+	FullyCompatible Compatibility = iota
+	BackwardCompatible
+	ForwardCompatible
+	Breaking
+)
+
+func (obj Compatibility) String() string {
+	switch obj {
+	case FullyCompatible:
+		return "full"
+	case BackwardCompatible:
+		return "backward"
+	case ForwardCompatible:
+		return "forward"
+	default:
+		return "breaking"
+	}
+}
+
+func (obj Compatibility) MarshalText() ([]byte, error) {
+	return []byte(obj.String()), nil
+}
+
+// combine returns the weakest guarantee both a and b still give.
+func combine(a, b Compatibility) Compatibility {
+	if a == b || b == FullyCompatible {
+		return a
+	}
+	if a == FullyCompatible {
+		return b
+	}
+	return Breaking
+}
+
+type Change struct {
+	Event         string
+	Path          string
+	Description   string
+	Compatibility Compatibility
+}
+
+type CompatibilityReport struct {
+	Compatibility Compatibility
+	Changes       []Change
+}
+
+// CheckCompatibility compares the current events against the committed
+// schema snapshot.
+func CheckCompatibility() (CompatibilityReport, error) {
+	previous := map[string]Schema{}
+	entries, err := committedSchemas.ReadDir("schemas")
+	if err != nil {
+		return CompatibilityReport{}, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()[:len(entry.Name())-len(".json")]
+		previous[name], err = CommittedSchema(name)
+		if err != nil {
+			return CompatibilityReport{}, err
+		}
+	}
+
+	current := map[string]Schema{}
+	for _, event := range Registry {
+		current[reflect.TypeOf(event).Name()] = GenerateSchema(event)
+	}
+
+	return CompareSchemas(previous, current), nil
+}
+
+// CompareSchemas classifies every difference between two sets of event
+// schemas keyed by event name.
+func CompareSchemas(previous, current map[string]Schema) CompatibilityReport {
+	report := CompatibilityReport{Compatibility: FullyCompatible, Changes: []Change{}}
+	add := func(change Change) {
+		report.Changes = append(report.Changes, change)
+		report.Compatibility = combine(report.Compatibility, change.Compatibility)
+	}
+
+	for _, name := range union(schemaNames(previous), schemaNames(current)) {
+		before, existed := previous[name]
+		after, exists := current[name]
+		switch {
+		case !existed:
+			add(Change{name, "$", "event added", FullyCompatible})
+		case !exists:
+			add(Change{name, "$", "event removed", Breaking})
+		default:
+			compareObject(name, "$", before, after, add)
+		}
+	}
+
+	return report
+}
+
+func compareObject(event, path string, before, after Schema, add func(Change)) {
+	if before.Type != after.Type {
+		add(Change{event, path, fmt.Sprintf("type changed from %v to %v", before.Type, after.Type), Breaking})
+		return
+	}
+
+	wasRequired := toSet(before.Required)
+	isRequired := toSet(after.Required)
+	for _, name := range union(propertyNames(before.Properties), propertyNames(after.Properties)) {
+		property := path + "." + name
+		beforeProperty, existed := before.Properties[name]
+		afterProperty, exists := after.Properties[name]
+		switch {
+		case !existed && isRequired[name]:
+			// old messages lack it, old consumers ignore it unless closed
+			add(Change{event, property, "required property added", compatibility(false, !closed(before))})
+		case !existed:
+			add(Change{event, property, "optional property added", compatibility(true, !closed(before))})
+		case !exists && wasRequired[name]:
+			// new consumers ignore it unless closed, old consumers still
+			// expect it
+			add(Change{event, property, "required property removed", compatibility(!closed(after), false)})
+		case !exists:
+			add(Change{event, property, "optional property removed", compatibility(!closed(after), true)})
+		default:
+			compareObject(event, property, *beforeProperty, *afterProperty, add)
+			if wasRequired[name] && !isRequired[name] {
+				add(Change{event, property, "property became optional", BackwardCompatible})
+			}
+			if !wasRequired[name] && isRequired[name] {
+				add(Change{event, property, "property became required", ForwardCompatible})
+			}
+		}
+	}
+}
+
+// closed tells whether schema rejects properties it does not declare, as
+// the generated event schemas do.
+func closed(schema Schema) bool {
+	return schema.AdditionalProperties != nil && !*schema.AdditionalProperties
+}
+
+// compatibility returns the guarantee left when new consumers can still read
+// old messages (backward) and old consumers new ones (forward).
+func compatibility(backward, forward bool) Compatibility {
+	switch {
+	case backward && forward:
+		return FullyCompatible
+	case backward:
+		return BackwardCompatible
+	case forward:
+		return ForwardCompatible
+	default:
+		return Breaking
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func schemaNames(schemas map[string]Schema) []string {
+	names := []string{}
+	for name := range schemas {
+		names = append(names, name)
+	}
+	return names
+}
+
+func propertyNames(properties map[string]*Schema) []string {
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	return names
+}
+
+func union(a, b []string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range append(a, b...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_CompareSchemas(t *testing.T) {
+	type OpenAccountEventV2 struct {
+		ID             string
+		AccountHolder  string
+		AccountType    int
+		OpeningBalance float64
+		Currency       string `json:",omitempty"`
+	}
+	type OpenAccountEventV3 struct {
+		ID            string
+		AccountHolder string
+		AccountType   int
+		Balance       float64
+	}
+	type OpenAccountEventV4 struct {
+		ID             string
+		AccountHolder  string
+		AccountType    string
+		OpeningBalance float64
+	}
+	type OpenAccountEventV5 struct {
+		ID             string
+		AccountHolder  string
+		AccountType    int
+		OpeningBalance float64
+		Currency       string
+	}
+
+	tests := []struct {
+		name              string
+		mockCurrent       map[string]Schema
+		wantCompatibility Compatibility
+		wantChanges       int
+	}{
+		{
+			name:              "Test should be fully compatible when nothing changed",
+			mockCurrent:       map[string]Schema{"OpenAccountEvent": GenerateSchema(OpenAccountEvent{})},
+			wantCompatibility: FullyCompatible,
+		},
+		{
+			name:              "Test should be backward compatible when optional property is added",
+			mockCurrent:       map[string]Schema{"OpenAccountEvent": GenerateSchema(OpenAccountEventV2{})},
+			wantCompatibility: BackwardCompatible,
+			wantChanges:       1,
+		},
+		{
+			name:              "Test should be breaking when required property is added",
+			mockCurrent:       map[string]Schema{"OpenAccountEvent": GenerateSchema(OpenAccountEventV5{})},
+			wantCompatibility: Breaking,
+			wantChanges:       1,
+		},
+		{
+			name:              "Test should be breaking when required property is renamed",
+			mockCurrent:       map[string]Schema{"OpenAccountEvent": GenerateSchema(OpenAccountEventV3{})},
+			wantCompatibility: Breaking,
+			wantChanges:       2,
+		},
+		{
+			name:              "Test should be breaking when property type changes",
+			mockCurrent:       map[string]Schema{"OpenAccountEvent": GenerateSchema(OpenAccountEventV4{})},
+			wantCompatibility: Breaking,
+			wantChanges:       1,
+		},
+		{
+			name:              "Test should be breaking when event is removed",
+			mockCurrent:       map[string]Schema{},
+			wantCompatibility: Breaking,
+			wantChanges:       1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previous := map[string]Schema{"OpenAccountEvent": GenerateSchema(OpenAccountEvent{})}
+
+			report := CompareSchemas(previous, test.mockCurrent)
+
+			if report.Compatibility != test.wantCompatibility {
+				t.Errorf("want %v, got %v: %+v", test.wantCompatibility, report.Compatibility, report.Changes)
+			}
+			if len(report.Changes) != test.wantChanges {
+				t.Errorf("want %v changes, got %+v", test.wantChanges, report.Changes)
+			}
+		})
+	}
+}
+
+func Test_compareObject(t *testing.T) {
+	object := func(additionalProperties bool, required []string, names ...string) Schema {
+		schema := Schema{Type: "object", Properties: map[string]*Schema{}, Required: required, AdditionalProperties: &additionalProperties}
+		for _, name := range names {
+			schema.Properties[name] = &Schema{Type: "string"}
+		}
+		return schema
+	}
+
+	tests := []struct {
+		name       string
+		mockBefore Schema
+		mockAfter  Schema
+		wantChange Change
+	}{
+		{
+			name:       "Test should be fully compatible when optional property is added to open schema",
+			mockBefore: object(true, []string{"ID"}, "ID"),
+			mockAfter:  object(true, []string{"ID"}, "ID", "Currency"),
+			wantChange: Change{"Event", "$.Currency", "optional property added", FullyCompatible},
+		},
+		{
+			name:       "Test should be backward compatible when optional property is added to closed schema",
+			mockBefore: object(false, []string{"ID"}, "ID"),
+			mockAfter:  object(false, []string{"ID"}, "ID", "Currency"),
+			wantChange: Change{"Event", "$.Currency", "optional property added", BackwardCompatible},
+		},
+		{
+			name:       "Test should be forward compatible when required property is added to open schema",
+			mockBefore: object(true, []string{"ID"}, "ID"),
+			mockAfter:  object(true, []string{"ID", "Currency"}, "ID", "Currency"),
+			wantChange: Change{"Event", "$.Currency", "required property added", ForwardCompatible},
+		},
+		{
+			name:       "Test should be breaking when required property is added to closed schema",
+			mockBefore: object(false, []string{"ID"}, "ID"),
+			mockAfter:  object(false, []string{"ID", "Currency"}, "ID", "Currency"),
+			wantChange: Change{"Event", "$.Currency", "required property added", Breaking},
+		},
+		{
+			name:       "Test should be fully compatible when optional property is removed from open schema",
+			mockBefore: object(true, []string{"ID"}, "ID", "Currency"),
+			mockAfter:  object(true, []string{"ID"}, "ID"),
+			wantChange: Change{"Event", "$.Currency", "optional property removed", FullyCompatible},
+		},
+		{
+			name:       "Test should be forward compatible when optional property is removed from closed schema",
+			mockBefore: object(false, []string{"ID"}, "ID", "Currency"),
+			mockAfter:  object(false, []string{"ID"}, "ID"),
+			wantChange: Change{"Event", "$.Currency", "optional property removed", ForwardCompatible},
+		},
+		{
+			name:       "Test should be backward compatible when required property is removed from open schema",
+			mockBefore: object(true, []string{"ID", "Currency"}, "ID", "Currency"),
+			mockAfter:  object(true, []string{"ID"}, "ID"),
+			wantChange: Change{"Event", "$.Currency", "required property removed", BackwardCompatible},
+		},
+		{
+			name:       "Test should be breaking when required property is removed from closed schema",
+			mockBefore: object(false, []string{"ID", "Currency"}, "ID", "Currency"),
+			mockAfter:  object(false, []string{"ID"}, "ID"),
+			wantChange: Change{"Event", "$.Currency", "required property removed", Breaking},
+		},
+		{
+			name:       "Test should be backward compatible when property becomes optional",
+			mockBefore: object(false, []string{"ID", "Currency"}, "ID", "Currency"),
+			mockAfter:  object(false, []string{"ID"}, "ID", "Currency"),
+			wantChange: Change{"Event", "$.Currency", "property became optional", BackwardCompatible},
+		},
+		{
+			name:       "Test should be forward compatible when property becomes required",
+			mockBefore: object(false, []string{"ID"}, "ID", "Currency"),
+			mockAfter:  object(false, []string{"ID", "Currency"}, "ID", "Currency"),
+			wantChange: Change{"Event", "$.Currency", "property became required", ForwardCompatible},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := []Change{}
+
+			compareObject("Event", "$", test.mockBefore, test.mockAfter, func(change Change) { changes = append(changes, change) })
+
+			if !reflect.DeepEqual(changes, []Change{test.wantChange}) {
+				t.Errorf("want %+v, got %+v", test.wantChange, changes)
+			}
+		})
+	}
+}
@@ -7,28 +7,38 @@ var Topics = []string{
 	reflect.TypeOf(DepositFundEvent{}).Name(),
 	reflect.TypeOf(WithdrawFundEvent{}).Name(),
 	reflect.TypeOf(CloseAccountEvent{}).Name(),
+	reflect.TypeOf(AdjustBalanceEvent{}).Name(),
 }
 
 type Event interface {
 }
 
 type OpenAccountEvent struct {
-	ID             string
-	AccountHolder  string
-	AccountType    int
-	OpeningBalance float64
+	ID             string  `protobuf:"1"`
+	AccountHolder  string  `protobuf:"2"`
+	AccountType    int     `protobuf:"3"`
+	OpeningBalance float64 `protobuf:"4"`
 }
 
 type DepositFundEvent struct {
-	ID     string
-	Amount float64
+	ID     string  `protobuf:"1"`
+	Amount float64 `protobuf:"2"`
 }
 
 type WithdrawFundEvent struct {
-	ID     string
-	Amount float64
+	ID     string  `protobuf:"1"`
+	Amount float64 `protobuf:"2"`
 }
 
 type CloseAccountEvent struct {
-	ID string
+	ID string `protobuf:"1"`
+}
+
+// AdjustBalanceEvent corrects a balance in the read model that drifted from
+// the event log. It is not a business event: Amount is added to the stored
+// balance, and reconciliation leaves it out of the expected balance.
+type AdjustBalanceEvent struct {
+	ID     string  `protobuf:"1"`
+	Amount float64 `protobuf:"2"`
+	Reason string  `protobuf:"3"`
 }
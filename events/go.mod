@@ -1,3 +1,5 @@
 module events
 
 go 1.17
+
+require google.golang.org/protobuf v1.30.0
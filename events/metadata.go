@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"fmt"
+)
+
+// Kafka header keys attached to every produced event.
+const (
+	EventTypeHeader     = "event-type"
+	SchemaVersionHeader = "schema-version"
+	CorrelationIDHeader = "correlation-id"
+	RequestIDHeader     = "request-id"
+	TraceparentHeader   = "traceparent"
+)
+
+// SchemaVersions is the version sent in the schema-version header. Bump an
+// event's version whenever its committed schema under schemas/ changes.
+var SchemaVersions = map[string]int{
+	"OpenAccountEvent":   1,
+	"DepositFundEvent":   1,
+	"WithdrawFundEvent":  1,
+	"CloseAccountEvent":  1,
+	"AdjustBalanceEvent": 1,
+}
+
+// Metadata travels with an event, from the HTTP request that caused it
+// through Kafka headers to the consumer handling it.
+type Metadata struct {
+	EventType     string
+	SchemaVersion string
+	ContentType   string
+	CorrelationID string
+	RequestID     string
+	Traceparent   string
+}
+
+type metadataKey struct{}
+
+func ContextWithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+func MetadataFromContext(ctx context.Context) Metadata {
+	metadata, _ := ctx.Value(metadataKey{}).(Metadata)
+	return metadata
+}
+
+// Headers returns the metadata as Kafka header key/value pairs, skipping
+// empty values.
+func (obj Metadata) Headers() map[string]string {
+	headers := map[string]string{}
+	for key, value := range map[string]string{
+		EventTypeHeader:     obj.EventType,
+		SchemaVersionHeader: obj.SchemaVersion,
+		ContentTypeHeader:   obj.ContentType,
+		CorrelationIDHeader: obj.CorrelationID,
+		RequestIDHeader:     obj.RequestID,
+		TraceparentHeader:   obj.Traceparent,
+	} {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+	return headers
+}
+
+func MetadataFromHeaders(headers map[string]string) Metadata {
+	return Metadata{
+		EventType:     headers[EventTypeHeader],
+		SchemaVersion: headers[SchemaVersionHeader],
+		ContentType:   headers[ContentTypeHeader],
+		CorrelationID: headers[CorrelationIDHeader],
+		RequestID:     headers[RequestIDHeader],
+		Traceparent:   headers[TraceparentHeader],
+	}
+}
+
+// String formats the fields useful in log lines.
+func (obj Metadata) String() string {
+	return fmt.Sprintf("request_id=%v correlation_id=%v traceparent=%v", obj.RequestID, obj.CorrelationID, obj.Traceparent)
+}
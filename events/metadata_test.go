@@ -0,0 +1,32 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func Test_Metadata_Headers(t *testing.T) {
+	metadata := Metadata{
+		EventType:     "DepositFundEvent",
+		SchemaVersion: "1",
+		ContentType:   ContentTypeJSON,
+		CorrelationID: "correlation",
+		RequestID:     "request",
+	}
+
+	headers := metadata.Headers()
+
+	if _, ok := headers[TraceparentHeader]; ok {
+		t.Errorf("want empty traceparent to be skipped, got %v", headers)
+	}
+	if !reflect.DeepEqual(metadata, MetadataFromHeaders(headers)) {
+		t.Errorf("want headers to round trip, got %#v", MetadataFromHeaders(headers))
+	}
+	if !reflect.DeepEqual(metadata, MetadataFromContext(ContextWithMetadata(context.Background(), metadata))) {
+		t.Errorf("want metadata to round trip through context")
+	}
+	if MetadataFromContext(context.Background()) != (Metadata{}) {
+		t.Errorf("want empty metadata from bare context")
+	}
+}
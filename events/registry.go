@@ -0,0 +1,215 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// SchemaRegistry is the part of the Confluent schema registry API the Avro
+// codec needs.
+type SchemaRegistry interface {
+	// Register returns the ID of schema under subject, registering it first
+	// when it is new.
+	Register(subject string, schema string) (id int, err error)
+	GetSchema(id int) (schema string, err error)
+}
+
+// registration is a schema registered under a subject.
+type registration struct {
+	subject string
+	schema  string
+}
+
+type fileSchemaRegistryState struct {
+	Subjects map[string][]int
+	Schemas  map[string]string
+}
+
+type fileSchemaRegistry struct {
+	mutex *sync.Mutex
+	path  string
+	cache map[int]string
+	ids   map[registration]int
+}
+
+// NewFileSchemaRegistry keeps schemas in a JSON file at path, standing in for
+// a schema registry in tests and local runs. The file is created on first
+// registration, and read again only for schemas and registrations this
+// registry has not seen yet.
+func NewFileSchemaRegistry(path string) SchemaRegistry {
+	return fileSchemaRegistry{&sync.Mutex{}, path, map[int]string{}, map[registration]int{}}
+}
+
+func (obj fileSchemaRegistry) load() (fileSchemaRegistryState, error) {
+	state := fileSchemaRegistryState{Subjects: map[string][]int{}, Schemas: map[string]string{}}
+	data, err := os.ReadFile(obj.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (obj fileSchemaRegistry) Register(subject string, schema string) (int, error) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if id, ok := obj.ids[registration{subject, schema}]; ok {
+		return id, nil
+	}
+
+	state, err := obj.load()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range state.Subjects[subject] {
+		if state.Schemas[strconv.Itoa(id)] == schema {
+			obj.ids[registration{subject, schema}] = id
+			return id, nil
+		}
+	}
+
+	id := 0
+	for key, existing := range state.Schemas {
+		if existing == schema {
+			id, _ = strconv.Atoi(key)
+		}
+	}
+	if id == 0 {
+		id = len(state.Schemas) + 1
+		state.Schemas[strconv.Itoa(id)] = schema
+	}
+	state.Subjects[subject] = append(state.Subjects[subject], id)
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	err = os.WriteFile(obj.path, data, 0644)
+	if err != nil {
+		return 0, err
+	}
+	obj.ids[registration{subject, schema}] = id
+	return id, nil
+}
+
+func (obj fileSchemaRegistry) GetSchema(id int) (string, error) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if schema, ok := obj.cache[id]; ok {
+		return schema, nil
+	}
+
+	state, err := obj.load()
+	if err != nil {
+		return "", err
+	}
+	schema, ok := state.Schemas[strconv.Itoa(id)]
+	if !ok {
+		return "", fmt.Errorf("schema %v not found", id)
+	}
+	obj.cache[id] = schema
+	return schema, nil
+}
+
+type httpSchemaRegistry struct {
+	mutex  *sync.Mutex
+	url    string
+	client *http.Client
+	cache  map[int]string
+	ids    map[registration]int
+}
+
+// NewHTTPSchemaRegistry talks to a Confluent compatible schema registry. It
+// remembers the schemas it fetched and the IDs it registered, so each costs
+// one request.
+func NewHTTPSchemaRegistry(url string, client *http.Client) SchemaRegistry {
+	return httpSchemaRegistry{&sync.Mutex{}, url, client, map[int]string{}, map[registration]int{}}
+}
+
+func (obj httpSchemaRegistry) Register(subject string, schema string) (int, error) {
+	obj.mutex.Lock()
+	id, ok := obj.ids[registration{subject, schema}]
+	obj.mutex.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := obj.client.Post(obj.url+"/subjects/"+subject+"/versions", "application/vnd.schemaregistry.v1+json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("register schema for %v: %v", subject, res.Status)
+	}
+
+	response := struct{ ID int }{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return 0, err
+	}
+
+	obj.mutex.Lock()
+	obj.ids[registration{subject, schema}] = response.ID
+	obj.mutex.Unlock()
+	return response.ID, nil
+}
+
+func (obj httpSchemaRegistry) GetSchema(id int) (string, error) {
+	obj.mutex.Lock()
+	schema, ok := obj.cache[id]
+	obj.mutex.Unlock()
+	if ok {
+		return schema, nil
+	}
+
+	res, err := obj.client.Get(obj.url + "/schemas/ids/" + strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get schema %v: %v", id, res.Status)
+	}
+
+	response := struct{ Schema string }{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return "", err
+	}
+
+	obj.mutex.Lock()
+	obj.cache[id] = response.Schema
+	obj.mutex.Unlock()
+	return response.Schema, nil
+}
+
+// NewSchemaRegistry picks the HTTP registry when url is set, the file backed
+// one when only file is set, and returns nil when neither is configured.
+func NewSchemaRegistry(url string, file string) SchemaRegistry {
+	switch {
+	case url != "":
+		return NewHTTPSchemaRegistry(url, http.DefaultClient)
+	case file != "":
+		return NewFileSchemaRegistry(file)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:generate go run ./cmd/schemagen
+
+// Schema is the subset of JSON Schema draft-07 needed to describe events.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+}
+
+//go:embed schemas/*.json
+var committedSchemas embed.FS
+
+// Registry lists one zero value per event, in the same order as Topics.
+var Registry = []Event{
+	OpenAccountEvent{},
+	DepositFundEvent{},
+	WithdrawFundEvent{},
+	CloseAccountEvent{},
+	AdjustBalanceEvent{},
+}
+
+// GenerateSchema describes the JSON encoding/json produces for event.
+func GenerateSchema(event Event) Schema {
+	schema := schemaOf(reflect.TypeOf(event))
+	schema.Schema = "http://json-schema.org/draft-07/schema#"
+	schema.Title = reflect.TypeOf(event).Name()
+	return *schema
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Struct:
+		additionalProperties := false
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: &additionalProperties}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, omitEmpty, skip := jsonName(field)
+			if skip {
+				continue
+			}
+			schema.Properties[name] = schemaOf(field.Type)
+			if !omitEmpty {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		sort.Strings(schema.Required)
+		return schema
+	default:
+		panic(fmt.Sprintf("events: unsupported field kind %v", t.Kind()))
+	}
+}
+
+func jsonName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	if field.PkgPath != "" {
+		return "", false, true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = field.Name
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// CommittedSchema returns the schema snapshot committed under schemas/ for
+// the named event.
+func CommittedSchema(name string) (Schema, error) {
+	schema := Schema{}
+	data, err := committedSchemas.ReadFile("schemas/" + name + ".json")
+	if err != nil {
+		return schema, err
+	}
+
+	err = json.Unmarshal(data, &schema)
+	return schema, err
+}
+
+// Validate reports the first way data does not conform to the schema.
+func (obj Schema) Validate(data []byte) error {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	return obj.validate("$", value)
+}
+
+func (obj Schema) validate(path string, value interface{}) error {
+	switch obj.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected object", path)
+		}
+		for _, name := range obj.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%v: missing required property %q", path, name)
+			}
+		}
+		for name, property := range object {
+			schema, ok := obj.Properties[name]
+			if !ok {
+				if obj.AdditionalProperties != nil && !*obj.AdditionalProperties {
+					return fmt.Errorf("%v: unexpected property %q", path, name)
+				}
+				continue
+			}
+			err := schema.validate(path+"."+name, property)
+			if err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%v: expected string", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%v: expected boolean", path)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%v: expected number", path)
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%v: expected integer", path)
+		}
+	}
+
+	return nil
+}
+
+// Sample builds a deterministic payload that satisfies the schema.
+func (obj Schema) Sample() []byte {
+	data, _ := json.Marshal(obj.sample())
+	return data
+}
+
+func (obj Schema) sample() interface{} {
+	switch obj.Type {
+	case "object":
+		object := map[string]interface{}{}
+		for name, property := range obj.Properties {
+			object[name] = property.sample()
+		}
+		return object
+	case "string":
+		return "sample"
+	case "boolean":
+		return true
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_CommittedSchema(t *testing.T) {
+	for _, event := range Registry {
+		name := reflect.TypeOf(event).Name()
+		t.Run("Test should match generated schema for "+name, func(t *testing.T) {
+			committed, err := CommittedSchema(name)
+			if err != nil {
+				t.Fatalf("schemas/%v.json missing, run go generate: %v", name, err)
+			}
+
+			if !reflect.DeepEqual(GenerateSchema(event), committed) {
+				t.Errorf("schemas/%v.json is stale, run go generate and review the change", name)
+			}
+		})
+	}
+}
+
+func Test_Schema_Validate(t *testing.T) {
+	schema := GenerateSchema(DepositFundEvent{})
+
+	tests := []struct {
+		name        string
+		mockPayload string
+		wantError   string
+	}{
+		{
+			name:        "Test should accept payload matching schema",
+			mockPayload: `{"ID":"123","Amount":1000}`,
+		},
+		{
+			name:        "Test should reject payload missing required property",
+			mockPayload: `{"ID":"123"}`,
+			wantError:   `$: missing required property "Amount"`,
+		},
+		{
+			name:        "Test should reject payload with wrong property type",
+			mockPayload: `{"ID":123,"Amount":1000}`,
+			wantError:   "$.ID: expected string",
+		},
+		{
+			name:        "Test should reject payload with unknown property",
+			mockPayload: `{"ID":"123","Amount":1000,"Currency":"THB"}`,
+			wantError:   `$: unexpected property "Currency"`,
+		},
+		{
+			name:        "Test should accept its own sample",
+			mockPayload: string(schema.Sample()),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := schema.Validate([]byte(test.mockPayload))
+
+			if test.wantError == "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if test.wantError != "" && (err == nil || !strings.Contains(err.Error(), test.wantError)) {
+				t.Errorf("want error %q, got %v", test.wantError, err)
+			}
+		})
+	}
+}
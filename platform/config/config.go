@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Profiles are the accepted values of Options.Profile.
+var Profiles = []string{"local", "test", "prod"}
+
+type Options struct {
+	// Dir holds config.yaml and the optional config.<profile>.yaml.
+	Dir string
+	// Profile is one of Profiles; empty means local.
+	Profile string
+	// Defaults maps dotted keys, e.g. "db.port", to the value used when
+	// neither a file nor the environment sets them.
+	Defaults map[string]interface{}
+}
+
+// Load fills out, a pointer to a struct with mapstructure keys, from
+// Options.Defaults, then config.yaml, then config.<profile>.yaml, then
+// environment variables named after the upper-cased key with dots replaced
+// by underscores, e.g. DB_HOST. Missing files are skipped, so a service can
+// run on defaults and environment variables alone.
+func Load(options Options, out interface{}) error {
+	profile := options.Profile
+	if profile == "" {
+		profile = "local"
+	}
+	if !contains(Profiles, profile) {
+		return fmt.Errorf("unknown profile %q, want one of %v", profile, strings.Join(Profiles, ", "))
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	for key, value := range options.Defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetDefault("profile", profile)
+
+	for _, name := range []string{"config.yaml", "config." + profile + ".yaml"} {
+		file, err := os.Open(filepath.Join(options.Dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = v.MergeConfig(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+	}
+
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	return v.Unmarshal(out)
+}
+
+// MergeDefaults combines the defaults of several packages into one map for
+// Options.Defaults; later maps win.
+func MergeDefaults(defaults ...map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, values := range defaults {
+		for key, value := range values {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// Secret returns the trimmed content of file when it is set, so passwords
+// can be mounted as files instead of written into config.yaml, and value
+// otherwise.
+func Secret(value string, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ValidationError lists every invalid field of a config, so one run reports
+// all of them.
+type ValidationError []string
+
+// Check records message against field unless ok.
+func (obj *ValidationError) Check(ok bool, field string, message string) {
+	if !ok {
+		*obj = append(*obj, field+": "+message)
+	}
+}
+
+// OneOf records an error against field unless value is one of values.
+func (obj *ValidationError) OneOf(field string, value string, values ...string) {
+	obj.Check(contains(values, value), field, fmt.Sprintf("%q is not one of %v", value, strings.Join(values, ", ")))
+}
+
+// Err returns the error, or nil when no field was recorded.
+func (obj ValidationError) Err() error {
+	if len(obj) == 0 {
+		return nil
+	}
+	return obj
+}
+
+func (obj ValidationError) Error() string {
+	return "invalid config: " + strings.Join(obj, "; ")
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type testConfig struct {
+	Profile string
+	DB      struct {
+		Host     string
+		Port     int
+		Password string
+	}
+	Kafka struct {
+		Servers []string
+	}
+}
+
+func Test_Load(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "config.yaml", "db:\n  host: localhost\n  port: 3306\nkafka:\n  servers:\n    - localhost:9092\n")
+	writeFile(t, dir, "config.prod.yaml", "db:\n  host: db.internal\n")
+
+	tests := []struct {
+		name        string
+		mockProfile string
+		mockEnv     map[string]string
+
+		wantConfig testConfig
+		wantError  bool
+	}{
+		{
+			name: "Test should read config.yaml over defaults for local profile",
+			wantConfig: func() testConfig {
+				config := testConfig{Profile: "local"}
+				config.DB.Host, config.DB.Port, config.DB.Password = "localhost", 3306, "secret"
+				config.Kafka.Servers = []string{"localhost:9092"}
+				return config
+			}(),
+		},
+		{
+			name:        "Test should merge profile file and environment over config.yaml",
+			mockProfile: "prod",
+			mockEnv:     map[string]string{"DB_PORT": "3307", "KAFKA_SERVERS": "kafka-1:9092,kafka-2:9092"},
+			wantConfig: func() testConfig {
+				config := testConfig{Profile: "prod"}
+				config.DB.Host, config.DB.Port, config.DB.Password = "db.internal", 3307, "secret"
+				config.Kafka.Servers = []string{"kafka-1:9092", "kafka-2:9092"}
+				return config
+			}(),
+		},
+		{
+			name:        "Test should return error for unknown profile",
+			mockProfile: "staging",
+			wantError:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.mockEnv {
+				t.Setenv(key, value)
+			}
+
+			config := testConfig{}
+			err := Load(Options{
+				Dir:      dir,
+				Profile:  test.mockProfile,
+				Defaults: map[string]interface{}{"db.password": "secret", "db.port": 1},
+			}, &config)
+			if (err != nil) != test.wantError {
+				t.Fatalf("want error %v, got %v", test.wantError, err)
+			}
+			if err == nil && !reflect.DeepEqual(test.wantConfig, config) {
+				t.Errorf("want %+v, got %+v", test.wantConfig, config)
+			}
+		})
+	}
+}
+
+func Test_Load_without_files(t *testing.T) {
+	config := testConfig{}
+	err := Load(Options{Dir: t.TempDir(), Defaults: map[string]interface{}{"db.host": "localhost"}}, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DB.Host != "localhost" {
+		t.Errorf("want default host, got %q", config.DB.Host)
+	}
+}
+
+func Test_Secret(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "password", "P@ssw0rd\n")
+
+	value, err := Secret("inline", "")
+	if err != nil || value != "inline" {
+		t.Errorf("want inline value, got %q %v", value, err)
+	}
+	value, err = Secret("inline", filepath.Join(dir, "password"))
+	if err != nil || value != "P@ssw0rd" {
+		t.Errorf("want file content, got %q %v", value, err)
+	}
+	_, err = Secret("", filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Error("want error for missing file")
+	}
+}
+
+func Test_ValidationError(t *testing.T) {
+	errs := ValidationError{}
+	if errs.Err() != nil {
+		t.Fatal("want nil error when nothing is recorded")
+	}
+
+	errs.Check(false, "kafka.group", "is required")
+	errs.Check(true, "db.host", "is required")
+	errs.OneOf("log.level", "verbose", "debug", "info")
+
+	want := `invalid config: kafka.group: is required; log.level: "verbose" is not one of debug, info`
+	if errs.Err() == nil || errs.Err().Error() != want {
+		t.Errorf("want %q, got %v", want, errs.Err())
+	}
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"platform/config"
+	"strconv"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Config is the database connection shared by the producer's event store
+// and the consumer's read model, read from the db key of their config.yaml.
+type Config struct {
+	// Driver is mysql, postgres or sqlite.
+	Driver   string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// PasswordFile, when set, replaces Password with the file content.
+	PasswordFile string `mapstructure:"passwordFile"`
+	// Database is the database name, or the file path for sqlite.
+	Database string
+	// MigrateOnStart applies pending migrations at startup; otherwise the
+	// service refuses to start until `migrate up` has run.
+	MigrateOnStart bool `mapstructure:"migrateOnStart"`
+}
+
+// Defaults holds the config.Options defaults for every key of Config, so
+// each can be set from the environment.
+var Defaults = map[string]interface{}{
+	"db.driver":         "mysql",
+	"db.host":           "localhost",
+	"db.port":           3306,
+	"db.username":       "",
+	"db.password":       "",
+	"db.passwordFile":   "",
+	"db.database":       "",
+	"db.migrateOnStart": true,
+}
+
+// Drivers lists the databases the services can be stored in.
+var Drivers = []string{"mysql", "postgres", "sqlite"}
+
+// ResolveSecrets reads the password from PasswordFile when it is set.
+func (obj *Config) ResolveSecrets() error {
+	password, err := config.Secret(obj.Password, obj.PasswordFile)
+	if err != nil {
+		return err
+	}
+	obj.Password = password
+	return nil
+}
+
+// Validate records every invalid db field in errs.
+func (obj Config) Validate(errs *config.ValidationError) {
+	errs.OneOf("db.driver", obj.Driver, Drivers...)
+	if obj.Driver != "sqlite" {
+		errs.Check(obj.Host != "", "db.host", "is required")
+		errs.Check(obj.Port > 0 && obj.Port < 65536, "db.port", "must be between 1 and 65535")
+		errs.Check(obj.Username != "", "db.username", "is required")
+	}
+	errs.Check(obj.Database != "", "db.database", "is required")
+}
+
+// DSN is the connection string for the driver.
+func (obj Config) DSN() string {
+	switch obj.Driver {
+	case "postgres":
+		dsn := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(obj.Username, obj.Password),
+			Host:   net.JoinHostPort(obj.Host, strconv.Itoa(obj.Port)),
+			Path:   "/" + obj.Database,
+		}
+		return dsn.String()
+	case "sqlite":
+		return obj.Database
+	}
+	return fmt.Sprintf("%v:%v@tcp(%v:%v)/%v",
+		obj.Username,
+		obj.Password,
+		obj.Host,
+		obj.Port,
+		obj.Database,
+	)
+}
+
+// Dialector opens dsn with the gorm driver named driver. For sqlite the dsn
+// is a file path or a file: URI.
+func Dialector(driver string, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case "mysql":
+		return mysql.Open(dsn), nil
+	case "postgres":
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		return sqlite.Open(dsn), nil
+	}
+	return nil, fmt.Errorf("unknown database driver %q", driver)
+}
+
+// Open connects to the database with GORM's own logging off and a span
+// recorded for every statement.
+func Open(cfg Config) (*gorm.DB, error) {
+	dial, err := Dialector(cfg.Driver, cfg.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dial, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Use(NewTracingPlugin())
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
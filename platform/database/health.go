@@ -0,0 +1,18 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Check reports whether the database answers a ping, for health.Checker.
+func Check(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_Check(t *testing.T) {
+	db, err := Open(Config{Driver: "sqlite", Database: "file:health?mode=memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := Check(db)
+
+	if err := check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	if check(context.Background()) == nil {
+		t.Error("want an error from a closed database")
+	}
+}
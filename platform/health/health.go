@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check reports whether a dependency is reachable. It must return when ctx
+// is done.
+type Check func(ctx context.Context) error
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusShutdown    = "shutting down"
+)
+
+// Report is the readiness response body.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func (obj Report) OK() bool {
+	return obj.Status == StatusOK
+}
+
+// Checker runs the readiness checks of a service.
+type Checker struct {
+	// Timeout bounds each check.
+	Timeout time.Duration
+
+	mutex        sync.RWMutex
+	checks       map[string]Check
+	shuttingDown bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{Timeout: 2 * time.Second, checks: map[string]Check{}}
+}
+
+// Add registers check under name, e.g. "kafka" or "db".
+func (obj *Checker) Add(name string, check Check) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	obj.checks[name] = check
+}
+
+// Shutdown makes every later readiness report fail, so load balancers stop
+// routing to the service while it drains.
+func (obj *Checker) Shutdown() {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+	obj.shuttingDown = true
+}
+
+// Ready runs every check concurrently and reports ok only when all pass.
+func (obj *Checker) Ready(ctx context.Context) Report {
+	obj.mutex.RLock()
+	shuttingDown := obj.shuttingDown
+	checks := make(map[string]Check, len(obj.checks))
+	for name, check := range obj.checks {
+		checks[name] = check
+	}
+	obj.mutex.RUnlock()
+
+	if shuttingDown {
+		return Report{Status: StatusShutdown}
+	}
+
+	report := Report{Status: StatusOK, Checks: map[string]string{}}
+	results := make(chan [2]string, len(checks))
+	for name, check := range checks {
+		go func(name string, check Check) {
+			ctx, cancel := context.WithTimeout(ctx, obj.Timeout)
+			defer cancel()
+			result := StatusOK
+			if err := check(ctx); err != nil {
+				result = err.Error()
+			}
+			results <- [2]string{name, result}
+		}(name, check)
+	}
+	for range checks {
+		result := <-results
+		report.Checks[result[0]] = result[1]
+		if result[1] != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+// LivenessHandler answers /healthz: the process is up and serving.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// ReadinessHandler answers /readyz with the Ready report, and 503 unless it
+// is ok.
+func (obj *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := obj.Ready(r.Context())
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Checker_Ready(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockChecks   map[string]Check
+		mockShutdown bool
+
+		wantStatus int
+		wantReport Report
+	}{
+		{
+			name: "Test should be ready when every check passes",
+			mockChecks: map[string]Check{
+				"kafka": func(context.Context) error { return nil },
+				"db":    func(context.Context) error { return nil },
+			},
+			wantStatus: http.StatusOK,
+			wantReport: Report{Status: StatusOK, Checks: map[string]string{"kafka": StatusOK, "db": StatusOK}},
+		},
+		{
+			name: "Test should report failing and timed out checks",
+			mockChecks: map[string]Check{
+				"kafka": func(context.Context) error { return errors.New("client has run out of available brokers") },
+				"db": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: Report{Status: StatusUnavailable, Checks: map[string]string{
+				"kafka": "client has run out of available brokers",
+				"db":    context.DeadlineExceeded.Error(),
+			}},
+		},
+		{
+			name: "Test should not be ready once shutting down",
+			mockChecks: map[string]Check{
+				"kafka": func(context.Context) error { return nil },
+			},
+			mockShutdown: true,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantReport:   Report{Status: StatusShutdown},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checker := NewChecker()
+			checker.Timeout = 10 * time.Millisecond
+			for name, check := range test.mockChecks {
+				checker.Add(name, check)
+			}
+			if test.mockShutdown {
+				checker.Shutdown()
+			}
+
+			recorder := httptest.NewRecorder()
+			checker.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			report := Report{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			if recorder.Code != test.wantStatus || !reflect.DeepEqual(test.wantReport, report) {
+				t.Errorf("want %v %+v, got %v %+v", test.wantStatus, test.wantReport, recorder.Code, report)
+			}
+		})
+	}
+}
+
+func Test_LivenessHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("want %v, got %v", http.StatusOK, recorder.Code)
+	}
+}
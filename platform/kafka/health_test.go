@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func Test_Check(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Metadata.Retry.Max = 0
+	saramaConfig.Net.DialTimeout = 100 * time.Millisecond
+	client, err := sarama.NewClient([]string{broker.Addr()}, saramaConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	check := Check(client)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := check(ctx); err != nil {
+		t.Fatalf("want broker reachable, got %v", err)
+	}
+
+	broker.Close()
+	if err := check(ctx); err == nil {
+		t.Error("want error once the broker is gone")
+	}
+}
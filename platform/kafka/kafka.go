@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"platform/config"
+	"sort"
+
+	"github.com/Shopify/sarama"
+)
+
+// Config is the client configuration shared by the producer and the
+// consumer, read from the kafka key of their config.yaml.
+type Config struct {
+	Servers []string
+	// ClientID is sent with every request and shows up in broker logs.
+	ClientID string `mapstructure:"clientId"`
+	// Version is the lowest broker version the client talks to, e.g. 2.8.0.
+	Version string
+
+	TLS struct {
+		Enabled bool
+		// CAFile verifies the brokers when they use a private CA.
+		CAFile string `mapstructure:"caFile"`
+		// CertFile and KeyFile authenticate the client with mutual TLS.
+		CertFile           string `mapstructure:"certFile"`
+		KeyFile            string `mapstructure:"keyFile"`
+		InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify"`
+	}
+
+	SASL struct {
+		// Mechanism is PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or empty to
+		// disable SASL.
+		Mechanism string
+		Username  string
+		Password  string
+		// PasswordFile, when set, replaces Password with the file content.
+		PasswordFile string `mapstructure:"passwordFile"`
+	}
+
+	Producer struct {
+		// Acks is all, leader or none.
+		Acks       string
+		Idempotent bool
+		Retries    int
+	}
+
+	Consumer struct {
+		// Rebalance is range, roundrobin or sticky.
+		Rebalance string
+		// InitialOffset is oldest or newest, used when the group has no
+		// committed offset.
+		InitialOffset string `mapstructure:"initialOffset"`
+	}
+
+	Topics TopicsConfig
+}
+
+// Defaults holds the config.Options defaults for every key of Config, so
+// each can be set from the environment.
+var Defaults = map[string]interface{}{
+	"kafka.servers":                           []string{"localhost:9092"},
+	"kafka.clientId":                          "",
+	"kafka.version":                           sarama.V1_0_0_0.String(),
+	"kafka.tls.enabled":                       false,
+	"kafka.tls.caFile":                        "",
+	"kafka.tls.certFile":                      "",
+	"kafka.tls.keyFile":                       "",
+	"kafka.tls.insecureSkipVerify":            false,
+	"kafka.sasl.mechanism":                    "",
+	"kafka.sasl.username":                     "",
+	"kafka.sasl.password":                     "",
+	"kafka.sasl.passwordFile":                 "",
+	"kafka.producer.acks":                     "all",
+	"kafka.producer.idempotent":               false,
+	"kafka.producer.retries":                  3,
+	"kafka.consumer.rebalance":                "range",
+	"kafka.consumer.initialOffset":            "newest",
+	"kafka.topics.provision":                  false,
+	"kafka.topics.defaults.partitions":        1,
+	"kafka.topics.defaults.replicationFactor": 1,
+	"kafka.topics.defaults.retention":         "-1ms",
+	"kafka.topics.defaults.cleanupPolicy":     "delete",
+}
+
+var acks = map[string]sarama.RequiredAcks{
+	"all":    sarama.WaitForAll,
+	"leader": sarama.WaitForLocal,
+	"none":   sarama.NoResponse,
+}
+
+var rebalanceStrategies = map[string]sarama.BalanceStrategy{
+	"range":      sarama.BalanceStrategyRange,
+	"roundrobin": sarama.BalanceStrategyRoundRobin,
+	"sticky":     sarama.BalanceStrategySticky,
+}
+
+var initialOffsets = map[string]int64{
+	"oldest": sarama.OffsetOldest,
+	"newest": sarama.OffsetNewest,
+}
+
+// ResolveSecrets reads the SASL password from PasswordFile when it is set.
+func (obj *Config) ResolveSecrets() error {
+	password, err := config.Secret(obj.SASL.Password, obj.SASL.PasswordFile)
+	if err != nil {
+		return err
+	}
+	obj.SASL.Password = password
+	return nil
+}
+
+// Validate records every invalid kafka field in errs.
+func (obj Config) Validate(errs *config.ValidationError) {
+	errs.Check(len(obj.Servers) > 0, "kafka.servers", "needs at least one broker")
+	if obj.Version != "" {
+		_, err := sarama.ParseKafkaVersion(obj.Version)
+		errs.Check(err == nil, "kafka.version", fmt.Sprintf("%q is not a kafka version", obj.Version))
+	}
+	if obj.TLS.Enabled {
+		errs.Check((obj.TLS.CertFile == "") == (obj.TLS.KeyFile == ""), "kafka.tls", "certFile and keyFile must be set together")
+	}
+	if obj.SASL.Mechanism != "" {
+		errs.OneOf("kafka.sasl.mechanism", obj.SASL.Mechanism, sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512)
+		errs.Check(obj.SASL.Username != "", "kafka.sasl.username", "is required with a sasl mechanism")
+	}
+	errs.OneOf("kafka.producer.acks", obj.Producer.Acks, keys(acks)...)
+	errs.Check(obj.Producer.Retries >= 0, "kafka.producer.retries", "must not be negative")
+	if obj.Producer.Idempotent {
+		errs.Check(obj.Producer.Acks == "all", "kafka.producer.acks", "must be all for an idempotent producer")
+		errs.Check(obj.Producer.Retries > 0, "kafka.producer.retries", "must be positive for an idempotent producer")
+	}
+	errs.OneOf("kafka.consumer.rebalance", obj.Consumer.Rebalance, keys(rebalanceStrategies)...)
+	errs.OneOf("kafka.consumer.initialOffset", obj.Consumer.InitialOffset, keys(initialOffsets)...)
+	obj.Topics.Validate(errs)
+}
+
+// Sarama builds the sarama config for both clients. SyncProducer needs
+// successes returned, so they always are.
+func (obj Config) Sarama() (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	if obj.ClientID != "" {
+		saramaConfig.ClientID = obj.ClientID
+	}
+	if obj.Version != "" {
+		version, err := sarama.ParseKafkaVersion(obj.Version)
+		if err != nil {
+			return nil, err
+		}
+		saramaConfig.Version = version
+	}
+
+	if obj.TLS.Enabled {
+		tlsConfig, err := obj.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	if obj.SASL.Mechanism != "" {
+		saramaConfig.Net.SASL.Enable = true
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(obj.SASL.Mechanism)
+		saramaConfig.Net.SASL.User = obj.SASL.Username
+		saramaConfig.Net.SASL.Password = obj.SASL.Password
+		switch obj.SASL.Mechanism {
+		case sarama.SASLTypeSCRAMSHA256:
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{HashGeneratorFcn: sha256} }
+		case sarama.SASLTypeSCRAMSHA512:
+			saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{HashGeneratorFcn: sha512} }
+		}
+	}
+
+	if ack, ok := acks[obj.Producer.Acks]; ok {
+		saramaConfig.Producer.RequiredAcks = ack
+	}
+	saramaConfig.Producer.Retry.Max = obj.Producer.Retries
+	saramaConfig.Producer.Return.Successes = true
+	if obj.Producer.Idempotent {
+		saramaConfig.Producer.Idempotent = true
+		saramaConfig.Net.MaxOpenRequests = 1
+	}
+
+	if strategy, ok := rebalanceStrategies[obj.Consumer.Rebalance]; ok {
+		saramaConfig.Consumer.Group.Rebalance.Strategy = strategy
+	}
+	if offset, ok := initialOffsets[obj.Consumer.InitialOffset]; ok {
+		saramaConfig.Consumer.Offsets.Initial = offset
+	}
+
+	return saramaConfig, saramaConfig.Validate()
+}
+
+func (obj Config) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: obj.TLS.InsecureSkipVerify}
+
+	if obj.TLS.CAFile != "" {
+		ca, err := os.ReadFile(obj.TLS.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %v", obj.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if obj.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(obj.TLS.CertFile, obj.TLS.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func keys[V any](values map[string]V) []string {
+	result := make([]string, 0, len(values))
+	for key := range values {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
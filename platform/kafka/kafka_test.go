@@ -0,0 +1,227 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"platform/config"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// defaultConfig loads Defaults the way the services do.
+func defaultConfig(t *testing.T) Config {
+	t.Helper()
+	out := struct{ Kafka Config }{}
+	err := config.Load(config.Options{Dir: t.TempDir(), Defaults: Defaults}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Kafka
+}
+
+func Test_Config_Sarama(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	tests := []struct {
+		name       string
+		mockConfig func(config *Config)
+
+		wantError  bool
+		wantConfig func(t *testing.T, saramaConfig *sarama.Config)
+	}{
+		{
+			name: "Test should build config from defaults",
+			wantConfig: func(t *testing.T, saramaConfig *sarama.Config) {
+				if saramaConfig.Producer.RequiredAcks != sarama.WaitForAll || !saramaConfig.Producer.Return.Successes {
+					t.Errorf("want acks from all replicas and successes returned, got %+v", saramaConfig.Producer)
+				}
+				if saramaConfig.Consumer.Group.Rebalance.Strategy != sarama.BalanceStrategyRange || saramaConfig.Consumer.Offsets.Initial != sarama.OffsetNewest {
+					t.Errorf("want range strategy from newest offset, got %+v", saramaConfig.Consumer)
+				}
+				if saramaConfig.Net.TLS.Enable || saramaConfig.Net.SASL.Enable {
+					t.Error("want tls and sasl disabled")
+				}
+			},
+		},
+		{
+			name: "Test should set client id, version and idempotent producer",
+			mockConfig: func(config *Config) {
+				config.ClientID = "producer"
+				config.Version = "2.8.0"
+				config.Producer.Idempotent = true
+				config.Consumer.Rebalance = "sticky"
+				config.Consumer.InitialOffset = "oldest"
+			},
+			wantConfig: func(t *testing.T, saramaConfig *sarama.Config) {
+				if saramaConfig.ClientID != "producer" || saramaConfig.Version != sarama.V2_8_0_0 {
+					t.Errorf("want client id and version, got %v %v", saramaConfig.ClientID, saramaConfig.Version)
+				}
+				if !saramaConfig.Producer.Idempotent || saramaConfig.Net.MaxOpenRequests != 1 {
+					t.Error("want idempotent producer with one open request")
+				}
+				if saramaConfig.Consumer.Group.Rebalance.Strategy != sarama.BalanceStrategySticky || saramaConfig.Consumer.Offsets.Initial != sarama.OffsetOldest {
+					t.Errorf("want sticky strategy from oldest offset, got %+v", saramaConfig.Consumer)
+				}
+			},
+		},
+		{
+			name: "Test should set scram sasl",
+			mockConfig: func(config *Config) {
+				config.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+				config.SASL.Username = "user"
+				config.SASL.Password = "password"
+			},
+			wantConfig: func(t *testing.T, saramaConfig *sarama.Config) {
+				if !saramaConfig.Net.SASL.Enable || saramaConfig.Net.SASL.Mechanism != sarama.SASLTypeSCRAMSHA512 {
+					t.Fatalf("want scram sasl, got %+v", saramaConfig.Net.SASL)
+				}
+				client := saramaConfig.Net.SASL.SCRAMClientGeneratorFunc()
+				if err := client.Begin("user", "password", ""); err != nil {
+					t.Fatal(err)
+				}
+				first, err := client.Step("")
+				if err != nil || first == "" || client.Done() {
+					t.Errorf("want client first message, got %q %v", first, err)
+				}
+			},
+		},
+		{
+			name: "Test should load ca and client certificate",
+			mockConfig: func(config *Config) {
+				config.TLS.Enabled = true
+				config.TLS.CAFile = certFile
+				config.TLS.CertFile = certFile
+				config.TLS.KeyFile = keyFile
+			},
+			wantConfig: func(t *testing.T, saramaConfig *sarama.Config) {
+				if !saramaConfig.Net.TLS.Enable || saramaConfig.Net.TLS.Config.RootCAs == nil || len(saramaConfig.Net.TLS.Config.Certificates) != 1 {
+					t.Errorf("want tls with ca and client certificate, got %+v", saramaConfig.Net.TLS)
+				}
+			},
+		},
+		{
+			name: "Test should return error for missing ca file",
+			mockConfig: func(config *Config) {
+				config.TLS.Enabled = true
+				config.TLS.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+			},
+			wantError: true,
+		},
+		{
+			name: "Test should return error when sarama rejects idempotence",
+			mockConfig: func(config *Config) {
+				config.Version = "0.10.2.0"
+				config.Producer.Idempotent = true
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := defaultConfig(t)
+			if test.mockConfig != nil {
+				test.mockConfig(&config)
+			}
+
+			saramaConfig, err := config.Sarama()
+			if (err != nil) != test.wantError {
+				t.Fatalf("want error %v, got %v", test.wantError, err)
+			}
+			if test.wantConfig != nil {
+				test.wantConfig(t, saramaConfig)
+			}
+		})
+	}
+}
+
+func Test_Config_Validate(t *testing.T) {
+	kafkaConfig := defaultConfig(t)
+	kafkaConfig.Servers = nil
+	kafkaConfig.Version = "latest"
+	kafkaConfig.SASL.Mechanism = "GSSAPI"
+	kafkaConfig.Producer.Acks = "leader"
+	kafkaConfig.Producer.Idempotent = true
+	kafkaConfig.Consumer.InitialOffset = "earliest"
+	kafkaConfig.Topics.Defaults.Partitions = 0
+	kafkaConfig.Topics.Overrides = []TopicConfig{{CleanupPolicy: "forever"}}
+
+	errs := config.ValidationError{}
+	kafkaConfig.Validate(&errs)
+
+	want := config.ValidationError{
+		"kafka.servers: needs at least one broker",
+		`kafka.version: "latest" is not a kafka version`,
+		`kafka.sasl.mechanism: "GSSAPI" is not one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512`,
+		"kafka.sasl.username: is required with a sasl mechanism",
+		"kafka.producer.acks: must be all for an idempotent producer",
+		`kafka.consumer.initialOffset: "earliest" is not one of newest, oldest`,
+		"kafka.topics.defaults.partitions: must be positive",
+		"kafka.topics.overrides[0].name: is required",
+		`kafka.topics.overrides[0].cleanupPolicy: "forever" is not one of delete, compact, compact,delete`,
+	}
+	if errs.Error() != want.Error() {
+		t.Errorf("want %v, got %v", want, errs)
+	}
+}
+
+func Test_Config_ResolveSecrets(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kafkaConfig := Config{}
+	kafkaConfig.SASL.PasswordFile = passwordFile
+	if err := kafkaConfig.ResolveSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if kafkaConfig.SASL.Password != "s3cret" {
+		t.Errorf("want password from file, got %q", kafkaConfig.SASL.Password)
+	}
+}
+
+// writeCertificate writes a self-signed certificate usable both as CA and
+// client certificate, and its key.
+func writeCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Shopify/sarama"
+)
+
+// Statuses of a TopicChange.
+const (
+	ChangeApplied = "applied"
+	// ChangePending is drift that provisioning fixes, found while checking.
+	ChangePending = "pending"
+	// ChangeManual is drift provisioning cannot fix: partitions cannot be
+	// removed, and a replication factor changes with a reassignment.
+	ChangeManual = "manual"
+)
+
+// TopicChange is a difference between a declared topic and the cluster.
+type TopicChange struct {
+	Topic string `json:"topic"`
+	// Setting is topic for a missing topic, partitions, replicationFactor,
+	// or a topic config such as retention.ms.
+	Setting string `json:"setting"`
+	Have    string `json:"have"`
+	Want    string `json:"want"`
+	Status  string `json:"status"`
+}
+
+// ProvisionTopics compares the declared topics with the cluster. With apply
+// it creates missing topics, adds partitions and alters configs, otherwise
+// it only reports the drift. A topic config left at the broker default
+// counts as drift, so the declared value is set explicitly.
+func ProvisionTopics(admin sarama.ClusterAdmin, topics []TopicConfig, apply bool) ([]TopicChange, error) {
+	existing, err := admin.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	status := ChangePending
+	if apply {
+		status = ChangeApplied
+	}
+	changes := []TopicChange{}
+	for _, topic := range topics {
+		entries := topic.entries()
+		detail, ok := existing[topic.Name]
+		if !ok {
+			if apply {
+				err = admin.CreateTopic(topic.Name, &sarama.TopicDetail{
+					NumPartitions:     topic.Partitions,
+					ReplicationFactor: topic.ReplicationFactor,
+					ConfigEntries:     pointers(entries),
+				}, false)
+				if err != nil {
+					return changes, fmt.Errorf("create topic %v: %w", topic.Name, err)
+				}
+			}
+			changes = append(changes, TopicChange{topic.Name, "topic", "missing", "present", status})
+			continue
+		}
+
+		if detail.NumPartitions != topic.Partitions {
+			change := TopicChange{topic.Name, "partitions", fmt.Sprint(detail.NumPartitions), fmt.Sprint(topic.Partitions), ChangeManual}
+			if detail.NumPartitions < topic.Partitions {
+				change.Status = status
+				if apply {
+					err = admin.CreatePartitions(topic.Name, topic.Partitions, nil, false)
+					if err != nil {
+						return changes, fmt.Errorf("add partitions to %v: %w", topic.Name, err)
+					}
+				}
+			}
+			changes = append(changes, change)
+		}
+		if detail.ReplicationFactor != topic.ReplicationFactor {
+			changes = append(changes, TopicChange{topic.Name, "replicationFactor", fmt.Sprint(detail.ReplicationFactor), fmt.Sprint(topic.ReplicationFactor), ChangeManual})
+		}
+
+		// altering replaces every config of the topic, so the ones not
+		// declared are sent back unchanged
+		altered := map[string]string{}
+		for name, value := range detail.ConfigEntries {
+			if value != nil {
+				altered[name] = *value
+			}
+		}
+		drifted := false
+		for _, name := range keys(entries) {
+			have, ok := altered[name]
+			if !ok {
+				have = "default"
+			}
+			if have == entries[name] {
+				continue
+			}
+			changes = append(changes, TopicChange{topic.Name, name, have, entries[name], status})
+			altered[name] = entries[name]
+			drifted = true
+		}
+		if drifted && apply {
+			err = admin.AlterConfig(sarama.TopicResource, topic.Name, pointers(altered), false)
+			if err != nil {
+				return changes, fmt.Errorf("alter config of %v: %w", topic.Name, err)
+			}
+		}
+	}
+	return changes, nil
+}
+
+func pointers(values map[string]string) map[string]*string {
+	result := map[string]*string{}
+	for key, value := range values {
+		value := value
+		result[key] = &value
+	}
+	return result
+}
+
+const provisionUsage = "usage: provision [-check]"
+
+// ProvisionCommand runs the provision subcommand, writing the changes as a
+// table to w. With -check it changes nothing. It fails while any drift is
+// left, so a scheduled check can alert on its exit status.
+func ProvisionCommand(admin sarama.ClusterAdmin, topics []TopicConfig, args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("provision", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	check := flags.Bool("check", false, "report drift without changing the topics")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 {
+		return errors.New(provisionUsage)
+	}
+
+	changes, err := ProvisionTopics(admin, topics, !*check)
+	if len(changes) == 0 && err == nil {
+		fmt.Fprintln(w, "topics up to date")
+		return nil
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TOPIC\tSETTING\tHAVE\tWANT\tSTATUS")
+	left := 0
+	for _, change := range changes {
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\n", change.Topic, change.Setting, change.Have, change.Want, change.Status)
+		if change.Status != ChangeApplied {
+			left++
+		}
+	}
+	table.Flush()
+	if err != nil {
+		return err
+	}
+	if left > 0 {
+		return fmt.Errorf("%d topic changes left", left)
+	}
+	return nil
+}
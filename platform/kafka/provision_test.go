@@ -0,0 +1,195 @@
+package kafka
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// newTopicsBroker serves OpenAccountEvent with one partition, a retention
+// of seven days and max.message.bytes raised, and DepositFundEvent with
+// three partitions and every declared config set.
+func newTopicsBroker(t *testing.T) (*sarama.MockBroker, sarama.ClusterAdmin) {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("OpenAccountEvent", 0, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 0, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 1, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 2, broker.BrokerID()),
+		"DescribeConfigsRequest": sarama.NewMockWrapper(&sarama.DescribeConfigsResponse{
+			Resources: []*sarama.ResourceResponse{
+				{Type: sarama.TopicResource, Name: "OpenAccountEvent", Configs: []*sarama.ConfigEntry{
+					{Name: "retention.ms", Value: "604800000"},
+					{Name: "cleanup.policy", Value: "delete", Default: true},
+					{Name: "max.message.bytes", Value: "2000000"},
+				}},
+				{Type: sarama.TopicResource, Name: "DepositFundEvent", Configs: []*sarama.ConfigEntry{
+					{Name: "retention.ms", Value: "604800000"},
+					{Name: "cleanup.policy", Value: "delete"},
+				}},
+			},
+		}),
+		"CreateTopicsRequest":     sarama.NewMockCreateTopicsResponse(t),
+		"CreatePartitionsRequest": sarama.NewMockCreatePartitionsResponse(t),
+		"AlterConfigsRequest":     sarama.NewMockAlterConfigsResponse(t),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V1_0_0_0
+	admin, err := sarama.NewClusterAdmin([]string{broker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	return broker, admin
+}
+
+var declaredTopics = TopicsConfig{
+	Defaults: TopicConfig{Partitions: 2, ReplicationFactor: 1, Retention: 168 * time.Hour, CleanupPolicy: "delete"},
+	Overrides: []TopicConfig{
+		{Name: "DepositFundEvent", Partitions: 1},
+		{Name: "AccountState", Partitions: 1, Retention: -time.Millisecond, CleanupPolicy: "compact"},
+	},
+}.Resolve([]string{"OpenAccountEvent", "DepositFundEvent", "WithdrawFundEvent"})
+
+func Test_ProvisionTopics(t *testing.T) {
+	t.Run("Test should create missing topics, add partitions and alter configs", func(t *testing.T) {
+		broker, admin := newTopicsBroker(t)
+
+		changes, err := ProvisionTopics(admin, declaredTopics, true)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []TopicChange{
+			{"OpenAccountEvent", "partitions", "1", "2", ChangeApplied},
+			{"OpenAccountEvent", "cleanup.policy", "default", "delete", ChangeApplied},
+			{"DepositFundEvent", "partitions", "3", "1", ChangeManual},
+			{"WithdrawFundEvent", "topic", "missing", "present", ChangeApplied},
+			{"AccountState", "topic", "missing", "present", ChangeApplied},
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("want %+v, got %+v", want, changes)
+		}
+
+		created := map[string]sarama.TopicDetail{}
+		partitions := map[string]int32{}
+		altered := map[string]map[string]string{}
+		for _, exchange := range broker.History() {
+			switch request := exchange.Request.(type) {
+			case *sarama.CreateTopicsRequest:
+				for name, detail := range request.TopicDetails {
+					created[name] = *detail
+				}
+			case *sarama.CreatePartitionsRequest:
+				for name, partition := range request.TopicPartitions {
+					partitions[name] = partition.Count
+				}
+			case *sarama.AlterConfigsRequest:
+				for _, resource := range request.Resources {
+					altered[resource.Name] = values(resource.ConfigEntries)
+				}
+			}
+		}
+
+		if detail := created["AccountState"]; detail.NumPartitions != 1 || detail.ReplicationFactor != 1 ||
+			!reflect.DeepEqual(values(detail.ConfigEntries), map[string]string{"retention.ms": "-1", "cleanup.policy": "compact"}) {
+			t.Errorf("want compacted AccountState kept forever, got %+v", detail)
+		}
+		if detail := created["WithdrawFundEvent"]; detail.NumPartitions != 2 || values(detail.ConfigEntries)["retention.ms"] != "604800000" {
+			t.Errorf("want WithdrawFundEvent with the defaults, got %+v", detail)
+		}
+		if !reflect.DeepEqual(partitions, map[string]int32{"OpenAccountEvent": 2}) {
+			t.Errorf("want partitions added to OpenAccountEvent only, got %v", partitions)
+		}
+		// configs that are not declared are kept
+		wantAltered := map[string]map[string]string{
+			"OpenAccountEvent": {"retention.ms": "604800000", "cleanup.policy": "delete", "max.message.bytes": "2000000"},
+		}
+		if !reflect.DeepEqual(altered, wantAltered) {
+			t.Errorf("want %v altered, got %v", wantAltered, altered)
+		}
+	})
+
+	t.Run("Test should only report drift when not applying", func(t *testing.T) {
+		broker, admin := newTopicsBroker(t)
+
+		changes, err := ProvisionTopics(admin, declaredTopics, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 5 || changes[0].Status != ChangePending || changes[2].Status != ChangeManual {
+			t.Errorf("want pending and manual changes, got %+v", changes)
+		}
+		for _, exchange := range broker.History() {
+			switch exchange.Request.(type) {
+			case *sarama.CreateTopicsRequest, *sarama.CreatePartitionsRequest, *sarama.AlterConfigsRequest:
+				t.Errorf("want no change, got %T", exchange.Request)
+			}
+		}
+	})
+}
+
+func Test_ProvisionCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockArgs []string
+		topics   []TopicConfig
+
+		wantOutput string
+		wantError  string
+	}{
+		{
+			name:     "Test should report drift and fail when checking",
+			mockArgs: []string{"-check"},
+			topics:   declaredTopics[:2],
+			wantOutput: "TOPIC             SETTING         HAVE     WANT    STATUS\n" +
+				"OpenAccountEvent  partitions      1        2       pending\n" +
+				"OpenAccountEvent  cleanup.policy  default  delete  pending\n" +
+				"DepositFundEvent  partitions      3        1       manual\n",
+			wantError: "3 topic changes left",
+		},
+		{
+			name:       "Test should say when topics are up to date",
+			topics:     []TopicConfig{{Name: "DepositFundEvent", Partitions: 3, ReplicationFactor: 1, Retention: 168 * time.Hour, CleanupPolicy: "delete"}},
+			wantOutput: "topics up to date\n",
+		},
+		{
+			name:      "Test should return usage when args are unknown",
+			mockArgs:  []string{"now"},
+			wantError: provisionUsage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, admin := newTopicsBroker(t)
+			w := &bytes.Buffer{}
+
+			err := ProvisionCommand(admin, test.topics, test.mockArgs, w)
+
+			if test.wantError == "" && err != nil || test.wantError != "" && (err == nil || err.Error() != test.wantError) {
+				t.Fatalf("want error %q, got %v", test.wantError, err)
+			}
+			if w.String() != test.wantOutput {
+				t.Errorf("want output\n%v\ngot\n%v", test.wantOutput, w.String())
+			}
+		})
+	}
+}
+
+func values(entries map[string]*string) map[string]string {
+	result := map[string]string{}
+	for key, value := range entries {
+		result[key] = *value
+	}
+	return result
+}
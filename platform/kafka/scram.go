@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	cryptosha256 "crypto/sha256"
+	cryptosha512 "crypto/sha512"
+
+	"github.com/xdg-go/scram"
+)
+
+var (
+	sha256 scram.HashGeneratorFcn = cryptosha256.New
+	sha512 scram.HashGeneratorFcn = cryptosha512.New
+)
+
+// scramClient adapts xdg-go/scram to sarama.SCRAMClient.
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func (obj *scramClient) Begin(userName, password, authzID string) error {
+	client, err := obj.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	obj.Client = client
+	obj.ClientConversation = client.NewConversation()
+	return nil
+}
+
+func (obj *scramClient) Step(challenge string) (string, error) {
+	return obj.ClientConversation.Step(challenge)
+}
+
+func (obj *scramClient) Done() bool {
+	return obj.ClientConversation.Done()
+}
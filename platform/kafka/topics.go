@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"fmt"
+	"platform/config"
+	"strconv"
+	"time"
+)
+
+// TopicConfig is the declared state of a topic. Zero fields of an override
+// take the value of the defaults.
+type TopicConfig struct {
+	Name              string
+	Partitions        int32
+	ReplicationFactor int16 `mapstructure:"replicationFactor"`
+	// Retention is how long messages are kept; a negative one, e.g. -1ms,
+	// keeps them forever.
+	Retention time.Duration
+	// CleanupPolicy is delete, compact or compact,delete.
+	CleanupPolicy string `mapstructure:"cleanupPolicy"`
+}
+
+// TopicsConfig declares the topics a service provisions, read from the
+// kafka.topics key.
+type TopicsConfig struct {
+	// Provision ensures the topics when the service starts.
+	Provision bool
+	// Defaults apply to every topic.
+	Defaults TopicConfig
+	// Overrides change the defaults by topic name, and declare topics
+	// beyond the event topics, such as a compacted state topic. It is a
+	// list because config keys are case-insensitive and topic names are not.
+	Overrides []TopicConfig
+}
+
+var cleanupPolicies = []string{"delete", "compact", "compact,delete"}
+
+// Resolve returns the declared topics: names, then the topics only the
+// overrides name, each with its overrides applied to the defaults.
+func (obj TopicsConfig) Resolve(names []string) []TopicConfig {
+	overrides := map[string]TopicConfig{}
+	for _, override := range obj.Overrides {
+		overrides[override.Name] = override
+	}
+
+	topics := []TopicConfig{}
+	declared := map[string]bool{}
+	for _, name := range names {
+		topics = append(topics, obj.resolve(name, overrides[name]))
+		declared[name] = true
+	}
+	for _, override := range obj.Overrides {
+		if !declared[override.Name] {
+			topics = append(topics, obj.resolve(override.Name, override))
+			declared[override.Name] = true
+		}
+	}
+	return topics
+}
+
+func (obj TopicsConfig) resolve(name string, override TopicConfig) TopicConfig {
+	topic := obj.Defaults
+	topic.Name = name
+	if override.Partitions != 0 {
+		topic.Partitions = override.Partitions
+	}
+	if override.ReplicationFactor != 0 {
+		topic.ReplicationFactor = override.ReplicationFactor
+	}
+	if override.Retention != 0 {
+		topic.Retention = override.Retention
+	}
+	if override.CleanupPolicy != "" {
+		topic.CleanupPolicy = override.CleanupPolicy
+	}
+	return topic
+}
+
+// Validate records every invalid kafka.topics field in errs.
+func (obj TopicsConfig) Validate(errs *config.ValidationError) {
+	errs.Check(obj.Defaults.Partitions > 0, "kafka.topics.defaults.partitions", "must be positive")
+	errs.Check(obj.Defaults.ReplicationFactor > 0, "kafka.topics.defaults.replicationFactor", "must be positive")
+	errs.Check(obj.Defaults.Retention != 0, "kafka.topics.defaults.retention", "must not be zero")
+	errs.OneOf("kafka.topics.defaults.cleanupPolicy", obj.Defaults.CleanupPolicy, cleanupPolicies...)
+	for i, override := range obj.Overrides {
+		field := fmt.Sprintf("kafka.topics.overrides[%v]", i)
+		errs.Check(override.Name != "", field+".name", "is required")
+		errs.Check(override.Partitions >= 0, field+".partitions", "must not be negative")
+		errs.Check(override.ReplicationFactor >= 0, field+".replicationFactor", "must not be negative")
+		if override.CleanupPolicy != "" {
+			errs.OneOf(field+".cleanupPolicy", override.CleanupPolicy, cleanupPolicies...)
+		}
+	}
+}
+
+// entries are the topic configs provisioning sets, as Kafka names them.
+func (obj TopicConfig) entries() map[string]string {
+	retention := "-1"
+	if obj.Retention > 0 {
+		retention = strconv.FormatInt(obj.Retention.Milliseconds(), 10)
+	}
+	return map[string]string{
+		"retention.ms":   retention,
+		"cleanup.policy": obj.CleanupPolicy,
+	}
+}
@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Config struct {
+	// Level is "debug", "info", "warn" or "error"; empty means info.
+	Level string
+	// Format is "json" or "text"; empty means json.
+	Format string
+}
+
+// Redacted replaces the value of every field named in RedactedFields.
+const Redacted = "[REDACTED]"
+
+// RedactedFields names the attributes, and the fields of logged structs and
+// maps, that hold personal data. Matching ignores case.
+var RedactedFields = []string{"AccountHolder"}
+
+// New builds a logger writing to w that redacts RedactedFields and adds the
+// fields stored with ContextWith, and the trace and span IDs, to every
+// record logged with a context.
+func New(w io.Writer, config Config) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if config.Level != "" {
+		err := level.UnmarshalText([]byte(config.Level))
+		if err != nil {
+			return nil, fmt.Errorf("unknown log level %q", config.Level)
+		}
+	}
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+
+	var handler slog.Handler
+	switch config.Format {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Discard returns a logger that drops every record, for tests.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+type contextKey struct{}
+
+// ContextWith returns a copy of ctx carrying args, as key-value pairs or
+// slog.Attr like Logger.With, which loggers from New add to every record
+// logged with the context.
+func ContextWith(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	record := slog.Record{}
+	record.Add(args...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs[:len(attrs):len(attrs)], attr)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (obj contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return obj.Handler.Handle(ctx, record)
+}
+
+func (obj contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{obj.Handler.WithAttrs(attrs)}
+}
+
+func (obj contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{obj.Handler.WithGroup(name)}
+}
+
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if isRedacted(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	if attr.Value.Kind() != slog.KindAny {
+		return attr
+	}
+
+	value := attr.Value.Any()
+	if _, ok := value.(error); ok {
+		return attr
+	}
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return attr
+	}
+
+	// structs, maps and slices are logged as their JSON form with the personal
+	// fields blanked, so events can be logged whole
+	data, err := json.Marshal(value)
+	if err != nil {
+		return attr
+	}
+	var fields interface{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return attr
+	}
+	return slog.Any(attr.Key, redactValue(fields))
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if isRedacted(key) {
+				value[key] = Redacted
+			} else {
+				value[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+func isRedacted(key string) bool {
+	for _, field := range RedactedFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
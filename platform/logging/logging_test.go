@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type openAccountEvent struct {
+	ID            string
+	AccountHolder string
+}
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockConfig Config
+		wantError  bool
+	}{
+		{
+			name: "Test should default to json at info level",
+		},
+		{
+			name:       "Test should accept text at debug level",
+			mockConfig: Config{Level: "debug", Format: "text"},
+		},
+		{
+			name:       "Test should return error for unknown level",
+			mockConfig: Config{Level: "verbose"},
+			wantError:  true,
+		},
+		{
+			name:       "Test should return error for unknown format",
+			mockConfig: Config{Format: "xml"},
+			wantError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(&bytes.Buffer{}, test.mockConfig)
+			if (err != nil) != test.wantError {
+				t.Fatalf("want error %v, got %v", test.wantError, err)
+			}
+		})
+	}
+}
+
+func Test_logger(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger, err := New(output, Config{Level: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWith(context.Background(), "request_id", "request")
+	ctx = ContextWith(ctx, "topic", "OpenAccountEvent")
+	logger.InfoContext(ctx, "dropped below level")
+	logger.WarnContext(ctx, "event handled",
+		"AccountHolder", "John Doe",
+		"event", openAccountEvent{ID: "123", AccountHolder: "John Doe"},
+		"events", []openAccountEvent{{ID: "456", AccountHolder: "Jane Doe"}},
+		"error", errors.New("error"),
+	)
+
+	lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("want 1 record, got %d: %s", len(lines), output)
+	}
+	record := map[string]interface{}{}
+	err = json.Unmarshal(lines[0], &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"msg":           "event handled",
+		"request_id":    "request",
+		"topic":         "OpenAccountEvent",
+		"AccountHolder": Redacted,
+		"error":         "error",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("want %v=%v, got %v", key, value, record[key])
+		}
+	}
+	if bytes.Contains(lines[0], []byte("Doe")) {
+		t.Errorf("want account holders redacted, got %s", lines[0])
+	}
+	if record["event"].(map[string]interface{})["ID"] != "123" {
+		t.Errorf("want event logged as json, got %v", record["event"])
+	}
+}
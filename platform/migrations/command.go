@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+const usage = "usage: migrate up | down [steps] | status"
+
+// Command runs the migrate subcommand with args, e.g. ["down", "2"], and
+// writes what it did to w.
+func (obj *Migrator) Command(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := obj.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(w, "applied %04d_%v\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(w, "no pending migrations")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+		reverted, err := obj.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(w, "reverted %04d_%v\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := obj.Status(ctx)
+		if err != nil {
+			return err
+		}
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt
+			}
+			fmt.Fprintf(table, "%04d\t%v\t%v\n", status.Version, status.Name, appliedAt)
+		}
+		return table.Flush()
+	}
+	return errors.New(usage)
+}
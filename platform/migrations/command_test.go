@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func Test_Migrator_Command(t *testing.T) {
+	migrator, err := New(openSQLiteDB(t), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		mockArgs []string
+
+		wantOutput string
+		wantError  string
+	}{
+		{
+			name:       "Test should list pending migrations",
+			mockArgs:   []string{"status"},
+			wantOutput: "VERSION  NAME           APPLIED AT\n0001     create_things  pending\n0002     add_name       pending\n",
+		},
+		{
+			name:       "Test should apply pending migrations",
+			mockArgs:   []string{"up"},
+			wantOutput: "applied 0001_create_things\napplied 0002_add_name\n",
+		},
+		{
+			name:       "Test should report nothing to apply",
+			mockArgs:   []string{"up"},
+			wantOutput: "no pending migrations\n",
+		},
+		{
+			name:       "Test should revert one migration by default",
+			mockArgs:   []string{"down"},
+			wantOutput: "reverted 0002_add_name\n",
+		},
+		{
+			name:      "Test should reject invalid steps",
+			mockArgs:  []string{"down", "all"},
+			wantError: `steps must be a positive number, got "all"`,
+		},
+		{
+			name:      "Test should print usage for unknown command",
+			mockArgs:  []string{"redo"},
+			wantError: usage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+
+			err := migrator.Command(context.Background(), test.mockArgs, output)
+
+			if test.wantError != "" {
+				if err == nil || err.Error() != test.wantError {
+					t.Fatalf("got error %v, want %v", err, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != test.wantOutput {
+				t.Errorf("got output %q, want %q", output.String(), test.wantOutput)
+			}
+		})
+	}
+}
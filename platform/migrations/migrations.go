@@ -0,0 +1,324 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTable records the applied migrations unless a Source names
+// another table.
+const DefaultTable = "schema_migrations"
+
+// fileName matches <version>_<name>[.<driver>].<up|down>.sql. A file for
+// the database's driver replaces the file without one.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+?)(?:\.(mysql|postgres|sqlite))?\.(up|down)\.sql$`)
+
+// Source is a service's set of migrations.
+type Source struct {
+	// Files holds the migration files at its root.
+	Files fs.FS
+	// Data fills the text/template placeholders in the files.
+	Data interface{}
+	// Table records the applied migrations, DefaultTable if empty.
+	Table string
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string
+}
+
+// PartialError means a migration failed after some of its statements were
+// committed, so the schema is between two versions and no row records it.
+// Only drivers that commit DDL implicitly, such as MySQL, leave one behind.
+type PartialError struct {
+	Migration Migration
+	Direction string
+	// Committed counts the statements that took effect, of Total.
+	Committed int
+	Total     int
+	Err       error
+}
+
+func (obj *PartialError) Error() string {
+	return fmt.Sprintf("migration %v_%v %v: %v of %v statements were committed before it failed, finish or revert them by hand: %v",
+		obj.Migration.Version, obj.Migration.Name, obj.Direction, obj.Committed, obj.Total, obj.Err)
+}
+
+func (obj *PartialError) Unwrap() error {
+	return obj.Err
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	table      string
+	migrations []Migration
+	// implicitCommits is set for drivers that commit every DDL statement
+	// on its own, whatever the transaction.
+	implicitCommits bool
+}
+
+// New loads the migrations in source for db's driver.
+func New(db *gorm.DB, source Source) (*Migrator, error) {
+	migrations, err := Load(source, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	table := source.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	return &Migrator{db, table, migrations, db.Dialector.Name() == "mysql"}, nil
+}
+
+// Load reads the migrations in source for driver, in version order.
+func Load(source Source, driver string) ([]Migration, error) {
+	entries, err := fs.ReadDir(source.Files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	specific := map[string]bool{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %v is not named <version>_<name>[.<driver>].<up|down>.sql", entry.Name())
+		}
+		fileDriver, direction := match[3], match[4]
+		if fileDriver != "" && fileDriver != driver {
+			continue
+		}
+		key := match[1] + "." + direction
+		if fileDriver == "" && specific[key] {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %v is named both %v and %v", version, migration.Name, match[2])
+		}
+
+		statements, err := render(source, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if direction == "up" {
+			migration.Up = statements
+		} else {
+			migration.Down = statements
+		}
+		if fileDriver != "" {
+			specific[key] = true
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == nil || migration.Down == nil {
+			return nil, fmt.Errorf("migration %v_%v needs an up and a down file for %v", migration.Version, migration.Name, driver)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// render fills source's data into a migration file and splits it into
+// statements, which end with a semicolon at the end of a line.
+func render(source Source, name string) ([]string, error) {
+	text, err := fs.ReadFile(source.Files, name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	buffer := &bytes.Buffer{}
+	err = tmpl.Execute(buffer, source.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	var statement []string
+	for _, line := range strings.Split(buffer.String(), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement = append(statement, line)
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.Join(statement, "\n"), ";"))
+			statement = nil
+		}
+	}
+	if len(statement) > 0 {
+		statements = append(statements, strings.Join(statement, "\n"))
+	}
+	return statements, nil
+}
+
+func (obj *Migrator) ensureTable(ctx context.Context) error {
+	return obj.db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS ` + obj.table + ` (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at VARCHAR(64) NOT NULL
+)`).Error
+}
+
+type appliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt string
+}
+
+func (obj *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	err := obj.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows []appliedMigration
+	err = obj.db.WithContext(ctx).Table(obj.table).Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int]appliedMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists every migration, applied or not, in version order.
+func (obj *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := obj.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []Status
+	for _, migration := range obj.migrations {
+		row, ok := applied[migration.Version]
+		statuses = append(statuses, Status{migration.Version, migration.Name, ok, row.AppliedAt})
+	}
+	return statuses, nil
+}
+
+// Pending lists the migrations Up would apply.
+func (obj *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := obj.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range obj.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and stops at the first that fails. MySQL commits DDL
+// implicitly, so there a migration that fails after its first statement
+// stays partly applied, which is reported as a *PartialError.
+func (obj *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := obj.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range pending {
+		executed := 0
+		err = obj.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			executed, err = exec(tx, migration.Up)
+			if err != nil {
+				return err
+			}
+			return tx.Table(obj.table).Create(&appliedMigration{migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339)}).Error
+		})
+		if err != nil {
+			return done, obj.failed(migration, "up", migration.Up, executed, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and may
+// leave one partly reverted like Up.
+func (obj *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := obj.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(obj.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := obj.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		executed := 0
+		err = obj.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			executed, err = exec(tx, migration.Down)
+			if err != nil {
+				return err
+			}
+			return tx.Table(obj.table).Where("version = ?", migration.Version).Delete(&appliedMigration{}).Error
+		})
+		if err != nil {
+			return done, obj.failed(migration, "down", migration.Down, executed, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// failed wraps the error of a migration whose first executed statements
+// succeeded as a *PartialError where the driver kept them, including when
+// recording the migration failed after all of them.
+func (obj *Migrator) failed(migration Migration, direction string, statements []string, executed int, err error) error {
+	if obj.implicitCommits && executed > 0 {
+		return &PartialError{migration, direction, executed, len(statements), err}
+	}
+	return fmt.Errorf("migration %v_%v %v: %w", migration.Version, migration.Name, direction, err)
+}
+
+// exec runs statements in order and returns how many succeeded.
+func exec(tx *gorm.DB, statements []string) (int, error) {
+	for i, statement := range statements {
+		err := tx.Exec(statement).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(statements), nil
+}
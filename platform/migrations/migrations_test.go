@@ -0,0 +1,150 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var source = Source{
+	Files: fstest.MapFS{
+		"0001_create_things.up.sql":       {Data: []byte("-- things\nCREATE TABLE {{.Table}} (\n\tid TEXT NOT NULL PRIMARY KEY\n);\n")},
+		"0001_create_things.down.sql":     {Data: []byte("DROP TABLE {{.Table}};\n")},
+		"0002_add_name.up.sql":            {Data: []byte("ALTER TABLE {{.Table}} ADD COLUMN name TEXT;\n")},
+		"0002_add_name.sqlite.up.sql":     {Data: []byte("ALTER TABLE {{.Table}} ADD COLUMN name TEXT NOT NULL DEFAULT '';\n")},
+		"0002_add_name.down.sql":          {Data: []byte("ALTER TABLE {{.Table}} DROP COLUMN name;\n")},
+		"0002_add_name.postgres.down.sql": {Data: []byte("ALTER TABLE {{.Table}} DROP COLUMN IF EXISTS name;\n")},
+	},
+	Data:  struct{ Table string }{"things"},
+	Table: "thing_migrations",
+}
+
+func openSQLiteDB(t *testing.T) *gorm.DB {
+	dsn := fmt.Sprintf("file:%v?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func Test_Load(t *testing.T) {
+	tests := []struct {
+		driver string
+
+		wantUp   []string
+		wantDown []string
+	}{
+		{
+			driver:   "mysql",
+			wantUp:   []string{"ALTER TABLE things ADD COLUMN name TEXT"},
+			wantDown: []string{"ALTER TABLE things DROP COLUMN name"},
+		},
+		{
+			driver:   "sqlite",
+			wantUp:   []string{"ALTER TABLE things ADD COLUMN name TEXT NOT NULL DEFAULT ''"},
+			wantDown: []string{"ALTER TABLE things DROP COLUMN name"},
+		},
+		{
+			driver:   "postgres",
+			wantUp:   []string{"ALTER TABLE things ADD COLUMN name TEXT"},
+			wantDown: []string{"ALTER TABLE things DROP COLUMN IF EXISTS name"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("Test should pick "+test.driver+" files over generic ones", func(t *testing.T) {
+			migrations, err := Load(source, test.driver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(migrations) != 2 {
+				t.Fatalf("got %v migrations, want 2", len(migrations))
+			}
+			if want := []string{"CREATE TABLE things (\n\tid TEXT NOT NULL PRIMARY KEY\n)"}; !reflect.DeepEqual(migrations[0].Up, want) {
+				t.Errorf("got up %q, want %q", migrations[0].Up, want)
+			}
+			if !reflect.DeepEqual(migrations[1].Up, test.wantUp) || !reflect.DeepEqual(migrations[1].Down, test.wantDown) {
+				t.Errorf("got %q and %q, want %q and %q", migrations[1].Up, migrations[1].Down, test.wantUp, test.wantDown)
+			}
+		})
+	}
+
+	t.Run("Test should reject a migration without a down file", func(t *testing.T) {
+		_, err := Load(Source{Files: fstest.MapFS{"0001_create_things.up.sql": {Data: []byte("SELECT 1;")}}}, "sqlite")
+
+		if err == nil || err.Error() != "migration 1_create_things needs an up and a down file for sqlite" {
+			t.Errorf("got error %v", err)
+		}
+	})
+}
+
+func Test_Migrator(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLiteDB(t)
+	migrator, err := New(db, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("got %v applied, error %v", len(applied), err)
+	}
+	if !db.Migrator().HasTable("thing_migrations") || db.Migrator().HasTable(DefaultTable) {
+		t.Error("want applied migrations recorded in the source's table")
+	}
+
+	reverted, err := migrator.Down(ctx, 5)
+	if err != nil || len(reverted) != 2 || reverted[0].Version != 2 {
+		t.Fatalf("got %v reverted, error %v", reverted, err)
+	}
+	if db.Migrator().HasTable("things") {
+		t.Error("want table dropped")
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil || len(pending) != 2 {
+		t.Errorf("got %v pending, error %v", len(pending), err)
+	}
+}
+
+func Test_Migrator_Up_Partial(t *testing.T) {
+	partial := Source{
+		Files: fstest.MapFS{
+			"0001_create_pair.up.sql":   {Data: []byte("CREATE TABLE first (id TEXT);\nCREATE TABLE second (id TEXT;\n")},
+			"0001_create_pair.down.sql": {Data: []byte("DROP TABLE second;\nDROP TABLE first;\n")},
+		},
+	}
+
+	for _, implicitCommits := range []bool{false, true} {
+		t.Run(fmt.Sprintf("implicit commits %v", implicitCommits), func(t *testing.T) {
+			migrator, err := New(openSQLiteDB(t), partial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			migrator.implicitCommits = implicitCommits
+
+			_, err = migrator.Up(context.Background())
+
+			var partialErr *PartialError
+			if errors.As(err, &partialErr) != implicitCommits {
+				t.Fatalf("want a partial error %v, got %v", implicitCommits, err)
+			}
+			if implicitCommits && (partialErr.Committed != 1 || partialErr.Total != 2 || partialErr.Direction != "up") {
+				t.Errorf("want 1 of 2 statements committed going up, got %+v", partialErr)
+			}
+			if !strings.HasPrefix(err.Error(), "migration 1_create_pair up: ") {
+				t.Errorf("want the migration named in %q", err)
+			}
+		})
+	}
+}
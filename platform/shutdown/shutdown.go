@@ -0,0 +1,43 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Step releases one resource during shutdown.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Close adapts an io.Closer, e.g. a Kafka client, to a Step.
+func Close(name string, closer io.Closer) Step {
+	return Step{name, func(context.Context) error { return closer.Close() }}
+}
+
+// Run runs steps in order, so resources close after the ones using them,
+// and gives up when ctx is done. It returns every step error, and the name
+// of the step still running when ctx expired.
+func Run(ctx context.Context, steps ...Step) error {
+	var errs []error
+	for _, step := range steps {
+		done := make(chan error, 1)
+		go func(step Step) {
+			done <- step.Run(ctx)
+		}(step)
+
+		select {
+		case err := <-done:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%v: %w", step.Name, err))
+			}
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("%v: %w", step.Name, ctx.Err()))
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type closer struct {
+	err error
+}
+
+func (obj closer) Close() error {
+	return obj.err
+}
+
+func Test_Run(t *testing.T) {
+	var ran []string
+	step := func(name string, err error) Step {
+		return Step{name, func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		mockSteps []Step
+
+		wantRan   []string
+		wantError string
+	}{
+		{
+			name:      "Test should run every step in order",
+			mockSteps: []Step{step("http", nil), step("kafka", nil), Close("db", closer{})},
+			wantRan:   []string{"http", "kafka"},
+		},
+		{
+			name:      "Test should keep going after a step fails and return every error",
+			mockSteps: []Step{step("http", errors.New("busy")), step("kafka", nil), Close("db", closer{errors.New("closed")})},
+			wantRan:   []string{"http", "kafka"},
+			wantError: "http: busy\ndb: closed",
+		},
+		{
+			name: "Test should stop at the step running when the timeout expires",
+			mockSteps: []Step{
+				step("http", nil),
+				{"kafka", func(ctx context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}},
+				step("db", nil),
+			},
+			wantRan:   []string{"http"},
+			wantError: "kafka: context deadline exceeded",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ran = nil
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := Run(ctx, test.mockSteps...)
+
+			if (err == nil && test.wantError != "") || (err != nil && err.Error() != test.wantError) {
+				t.Errorf("want error %q, got %v", test.wantError, err)
+			}
+			if !reflect.DeepEqual(test.wantRan, ran) {
+				t.Errorf("want %v ran, got %v", test.wantRan, ran)
+			}
+		})
+	}
+}
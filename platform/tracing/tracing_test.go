@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_Init(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockExporter string
+		wantError    bool
+	}{
+		{
+			name: "Test should default to recording nothing",
+		},
+		{
+			name:         "Test should accept stdout exporter",
+			mockExporter: "stdout",
+		},
+		{
+			name:         "Test should return error for unknown exporter",
+			mockExporter: "zipkin",
+			wantError:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shutdown, err := Init(context.Background(), Config{ServiceName: "test", Exporter: test.mockExporter})
+
+			if (err != nil) != test.wantError {
+				t.Fatalf("want error %v, got %v", test.wantError, err)
+			}
+			if shutdown != nil {
+				shutdown(context.Background())
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"producer/commands"
+	"strconv"
+	"strings"
+)
+
+// Account is the consumer read model of an account.
+type Account struct {
+	ID            string  `json:"id"`
+	AccountHolder string  `json:"accountHolder"`
+	AccountType   int     `json:"accountType"`
+	Balance       float64 `json:"balance"`
+}
+
+// Result is the producer response to an account command.
+type Result struct {
+	Message string `json:"message"`
+	ID      string `json:"id,omitempty"`
+}
+
+// do sends a request and decodes the JSON response into response, failing
+// with the status and body of anything but a 2xx.
+func (obj cli) do(ctx context.Context, method string, baseURL string, path string, body interface{}, response interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := obj.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%v %v: %v: %v", method, path, res.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, response)
+}
+
+// command posts an account command to the producer and prints its result.
+func (obj cli) command(ctx context.Context, path string, command interface{}) error {
+	result := Result{}
+	err := obj.do(ctx, http.MethodPost, obj.ProducerURL, path, command, &result)
+	if err != nil {
+		return err
+	}
+	return obj.write(result, []string{"MESSAGE", "ID"}, [][]string{{result.Message, result.ID}})
+}
+
+func open(ctx context.Context, obj cli, args []string) error {
+	const usage = "usage: open -holder name [-type n] [-balance amount]"
+	flags := flag.NewFlagSet("open", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	holder := flags.String("holder", "", "account holder")
+	accountType := flags.Int("type", 1, "account type")
+	balance := flags.Float64("balance", 0, "opening balance")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *holder == "" {
+		return errors.New(usage)
+	}
+
+	return obj.command(ctx, "/openAccount", commands.OpenAccountCommand{
+		AccountHolder:  *holder,
+		AccountType:    *accountType,
+		OpeningBalance: *balance,
+	})
+}
+
+// parseAmount reads the -id and -amount flags deposit and withdraw share.
+func parseAmount(name string, args []string) (string, float64, error) {
+	usage := fmt.Sprintf("usage: %v -id account -amount amount", name)
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	id := flags.String("id", "", "account ID")
+	amount := flags.Float64("amount", 0, "amount")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *id == "" || *amount <= 0 {
+		return "", 0, errors.New(usage)
+	}
+	return *id, *amount, nil
+}
+
+func deposit(ctx context.Context, obj cli, args []string) error {
+	id, amount, err := parseAmount("deposit", args)
+	if err != nil {
+		return err
+	}
+	return obj.command(ctx, "/depositFund", commands.DepositFundCommand{ID: id, Amount: amount})
+}
+
+func withdraw(ctx context.Context, obj cli, args []string) error {
+	id, amount, err := parseAmount("withdraw", args)
+	if err != nil {
+		return err
+	}
+	return obj.command(ctx, "/withdrawFund", commands.WithdrawFundCommand{ID: id, Amount: amount})
+}
+
+// parseID reads the -id flag of the commands on a single account.
+func parseID(name string, args []string) (string, error) {
+	usage := fmt.Sprintf("usage: %v -id account", name)
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	id := flags.String("id", "", "account ID")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *id == "" {
+		return "", errors.New(usage)
+	}
+	return *id, nil
+}
+
+func closeAccount(ctx context.Context, obj cli, args []string) error {
+	id, err := parseID("close", args)
+	if err != nil {
+		return err
+	}
+	return obj.command(ctx, "/closeAccount", commands.CloseAccountCommand{ID: id})
+}
+
+func (obj cli) writeAccounts(value interface{}, accounts []Account) error {
+	rows := [][]string{}
+	for _, account := range accounts {
+		rows = append(rows, []string{
+			account.ID,
+			account.AccountHolder,
+			strconv.Itoa(account.AccountType),
+			strconv.FormatFloat(account.Balance, 'f', 2, 64),
+		})
+	}
+	return obj.write(value, []string{"ID", "HOLDER", "TYPE", "BALANCE"}, rows)
+}
+
+func listAccounts(ctx context.Context, obj cli, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: accounts")
+	}
+
+	accounts := []Account{}
+	err := obj.do(ctx, http.MethodGet, obj.ConsumerURL, "/accounts", nil, &accounts)
+	if err != nil {
+		return err
+	}
+	return obj.writeAccounts(accounts, accounts)
+}
+
+func showAccount(ctx context.Context, obj cli, args []string) error {
+	id, err := parseID("account", args)
+	if err != nil {
+		return err
+	}
+
+	account := Account{}
+	err = obj.do(ctx, http.MethodGet, obj.ConsumerURL, "/accounts/"+url.PathEscape(id), nil, &account)
+	if err != nil {
+		return err
+	}
+	return obj.writeAccounts(account, []Account{account})
+}
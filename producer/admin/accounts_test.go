@@ -0,0 +1,228 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"events"
+	"net/http"
+	"net/http/httptest"
+	"platform/logging"
+	"producer/aggregates"
+	accountcontrollers "producer/controllers/account"
+	"producer/eventstore"
+	"producer/replay"
+	accountservice "producer/services/account"
+	outboxservice "producer/services/outbox"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventProducer struct {
+	events []events.Event
+}
+
+func (obj *fakeEventProducer) Produce(ctx context.Context, event events.Event) error {
+	obj.events = append(obj.events, event)
+	return nil
+}
+
+// hostTarget sends requests for http://producer to the in-process producer
+// app and the rest to the consumer handler.
+type hostTarget struct {
+	producer replay.ITarget
+	consumer http.Handler
+}
+
+func (obj hostTarget) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == "producer" {
+		return obj.producer.Do(req)
+	}
+	recorder := httptest.NewRecorder()
+	obj.consumer.ServeHTTP(recorder, req)
+	return recorder.Result(), nil
+}
+
+// fakeConsumer serves the consumer account API from a fixed read model.
+func fakeConsumer(accounts []Account) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/accounts", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(accounts)
+	})
+	mux.HandleFunc("/accounts/", func(w http.ResponseWriter, r *http.Request) {
+		for _, account := range accounts {
+			if "/accounts/"+account.ID == r.URL.Path {
+				json.NewEncoder(w).Encode(account)
+				return
+			}
+		}
+		http.Error(w, "account not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/consumer/failed-messages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"topic":"DepositFundEvent","partition":1,"offset":5,"timestamp":"2024-03-01T00:00:00Z","headers":{},` +
+			`"value":"e30=","error":"record not found","attempts":3,"failedAt":"2024-03-01T09:00:00Z"}]`))
+	})
+	mux.HandleFunc("/consumer/failed-messages/redrive", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method != http.MethodPost || query.Get("topic") != "DepositFundEvent" || query.Get("partition") != "1" || query.Get("offset") != "5" {
+			http.Error(w, "no failed message", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"topic":"DepositFundEvent","partition":1,"offset":5}`))
+	})
+	return mux
+}
+
+func newOptions(eventProducer *fakeEventProducer) Options {
+	store := eventstore.NewMemoryEventStore()
+	accountService := accountservice.NewAccountService(outboxservice.NewRelay(store, eventProducer, 100, logging.Discard()), aggregates.NewAccountRepository(store, 0, logging.Discard()), logging.Discard())
+	app := fiber.New()
+	accountcontrollers.RegisterRoutes(app, accountcontrollers.NewAccountController(accountService, logging.Discard()))
+
+	return Options{
+		ProducerURL: "http://producer",
+		ConsumerURL: "http://consumer",
+		HTTP: hostTarget{replay.NewFiberTarget(app), fakeConsumer([]Account{
+			{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1500},
+			{ID: "456", AccountHolder: "Jane Doe", AccountType: 2, Balance: 20.5},
+		})},
+	}
+}
+
+func Test_Run_Account_Commands(t *testing.T) {
+	eventProducer := &fakeEventProducer{}
+	options := newOptions(eventProducer)
+	run := func(args ...string) (string, error) {
+		w := &bytes.Buffer{}
+		err := Run(context.Background(), options, args, w)
+		return w.String(), err
+	}
+
+	output, err := run("-o", "json", "open", "-holder", "John Doe", "-type", "2", "-balance", "1000")
+	assert.NoError(t, err)
+	result := Result{}
+	assert.NoError(t, json.Unmarshal([]byte(output), &result))
+	assert.Equal(t, "open account success", result.Message)
+	_, err = uuid.Parse(result.ID)
+	assert.NoError(t, err)
+	id := result.ID
+
+	output, err = run("deposit", "-id", id, "-amount", "500")
+	assert.NoError(t, err)
+	assert.Equal(t, "MESSAGE               ID\ndeposit fund success  \n", output)
+
+	_, err = run("withdraw", "-id", id, "-amount", "2000")
+	assert.EqualError(t, err, "POST /withdrawFund: 422 Unprocessable Entity: insufficient funds")
+
+	_, err = run("withdraw", "-id", id, "-amount", "200")
+	assert.NoError(t, err)
+
+	_, err = run("close", "-id", id)
+	assert.NoError(t, err)
+
+	_, err = run("deposit", "-id", id, "-amount", "500")
+	assert.EqualError(t, err, "POST /depositFund: 409 Conflict: account is closed")
+
+	_, err = run("close", "-id", "missing")
+	assert.EqualError(t, err, "POST /closeAccount: 404 Not Found: account not found")
+
+	assert.Equal(t, []events.Event{
+		events.OpenAccountEvent{ID: id, AccountHolder: "John Doe", AccountType: 2, OpeningBalance: 1000},
+		events.DepositFundEvent{ID: id, Amount: 500},
+		events.WithdrawFundEvent{ID: id, Amount: 200},
+		events.CloseAccountEvent{ID: id},
+	}, eventProducer.events)
+}
+
+func Test_Run(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+
+		wantOutput string
+		wantError  string
+	}{
+		{
+			name: "Test should list accounts as a table",
+			args: []string{"accounts"},
+			wantOutput: "ID   HOLDER    TYPE  BALANCE\n" +
+				"123  John Doe  1     1500.00\n" +
+				"456  Jane Doe  2     20.50\n",
+		},
+		{
+			name: "Test should show an account as json",
+			args: []string{"-o", "json", "account", "-id", "456"},
+			wantOutput: "{\n" +
+				"  \"id\": \"456\",\n" +
+				"  \"accountHolder\": \"Jane Doe\",\n" +
+				"  \"accountType\": 2,\n" +
+				"  \"balance\": 20.5\n" +
+				"}\n",
+		},
+		{
+			name:      "Test should return error when account is not found",
+			args:      []string{"account", "-id", "789"},
+			wantError: "GET /accounts/789: 404 Not Found: account not found",
+		},
+		{
+			name: "Test should list the failed messages",
+			args: []string{"failed"},
+			wantOutput: "TOPIC             PARTITION  OFFSET  ATTEMPTS  FAILED                ERROR\n" +
+				"DepositFundEvent  1          5       3         2024-03-01T09:00:00Z  record not found\n",
+		},
+		{
+			name: "Test should redrive a failed message",
+			args: []string{"redrive", "-topic", "DepositFundEvent", "-partition", "1", "-offset", "5"},
+			wantOutput: "TOPIC             PARTITION  OFFSET\n" +
+				"DepositFundEvent  1          5\n",
+		},
+		{
+			name:      "Test should return error when no message failed at the position",
+			args:      []string{"redrive", "-topic", "DepositFundEvent", "-partition", "0", "-offset", "5"},
+			wantError: "POST /consumer/failed-messages/redrive?offset=5&partition=0&topic=DepositFundEvent: 404 Not Found: no failed message",
+		},
+		{
+			name:      "Test should return usage when offset is missing",
+			args:      []string{"redrive", "-topic", "DepositFundEvent"},
+			wantError: "usage: redrive -topic topic -partition n -offset n",
+		},
+		{
+			name:      "Test should return usage when amount is missing",
+			args:      []string{"deposit", "-id", "123"},
+			wantError: "usage: deposit -id account -amount amount",
+		},
+		{
+			name:      "Test should return error when output is unknown",
+			args:      []string{"-o", "yaml", "accounts"},
+			wantError: `unknown output "yaml", want table or json`,
+		},
+		{
+			name:      "Test should return error when command is unknown",
+			args:      []string{"transfer"},
+			wantError: "unknown command \"transfer\"\n" + usage + "account, accounts, close, deposit, failed, lag, open, redrive, tail, withdraw",
+		},
+		{
+			name:      "Test should return error when kafka is not configured",
+			args:      []string{"lag"},
+			wantError: "no kafka connection configured",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+
+			err := Run(context.Background(), newOptions(&fakeEventProducer{}), test.args, w)
+
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantOutput, w.String())
+		})
+	}
+}
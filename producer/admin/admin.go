@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"producer/replay"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Shopify/sarama"
+)
+
+const usage = "usage: admin [-o table|json] [-producer url] [-consumer url] [-group name] <command> [flags]\ncommands: "
+
+// Options are the defaults of the global flags, and the clients the
+// commands use.
+type Options struct {
+	// ProducerURL is the base URL of the producer API, e.g.
+	// http://localhost:8000.
+	ProducerURL string
+	// ConsumerURL is the base URL of the consumer account API, e.g.
+	// http://localhost:9101.
+	ConsumerURL string
+	// Group is the consumer group lag is reported for.
+	Group string
+	// HTTP sends the producer and consumer requests. *http.Client satisfies
+	// it for running services.
+	HTTP replay.ITarget
+	// Kafka connects to the brokers, only for the commands that need them.
+	Kafka func() (sarama.Client, error)
+}
+
+type cli struct {
+	Options
+	output string
+	w      io.Writer
+}
+
+var subcommands = map[string]func(ctx context.Context, obj cli, args []string) error{
+	"open":     open,
+	"deposit":  deposit,
+	"withdraw": withdraw,
+	"close":    closeAccount,
+	"accounts": listAccounts,
+	"account":  showAccount,
+	"tail":     tail,
+	"lag":      lag,
+	"failed":   listFailed,
+	"redrive":  redrive,
+}
+
+func commandNames() []string {
+	names := []string{}
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the command named by the first argument after the global flags,
+// writing its result to w as a table or as JSON.
+func Run(ctx context.Context, options Options, args []string, w io.Writer) error {
+	obj := cli{Options: options, w: w}
+	flags := flag.NewFlagSet("admin", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&obj.output, "o", "table", "output format, table or json")
+	flags.StringVar(&obj.ProducerURL, "producer", options.ProducerURL, "base URL of the producer API")
+	flags.StringVar(&obj.ConsumerURL, "consumer", options.ConsumerURL, "base URL of the consumer API")
+	flags.StringVar(&obj.Group, "group", options.Group, "consumer group to report lag for")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() == 0 {
+		return errors.New(usage + strings.Join(commandNames(), ", "))
+	}
+	if obj.output != "table" && obj.output != "json" {
+		return fmt.Errorf("unknown output %q, want table or json", obj.output)
+	}
+
+	command, ok := subcommands[flags.Arg(0)]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%v", flags.Arg(0), usage+strings.Join(commandNames(), ", "))
+	}
+	return command(ctx, obj, flags.Args()[1:])
+}
+
+// write prints value as indented JSON, or as a table of header and rows.
+func (obj cli) write(value interface{}, header []string, rows [][]string) error {
+	if obj.output == "json" {
+		encoder := json.NewEncoder(obj.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	tw := tabwriter.NewWriter(obj.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// kafka connects only once a command needs the brokers, so the account
+// commands work without them.
+func (obj cli) kafka() (sarama.Client, error) {
+	if obj.Kafka == nil {
+		return nil, errors.New("no kafka connection configured")
+	}
+	return obj.Kafka()
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// FailedMessage is a message the consumer dead lettered after its last
+// attempt, with the error of that attempt.
+type FailedMessage struct {
+	Topic     string            `json:"topic"`
+	Partition int32             `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Value     []byte            `json:"value"`
+	Error     string            `json:"error"`
+	Attempts  int               `json:"attempts"`
+	FailedAt  time.Time         `json:"failedAt"`
+}
+
+// Redriven is the position of a message the consumer handled again.
+type Redriven struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// listFailed lists the messages the consumer dead lettered.
+func listFailed(ctx context.Context, obj cli, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: failed")
+	}
+
+	messages := []FailedMessage{}
+	err := obj.do(ctx, http.MethodGet, obj.ConsumerURL, "/consumer/failed-messages", nil, &messages)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, message := range messages {
+		rows = append(rows, []string{
+			message.Topic,
+			strconv.Itoa(int(message.Partition)),
+			strconv.FormatInt(message.Offset, 10),
+			strconv.Itoa(message.Attempts),
+			message.FailedAt.Format(time.RFC3339),
+			message.Error,
+		})
+	}
+	return obj.write(messages, []string{"TOPIC", "PARTITION", "OFFSET", "ATTEMPTS", "FAILED", "ERROR"}, rows)
+}
+
+// redrive has the consumer handle a message it dead lettered again, at its
+// original position, so it is applied once even if it was applied after
+// all. `failed` lists the positions.
+func redrive(ctx context.Context, obj cli, args []string) error {
+	const usage = "usage: redrive -topic topic -partition n -offset n"
+	flags := flag.NewFlagSet("redrive", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	topic := flags.String("topic", "", "topic of the message")
+	partition := flags.Int("partition", 0, "partition of the message")
+	offset := flags.Int64("offset", -1, "offset of the message")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *topic == "" || *partition < 0 || *offset < 0 {
+		return errors.New(usage)
+	}
+
+	query := url.Values{}
+	query.Set("topic", *topic)
+	query.Set("partition", strconv.Itoa(*partition))
+	query.Set("offset", strconv.FormatInt(*offset, 10))
+	result := Redriven{}
+	err = obj.do(ctx, http.MethodPost, obj.ConsumerURL, "/consumer/failed-messages/redrive?"+query.Encode(), nil, &result)
+	if err != nil {
+		return err
+	}
+	return obj.write(result, []string{"TOPIC", "PARTITION", "OFFSET"}, [][]string{{
+		result.Topic,
+		fmt.Sprint(result.Partition),
+		fmt.Sprint(result.Offset),
+	}})
+}
@@ -0,0 +1,255 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// Message is a consumed message with its payload decoded into the event of
+// its topic. Error replaces Event when the payload does not decode.
+type Message struct {
+	Topic     string            `json:"topic"`
+	Partition int32             `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Key       string            `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Event     events.Event      `json:"event,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// decode reads the payload with the codec its content-type header names,
+// like the consumer does.
+func decode(msg *sarama.ConsumerMessage) Message {
+	message := Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Key:       string(msg.Key),
+		Headers:   map[string]string{},
+	}
+	for _, header := range msg.Headers {
+		message.Headers[string(header.Key)] = string(header.Value)
+	}
+
+	codec, err := events.CodecFor(message.Headers[events.ContentTypeHeader])
+	if err != nil {
+		message.Error = err.Error()
+		return message
+	}
+	event, err := events.New(msg.Topic)
+	if err != nil {
+		message.Error = err.Error()
+		return message
+	}
+	err = codec.Decode(msg.Value, event)
+	if err != nil {
+		message.Error = err.Error()
+		return message
+	}
+	message.Event = event
+	return message
+}
+
+const tailRow = "%-9v  %-8v  %-20v  %v\n"
+
+// tail prints messages as they arrive, one JSON object per line with -o
+// json, until it has printed -n of them or ctx is done.
+func tail(ctx context.Context, obj cli, args []string) error {
+	const usage = "usage: tail -topic topic [-offset oldest|newest] [-n count]"
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	topic := flags.String("topic", "", "topic to tail")
+	from := flags.String("offset", "newest", "oldest to start at the oldest retained message, newest for new ones only")
+	count := flags.Int("n", 0, "messages to print before stopping, 0 to follow until interrupted")
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *topic == "" || *count < 0 {
+		return errors.New(usage)
+	}
+	offsets := map[string]int64{"oldest": sarama.OffsetOldest, "newest": sarama.OffsetNewest}
+	offset, ok := offsets[*from]
+	if !ok {
+		return errors.New(usage)
+	}
+
+	client, err := obj.kafka()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+	partitions, err := client.Partitions(*topic)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	messages := make(chan *sarama.ConsumerMessage)
+	for _, partition := range partitions {
+		partitionConsumer, err := consumer.ConsumePartition(*topic, partition, offset)
+		if err != nil {
+			cancel()
+			return err
+		}
+		defer partitionConsumer.Close()
+		go forward(ctx, partitionConsumer, messages)
+	}
+	// deferred last, so forwarding stops before the partition consumers close
+	defer cancel()
+
+	if obj.output == "table" {
+		fmt.Fprintf(obj.w, tailRow, "PARTITION", "OFFSET", "TIMESTAMP", "EVENT")
+	}
+	encoder := json.NewEncoder(obj.w)
+	for printed := 0; *count == 0 || printed < *count; printed++ {
+		var msg *sarama.ConsumerMessage
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg = <-messages:
+		}
+
+		message := decode(msg)
+		if obj.output == "json" {
+			err = encoder.Encode(message)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		payload := "error: " + message.Error
+		if message.Event != nil {
+			data, err := json.Marshal(message.Event)
+			if err != nil {
+				return err
+			}
+			payload = string(data)
+		}
+		// messages from brokers before 0.10 have no timestamp
+		timestamp := "-"
+		if !message.Timestamp.IsZero() {
+			timestamp = message.Timestamp.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(obj.w, tailRow, message.Partition, message.Offset, timestamp, payload)
+	}
+	return nil
+}
+
+// forward passes the messages of a partition on until ctx is done.
+func forward(ctx context.Context, partitionConsumer sarama.PartitionConsumer, messages chan<- *sarama.ConsumerMessage) {
+	for msg := range partitionConsumer.Messages() {
+		select {
+		case messages <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PartitionLag is how far the consumer group is behind on a partition.
+// Committed is -1 when the group has not committed an offset yet, and then
+// every retained message counts.
+type PartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Committed int64  `json:"committed"`
+	Newest    int64  `json:"newest"`
+	Lag       int64  `json:"lag"`
+}
+
+type LagReport struct {
+	Group      string         `json:"group"`
+	Partitions []PartitionLag `json:"partitions"`
+	Total      int64          `json:"total"`
+}
+
+// lag reports the group lag on every event topic that exists.
+func lag(ctx context.Context, obj cli, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: lag")
+	}
+
+	client, err := obj.kafka()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		return err
+	}
+
+	existing, err := client.Topics()
+	if err != nil {
+		return err
+	}
+	exists := map[string]bool{}
+	for _, topic := range existing {
+		exists[topic] = true
+	}
+	topics := []string{}
+	topicPartitions := map[string][]int32{}
+	for _, topic := range events.Topics {
+		if !exists[topic] {
+			continue
+		}
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			return err
+		}
+		topics = append(topics, topic)
+		topicPartitions[topic] = partitions
+	}
+	committed, err := admin.ListConsumerGroupOffsets(obj.Group, topicPartitions)
+	if err != nil {
+		return err
+	}
+
+	report := LagReport{Group: obj.Group, Partitions: []PartitionLag{}}
+	rows := [][]string{}
+	for _, topic := range topics {
+		for _, partition := range topicPartitions[topic] {
+			newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return err
+			}
+			partitionLag := PartitionLag{Topic: topic, Partition: partition, Committed: -1, Newest: newest}
+			if block := committed.GetBlock(topic, partition); block != nil && block.Offset >= 0 {
+				partitionLag.Committed = block.Offset
+				partitionLag.Lag = newest - block.Offset
+			} else {
+				oldest, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+				if err != nil {
+					return err
+				}
+				partitionLag.Lag = newest - oldest
+			}
+
+			report.Partitions = append(report.Partitions, partitionLag)
+			report.Total += partitionLag.Lag
+			rows = append(rows, []string{
+				topic,
+				strconv.Itoa(int(partition)),
+				strconv.FormatInt(partitionLag.Committed, 10),
+				strconv.FormatInt(newest, 10),
+				strconv.FormatInt(partitionLag.Lag, 10),
+			})
+		}
+	}
+	rows = append(rows, []string{"TOTAL", "", "", "", strconv.FormatInt(report.Total, 10)})
+	return obj.write(report, []string{"TOPIC", "PARTITION", "COMMITTED", "NEWEST", "LAG"}, rows)
+}
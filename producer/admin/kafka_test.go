@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBroker serves one partition of OpenAccountEvent, the last message of
+// which does not decode, and two of DepositFundEvent, the first missing
+// offset 0. The accountConsumer group has committed all but the last
+// partition. Messages have no timestamp, like those of brokers before 0.10.
+func newBroker(t *testing.T) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("OpenAccountEvent", 0, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 0, broker.BrokerID()).
+			SetLeader("DepositFundEvent", 1, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "accountConsumer", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("accountConsumer", "OpenAccountEvent", 0, 1, "", sarama.ErrNoError).
+			SetOffset("accountConsumer", "DepositFundEvent", 0, 2, "", sarama.ErrNoError).
+			SetOffset("accountConsumer", "DepositFundEvent", 1, -1, "", sarama.ErrNoError),
+		// kafka 1.0.0 lists offsets with v1 and fetches with v4
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("OpenAccountEvent", 0, sarama.OffsetOldest, 0).
+			SetOffset("DepositFundEvent", 0, sarama.OffsetOldest, 0).
+			SetOffset("DepositFundEvent", 1, sarama.OffsetOldest, 1).
+			SetOffset("OpenAccountEvent", 0, sarama.OffsetNewest, 3).
+			SetOffset("DepositFundEvent", 0, sarama.OffsetNewest, 3).
+			SetOffset("DepositFundEvent", 1, sarama.OffsetNewest, 5),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetVersion(4).
+			SetMessage("OpenAccountEvent", 0, 0, sarama.StringEncoder(`{"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}`)).
+			SetMessage("OpenAccountEvent", 0, 1, sarama.StringEncoder(`{"ID":"456","AccountHolder":"Jane Doe","AccountType":2,"OpeningBalance":2000}`)).
+			SetMessage("OpenAccountEvent", 0, 2, sarama.StringEncoder(`not json`)).
+			SetMessage("DepositFundEvent", 0, 1, sarama.StringEncoder(`{"ID":"123","Amount":500}`)).
+			SetMessage("DepositFundEvent", 0, 2, sarama.StringEncoder(`{"ID":"456","Amount":100}`)).
+			SetHighWaterMark("OpenAccountEvent", 0, 3).
+			SetHighWaterMark("DepositFundEvent", 0, 3),
+	})
+	return broker
+}
+
+func newKafkaOptions(broker *sarama.MockBroker) Options {
+	return Options{
+		Group: "accountConsumer",
+		Kafka: func() (sarama.Client, error) {
+			config := sarama.NewConfig()
+			config.Version = sarama.V1_0_0_0
+			return sarama.NewClient([]string{broker.Addr()}, config)
+		},
+	}
+}
+
+func Test_Run_Kafka_Commands(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+
+		wantOutput string
+		wantError  string
+	}{
+		{
+			name: "Test should tail decoded events",
+			args: []string{"tail", "-topic", "OpenAccountEvent", "-offset", "oldest", "-n", "3"},
+			wantOutput: "PARTITION  OFFSET    TIMESTAMP             EVENT\n" +
+				`0          0         -                     {"ID":"123","AccountHolder":"John Doe","AccountType":1,"OpeningBalance":1000}` + "\n" +
+				`0          1         -                     {"ID":"456","AccountHolder":"Jane Doe","AccountType":2,"OpeningBalance":2000}` + "\n" +
+				"0          2         -                     error: invalid character 'o' in literal null (expecting 'u')\n",
+		},
+		{
+			name:       "Test should tail as json lines",
+			args:       []string{"-o", "json", "tail", "-topic", "DepositFundEvent", "-offset", "oldest", "-n", "1"},
+			wantOutput: `{"topic":"DepositFundEvent","partition":0,"offset":1,"timestamp":"0001-01-01T00:00:00Z","headers":{},"event":{"ID":"123","Amount":500}}` + "\n",
+		},
+		{
+			name: "Test should report lag of the consumer group",
+			args: []string{"lag"},
+			wantOutput: "TOPIC             PARTITION  COMMITTED  NEWEST  LAG\n" +
+				"OpenAccountEvent  0          1          3       2\n" +
+				"DepositFundEvent  0          2          3       1\n" +
+				"DepositFundEvent  1          -1         5       4\n" +
+				"TOTAL                                           7\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+
+			err := Run(context.Background(), newKafkaOptions(newBroker(t)), test.args, w)
+
+			if test.wantError != "" {
+				assert.EqualError(t, err, test.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantOutput, w.String())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package aggregates
+
+import (
+	"errors"
+	"events"
+	"fmt"
+)
+
+var (
+	ErrAccountExists     = errors.New("account already exists")
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrAccountClosed     = errors.New("account is closed")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrEmptyAdjustment   = errors.New("adjustment must not be zero")
+)
+
+// Account is the state of a bank account rebuilt from its events. Commands
+// are decided against it and return the event to append, or the reason the
+// command is rejected.
+type Account struct {
+	ID            string
+	AccountHolder string
+	AccountType   int
+	Balance       float64
+	Closed        bool
+	// Version counts the events applied, 0 for an account never opened.
+	Version int
+}
+
+// Apply folds one event of the account's stream into its state.
+func (obj *Account) Apply(event events.Event) error {
+	switch event := event.(type) {
+	case events.OpenAccountEvent:
+		obj.ID = event.ID
+		obj.AccountHolder = event.AccountHolder
+		obj.AccountType = event.AccountType
+		obj.Balance = event.OpeningBalance
+	case events.DepositFundEvent:
+		obj.Balance += event.Amount
+	case events.WithdrawFundEvent:
+		obj.Balance -= event.Amount
+	case events.CloseAccountEvent:
+		obj.Closed = true
+	case events.AdjustBalanceEvent:
+		obj.Balance += event.Amount
+	default:
+		return fmt.Errorf("account %v: unknown event %T", obj.ID, event)
+	}
+	obj.Version++
+	return nil
+}
+
+func (obj Account) Open(id string, accountHolder string, accountType int, openingBalance float64) (events.Event, error) {
+	if openingBalance <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if obj.Version > 0 {
+		return nil, ErrAccountExists
+	}
+	return events.OpenAccountEvent{
+		ID:             id,
+		AccountHolder:  accountHolder,
+		AccountType:    accountType,
+		OpeningBalance: openingBalance,
+	}, nil
+}
+
+func (obj Account) Deposit(amount float64) (events.Event, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	err := obj.active()
+	if err != nil {
+		return nil, err
+	}
+	return events.DepositFundEvent{ID: obj.ID, Amount: amount}, nil
+}
+
+func (obj Account) Withdraw(amount float64) (events.Event, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	err := obj.active()
+	if err != nil {
+		return nil, err
+	}
+	if amount > obj.Balance {
+		return nil, ErrInsufficientFunds
+	}
+	return events.WithdrawFundEvent{ID: obj.ID, Amount: amount}, nil
+}
+
+// Adjust corrects the balance by amount, up or down, so the account agrees
+// with the read model reconciliation fixed.
+func (obj Account) Adjust(amount float64, reason string) (events.Event, error) {
+	if amount == 0 {
+		return nil, ErrEmptyAdjustment
+	}
+	err := obj.active()
+	if err != nil {
+		return nil, err
+	}
+	return events.AdjustBalanceEvent{ID: obj.ID, Amount: amount, Reason: reason}, nil
+}
+
+func (obj Account) Close() (events.Event, error) {
+	err := obj.active()
+	if err != nil {
+		return nil, err
+	}
+	return events.CloseAccountEvent{ID: obj.ID}, nil
+}
+
+func (obj Account) active() error {
+	if obj.Version == 0 {
+		return ErrAccountNotFound
+	}
+	if obj.Closed {
+		return ErrAccountClosed
+	}
+	return nil
+}
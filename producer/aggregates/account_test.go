@@ -0,0 +1,148 @@
+package aggregates
+
+import (
+	"events"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Account(t *testing.T) {
+	opened := []events.Event{events.OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000}}
+	closed := append(opened, events.CloseAccountEvent{ID: "123"})
+
+	tests := []struct {
+		name   string
+		stream []events.Event
+		decide func(account Account) (events.Event, error)
+
+		wantEvent events.Event
+		wantError error
+	}{
+		{
+			name:      "Test should open an account that does not exist",
+			decide:    func(account Account) (events.Event, error) { return account.Open("123", "John Doe", 1, 1000) },
+			wantEvent: opened[0],
+		},
+		{
+			name:      "Test should reject opening an account with no money",
+			decide:    func(account Account) (events.Event, error) { return account.Open("123", "John Doe", 1, 0) },
+			wantError: ErrInvalidAmount,
+		},
+		{
+			name:      "Test should reject a negative opening balance",
+			decide:    func(account Account) (events.Event, error) { return account.Open("123", "John Doe", 1, -1000) },
+			wantError: ErrInvalidAmount,
+		},
+		{
+			name:      "Test should reject opening an account twice",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Open("123", "John Doe", 1, 1000) },
+			wantError: ErrAccountExists,
+		},
+		{
+			name:      "Test should reject a deposit to an account that does not exist",
+			decide:    func(account Account) (events.Event, error) { return account.Deposit(100) },
+			wantError: ErrAccountNotFound,
+		},
+		{
+			name:      "Test should reject a deposit to a closed account",
+			stream:    closed,
+			decide:    func(account Account) (events.Event, error) { return account.Deposit(100) },
+			wantError: ErrAccountClosed,
+		},
+		{
+			name:      "Test should deposit to an open account",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Deposit(100) },
+			wantEvent: events.DepositFundEvent{ID: "123", Amount: 100},
+		},
+		{
+			name:      "Test should reject a deposit of no money",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Deposit(0) },
+			wantError: ErrInvalidAmount,
+		},
+		{
+			name:      "Test should reject a negative deposit",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Deposit(-1000) },
+			wantError: ErrInvalidAmount,
+		},
+		{
+			name:      "Test should withdraw up to the balance",
+			stream:    append(opened, events.WithdrawFundEvent{ID: "123", Amount: 400}),
+			decide:    func(account Account) (events.Event, error) { return account.Withdraw(600) },
+			wantEvent: events.WithdrawFundEvent{ID: "123", Amount: 600},
+		},
+		{
+			name:      "Test should reject a withdrawal over the balance",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Withdraw(1000.01) },
+			wantError: ErrInsufficientFunds,
+		},
+		{
+			name:      "Test should reject a withdrawal of no money",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Withdraw(0) },
+			wantError: ErrInvalidAmount,
+		},
+		{
+			name:      "Test should reject a negative withdrawal",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Withdraw(-1000) },
+			wantError: ErrInvalidAmount,
+		},
+		{
+			name:      "Test should adjust a balance down",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Adjust(-250, "reconciliation") },
+			wantEvent: events.AdjustBalanceEvent{ID: "123", Amount: -250, Reason: "reconciliation"},
+		},
+		{
+			name:      "Test should reject an adjustment of no money",
+			stream:    opened,
+			decide:    func(account Account) (events.Event, error) { return account.Adjust(0, "reconciliation") },
+			wantError: ErrEmptyAdjustment,
+		},
+		{
+			name:      "Test should reject adjusting a closed account",
+			stream:    closed,
+			decide:    func(account Account) (events.Event, error) { return account.Adjust(100, "reconciliation") },
+			wantError: ErrAccountClosed,
+		},
+		{
+			name:      "Test should reject closing a closed account",
+			stream:    closed,
+			decide:    func(account Account) (events.Event, error) { return account.Close() },
+			wantError: ErrAccountClosed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			account := Account{}
+			for _, event := range test.stream {
+				assert.NoError(t, account.Apply(event))
+			}
+
+			event, err := test.decide(account)
+
+			assert.Equal(t, test.wantError, err)
+			assert.Equal(t, test.wantEvent, event)
+		})
+	}
+}
+
+func Test_Account_Apply(t *testing.T) {
+	account := Account{}
+
+	assert.NoError(t, account.Apply(events.OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000}))
+	assert.NoError(t, account.Apply(events.DepositFundEvent{ID: "123", Amount: 500}))
+	assert.NoError(t, account.Apply(events.WithdrawFundEvent{ID: "123", Amount: 200}))
+	assert.NoError(t, account.Apply(events.AdjustBalanceEvent{ID: "123", Amount: -50, Reason: "reconciliation"}))
+	assert.NoError(t, account.Apply(events.CloseAccountEvent{ID: "123"}))
+	assert.Error(t, account.Apply(&events.DepositFundEvent{ID: "123", Amount: 500}))
+
+	assert.Equal(t, Account{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1250, Closed: true, Version: 5}, account)
+}
@@ -0,0 +1,90 @@
+package aggregates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"log/slog"
+	"producer/eventstore"
+)
+
+type IAccountRepository interface {
+	// Load rebuilds an account from its latest snapshot and the events after
+	// it. An account that was never opened comes back at version 0.
+	Load(ctx context.Context, id string) (Account, error)
+	// Save appends events to the account's stream if nothing was appended
+	// since it was loaded, then applies them to account.
+	Save(ctx context.Context, account *Account, events ...events.Event) error
+}
+
+type accountRepository struct {
+	store         eventstore.IEventStore
+	snapshotEvery int
+	logger        *slog.Logger
+}
+
+// NewAccountRepository snapshots an account each time its version passes a
+// multiple of snapshotEvery, so loading a long-lived account replays at most
+// that many events. Zero disables snapshots.
+func NewAccountRepository(store eventstore.IEventStore, snapshotEvery int, logger *slog.Logger) IAccountRepository {
+	return accountRepository{store, snapshotEvery, logger}
+}
+
+func (obj accountRepository) Load(ctx context.Context, id string) (Account, error) {
+	account := Account{}
+	snapshot, err := obj.store.LoadSnapshot(ctx, id)
+	if err == nil {
+		err = json.Unmarshal(snapshot.State, &account)
+	}
+	if err != nil && !errors.Is(err, eventstore.ErrSnapshotNotFound) {
+		return Account{}, err
+	}
+
+	stream, err := obj.store.Load(ctx, id, account.Version)
+	if err != nil {
+		return Account{}, err
+	}
+	for _, event := range stream {
+		err = account.Apply(event)
+		if err != nil {
+			return Account{}, err
+		}
+	}
+	return account, nil
+}
+
+func (obj accountRepository) Save(ctx context.Context, account *Account, events ...events.Event) error {
+	next := *account
+	for _, event := range events {
+		err := next.Apply(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := obj.store.Append(ctx, next.ID, account.Version, events)
+	if err != nil {
+		return err
+	}
+	previous := account.Version
+	*account = next
+
+	if obj.snapshotEvery > 0 && account.Version/obj.snapshotEvery > previous/obj.snapshotEvery {
+		// The events are stored, so a failed snapshot only costs a longer
+		// replay next time.
+		err = obj.snapshot(ctx, *account)
+		if err != nil {
+			obj.logger.WarnContext(ctx, "saving snapshot failed", "account_id", account.ID, "version", account.Version, "error", err)
+		}
+	}
+	return nil
+}
+
+func (obj accountRepository) snapshot(ctx context.Context, account Account) error {
+	state, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	return obj.store.SaveSnapshot(ctx, eventstore.Snapshot{StreamID: account.ID, Version: account.Version, State: state})
+}
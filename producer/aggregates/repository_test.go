@@ -0,0 +1,75 @@
+package aggregates
+
+import (
+	"context"
+	"errors"
+	"events"
+	"platform/logging"
+	"producer/eventstore"
+	mockEventStore "producer/eventstore/mock"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_accountRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Test should snapshot every snapshotEvery events and load from the snapshot", func(t *testing.T) {
+		store := eventstore.NewMemoryEventStore()
+		accountRepository := NewAccountRepository(store, 3, logging.Discard())
+
+		account := Account{}
+		assert.NoError(t, accountRepository.Save(ctx, &account, events.OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000}))
+		for i := 0; i < 4; i++ {
+			assert.NoError(t, accountRepository.Save(ctx, &account, events.DepositFundEvent{ID: "123", Amount: 100}))
+		}
+
+		snapshot, err := store.LoadSnapshot(ctx, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, snapshot.Version)
+
+		loaded, err := accountRepository.Load(ctx, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, account, loaded)
+		assert.Equal(t, Account{ID: "123", AccountHolder: "John Doe", AccountType: 1, Balance: 1400, Version: 5}, loaded)
+	})
+
+	t.Run("Test should reject saving an account changed since it was loaded", func(t *testing.T) {
+		accountRepository := NewAccountRepository(eventstore.NewMemoryEventStore(), 0, logging.Discard())
+		account := Account{}
+		assert.NoError(t, accountRepository.Save(ctx, &account, events.OpenAccountEvent{ID: "123", OpeningBalance: 1000}))
+		first, _ := accountRepository.Load(ctx, "123")
+		second, _ := accountRepository.Load(ctx, "123")
+
+		assert.NoError(t, accountRepository.Save(ctx, &first, events.WithdrawFundEvent{ID: "123", Amount: 1000}))
+		err := accountRepository.Save(ctx, &second, events.WithdrawFundEvent{ID: "123", Amount: 1000})
+
+		assert.ErrorIs(t, err, eventstore.ErrConcurrencyConflict)
+		assert.Equal(t, 1, second.Version)
+	})
+
+	t.Run("Test should save events when the snapshot fails", func(t *testing.T) {
+		store := mockEventStore.NewIEventStore(t)
+		store.On("Append", mock.Anything, "123", 0, mock.Anything).Return(nil)
+		store.On("SaveSnapshot", mock.Anything, mock.Anything).Return(errors.New("error"))
+		accountRepository := NewAccountRepository(store, 1, logging.Discard())
+
+		account := Account{}
+		err := accountRepository.Save(ctx, &account, events.OpenAccountEvent{ID: "123", OpeningBalance: 1000})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, account.Version)
+	})
+
+	t.Run("Test should return error when loading the snapshot fails", func(t *testing.T) {
+		store := mockEventStore.NewIEventStore(t)
+		store.On("LoadSnapshot", mock.Anything, "123").Return(eventstore.Snapshot{}, errors.New("error"))
+		accountRepository := NewAccountRepository(store, 1, logging.Discard())
+
+		_, err := accountRepository.Load(ctx, "123")
+
+		assert.EqualError(t, err, "error")
+	})
+}
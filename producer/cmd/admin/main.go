@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"producer/admin"
+	"producer/config"
+	"syscall"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+func main() {
+	cfg, err := config.Load(".", os.Getenv("APP_PROFILE"))
+	if err != nil {
+		panic(err)
+	}
+
+	// tail decodes avro payloads with the schemas the producer registered
+	registry := events.NewSchemaRegistry(cfg.SchemaRegistry.URL, cfg.SchemaRegistry.File)
+	if registry != nil {
+		events.RegisterCodec(events.AvroCodec{Registry: registry})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = admin.Run(ctx, admin.Options{
+		ProducerURL: "http://localhost" + cfg.Server.Address,
+		ConsumerURL: cfg.Consumer.URL,
+		Group:       cfg.Consumer.Group,
+		HTTP:        &http.Client{Timeout: 10 * time.Second},
+		Kafka: func() (sarama.Client, error) {
+			saramaConfig, err := cfg.Kafka.Sarama()
+			if err != nil {
+				return nil, err
+			}
+			return sarama.NewClient(cfg.Kafka.Servers, saramaConfig)
+		},
+	}, os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"producer/replay"
+	"time"
+)
+
+func main() {
+	file := flag.String("file", "requests.jsonl", "JSONL corpus of request records")
+	url := flag.String("url", "http://localhost:8000", "base URL of the running producer")
+	rate := flag.Float64("rate", 0, "maximum requests per second, 0 for unlimited")
+	concurrency := flag.Int("concurrency", 1, "number of concurrent workers")
+	timeout := flag.Duration("timeout", 10*time.Second, "per request timeout")
+	flag.Parse()
+
+	corpus, err := os.Open(*file)
+	if err != nil {
+		panic(err)
+	}
+	defer corpus.Close()
+
+	records, err := replay.ReadRecords(corpus)
+	if err != nil {
+		panic(err)
+	}
+
+	report := replay.Run(&http.Client{Timeout: *timeout}, records, replay.Options{
+		BaseURL:     *url,
+		Rate:        *rate,
+		Concurrency: *concurrency,
+	})
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	fmt.Fprintf(os.Stderr, "%v/%v requests matched\n", report.Passed, report.Total)
+	if len(report.Mismatches) > 0 {
+		os.Exit(1)
+	}
+}
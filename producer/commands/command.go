@@ -19,3 +19,11 @@ type WithdrawFundCommand struct {
 type CloseAccountCommand struct {
 	ID string
 }
+
+// AdjustBalanceCommand corrects a balance by Amount, which may be negative,
+// as reconciliation does when the read model drifted from the event log.
+type AdjustBalanceCommand struct {
+	ID     string
+	Amount float64
+	Reason string
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"events"
+	"platform/config"
+	"platform/database"
+	"platform/kafka"
+	"time"
+)
+
+type Config struct {
+	Profile string
+
+	Server struct {
+		Address string
+	}
+
+	Kafka struct {
+		kafka.Config `mapstructure:",squash"`
+		// ContentType selects the codec events are produced with.
+		ContentType string `mapstructure:"contentType"`
+	}
+
+	// DB stores the account event streams.
+	DB database.Config
+
+	EventStore struct {
+		// SnapshotEvery is how many events an account gets between
+		// snapshots; 0 disables snapshots.
+		SnapshotEvery int `mapstructure:"snapshotEvery"`
+	} `mapstructure:"eventStore"`
+
+	// Outbox holds the appended events until they are produced.
+	Outbox struct {
+		// Interval is how often the events a request failed to produce are
+		// retried.
+		Interval time.Duration
+		// BatchSize is how many events are read from the outbox at once.
+		BatchSize int `mapstructure:"batchSize"`
+	}
+
+	// Consumer is where the admin CLI finds the consumer, matching its
+	// admin.address and kafka.group.
+	Consumer struct {
+		// URL is the base URL of the consumer account API.
+		URL   string
+		Group string
+	}
+
+	SchemaRegistry struct {
+		URL  string
+		File string
+	} `mapstructure:"schemaRegistry"`
+
+	Tracing struct {
+		Exporter string
+		Endpoint string
+	}
+
+	Log struct {
+		Level  string
+		Format string
+	}
+
+	Shutdown struct {
+		// Timeout bounds draining and closing clients after a signal.
+		Timeout time.Duration
+	}
+}
+
+var defaults = map[string]interface{}{
+	"server.address":           ":8000",
+	"kafka.clientId":           "producer",
+	"kafka.contentType":        events.ContentTypeJSON,
+	"db.driver":                "sqlite",
+	"db.database":              "producer.db",
+	"eventStore.snapshotEvery": 100,
+	"outbox.interval":          "1s",
+	"outbox.batchSize":         100,
+	"consumer.url":             "http://localhost:9101",
+	"consumer.group":           "accountConsumer",
+	"schemaRegistry.url":       "",
+	"schemaRegistry.file":      "",
+	"tracing.exporter":         "none",
+	"tracing.endpoint":         "localhost:4318",
+	"log.level":                "info",
+	"log.format":               "json",
+	"shutdown.timeout":         "30s",
+}
+
+// Load reads the producer config for profile from dir, resolves secret
+// files and validates it.
+func Load(dir string, profile string) (Config, error) {
+	obj := Config{}
+	err := config.Load(config.Options{Dir: dir, Profile: profile, Defaults: config.MergeDefaults(kafka.Defaults, database.Defaults, defaults)}, &obj)
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = obj.Kafka.ResolveSecrets()
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = obj.DB.ResolveSecrets()
+	if err != nil {
+		return Config{}, err
+	}
+	return obj, obj.Validate()
+}
+
+// Validate reports every invalid field at once.
+func (obj Config) Validate() error {
+	errs := config.ValidationError{}
+
+	errs.Check(obj.Server.Address != "", "server.address", "is required")
+	obj.Kafka.Validate(&errs)
+	errs.OneOf("kafka.contentType", obj.Kafka.ContentType, events.ContentTypeJSON, events.ContentTypeProtobuf, events.ContentTypeAvro)
+	if obj.Kafka.ContentType == events.ContentTypeAvro {
+		errs.Check(obj.SchemaRegistry.URL != "" || obj.SchemaRegistry.File != "", "schemaRegistry", "url or file is required for "+events.ContentTypeAvro)
+	}
+	obj.DB.Validate(&errs)
+	errs.Check(obj.EventStore.SnapshotEvery >= 0, "eventStore.snapshotEvery", "must not be negative")
+	errs.Check(obj.Outbox.Interval > 0, "outbox.interval", "must be positive")
+	errs.Check(obj.Outbox.BatchSize > 0, "outbox.batchSize", "must be positive")
+	errs.Check(obj.Consumer.URL != "", "consumer.url", "is required")
+	errs.Check(obj.Consumer.Group != "", "consumer.group", "is required")
+	errs.OneOf("tracing.exporter", obj.Tracing.Exporter, "otlp", "stdout", "none")
+	if obj.Tracing.Exporter == "otlp" {
+		errs.Check(obj.Tracing.Endpoint != "", "tracing.endpoint", "is required for the otlp exporter")
+	}
+	errs.OneOf("log.level", obj.Log.Level, "debug", "info", "warn", "error")
+	errs.OneOf("log.format", obj.Log.Format, "json", "text")
+	errs.Check(obj.Shutdown.Timeout > 0, "shutdown.timeout", "must be positive")
+
+	return errs.Err()
+}
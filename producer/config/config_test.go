@@ -0,0 +1,93 @@
+package config
+
+import (
+	"events"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Load(t *testing.T) {
+	for _, profile := range []string{"local", "test", "prod"} {
+		t.Run("Test should load committed config for "+profile+" profile", func(t *testing.T) {
+			config, err := Load("..", profile)
+
+			assert.NoError(t, err)
+			assert.Equal(t, profile, config.Profile)
+			assert.Equal(t, ":8000", config.Server.Address)
+		})
+	}
+
+	t.Run("Test should override file with environment", func(t *testing.T) {
+		t.Setenv("KAFKA_SERVERS", "kafka-1:9092,kafka-2:9092")
+		t.Setenv("KAFKA_CONTENTTYPE", events.ContentTypeProtobuf)
+
+		config, err := Load("..", "local")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"kafka-1:9092", "kafka-2:9092"}, config.Kafka.Servers)
+		assert.Equal(t, events.ContentTypeProtobuf, config.Kafka.ContentType)
+	})
+
+	t.Run("Test should build secured sarama config from environment", func(t *testing.T) {
+		passwordFile := filepath.Join(t.TempDir(), "kafka_password")
+		assert.NoError(t, os.WriteFile(passwordFile, []byte("s3cret\n"), 0600))
+		t.Setenv("KAFKA_SASL_MECHANISM", "SCRAM-SHA-256")
+		t.Setenv("KAFKA_SASL_USERNAME", "producer")
+		t.Setenv("KAFKA_SASL_PASSWORDFILE", passwordFile)
+		t.Setenv("KAFKA_PRODUCER_IDEMPOTENT", "true")
+		t.Setenv("KAFKA_VERSION", "2.8.0")
+
+		config, err := Load("..", "local")
+		assert.NoError(t, err)
+		saramaConfig, err := config.Kafka.Sarama()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "producer", saramaConfig.ClientID)
+		assert.Equal(t, "s3cret", saramaConfig.Net.SASL.Password)
+		assert.True(t, saramaConfig.Net.SASL.Enable)
+		assert.True(t, saramaConfig.Producer.Idempotent)
+	})
+
+	t.Run("Test should use in-memory sqlite for test profile", func(t *testing.T) {
+		config, err := Load("..", "test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "sqlite", config.DB.Driver)
+		assert.Equal(t, "file:producer_test?mode=memory&cache=shared", config.DB.DSN())
+		assert.Equal(t, 100, config.EventStore.SnapshotEvery)
+		assert.Equal(t, time.Second, config.Outbox.Interval)
+		assert.Equal(t, 100, config.Outbox.BatchSize)
+		assert.Equal(t, "http://localhost:9101", config.Consumer.URL)
+		assert.Equal(t, "accountConsumer", config.Consumer.Group)
+	})
+
+	t.Run("Test should list every invalid field", func(t *testing.T) {
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(`
+kafka:
+  contentType: application/vnd.confluent.avro
+tracing:
+  exporter: zipkin
+outbox:
+  batchSize: 0
+consumer:
+  group: ""
+log:
+  level: verbose
+`), 0644)
+		assert.NoError(t, err)
+
+		_, err = Load(dir, "")
+
+		assert.EqualError(t, err, `invalid config: `+
+			`schemaRegistry: url or file is required for application/vnd.confluent.avro; `+
+			`outbox.batchSize: must be positive; `+
+			`consumer.group: is required; `+
+			`tracing.exporter: "zipkin" is not one of otlp, stdout, none; `+
+			`log.level: "verbose" is not one of debug, info, warn, error`)
+	})
+}
@@ -2,7 +2,7 @@ package accountcontrollers
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"producer/commands"
 	services "producer/services/account"
 
@@ -14,14 +14,16 @@ type IAccountController interface {
 	DepositFund(c *fiber.Ctx) error
 	WithdrawFund(c *fiber.Ctx) error
 	CloseAccount(c *fiber.Ctx) error
+	AdjustBalance(c *fiber.Ctx) error
 }
 
 type accountController struct {
 	accountService services.IAccountService
+	logger         *slog.Logger
 }
 
-func NewAccountController(accountService services.IAccountService) IAccountController {
-	return accountController{accountService}
+func NewAccountController(accountService services.IAccountService, logger *slog.Logger) IAccountController {
+	return accountController{accountService, logger}
 }
 
 func (obj accountController) OpenAccount(c *fiber.Ctx) error {
@@ -32,10 +34,10 @@ func (obj accountController) OpenAccount(c *fiber.Ctx) error {
 		return c.SendString(err.Error())
 	}
 
-	id, err := obj.accountService.OpenAccount(command)
+	id, err := obj.accountService.OpenAccount(c.UserContext(), command)
 	if err != nil {
-		c.Status(fiber.StatusInternalServerError)
-		return err
+		c.Status(statusFor(err))
+		return c.SendString(err.Error())
 	}
 
 	c.Status(fiber.StatusCreated)
@@ -53,9 +55,9 @@ func (obj accountController) DepositFund(c *fiber.Ctx) error {
 		return c.SendString(err.Error())
 	}
 
-	err = obj.accountService.DepositFund(command)
+	err = obj.accountService.DepositFund(c.UserContext(), command)
 	if err != nil {
-		c.Status(fiber.StatusInternalServerError)
+		c.Status(statusFor(err))
 		return c.SendString(err.Error())
 	}
 
@@ -71,10 +73,10 @@ func (obj accountController) WithdrawFund(c *fiber.Ctx) error {
 		return err
 	}
 
-	err = obj.accountService.WithdrawFund(command)
+	err = obj.accountService.WithdrawFund(c.UserContext(), command)
 	if err != nil {
-		log.Println(err)
-		return err
+		obj.logger.ErrorContext(c.UserContext(), "withdraw fund failed", "account_id", command.ID, "error", err)
+		return fiber.NewError(statusFor(err), err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -89,13 +91,31 @@ func (obj accountController) CloseAccount(c *fiber.Ctx) error {
 		return err
 	}
 
-	err = obj.accountService.CloseAccount(command)
+	err = obj.accountService.CloseAccount(c.UserContext(), command)
 	if err != nil {
-		log.Println(err)
-		return err
+		obj.logger.ErrorContext(c.UserContext(), "close account failed", "account_id", command.ID, "error", err)
+		return fiber.NewError(statusFor(err), err.Error())
 	}
 
 	return c.JSON(fiber.Map{
 		"message": "close account success",
 	})
 }
+
+func (obj accountController) AdjustBalance(c *fiber.Ctx) error {
+	command := commands.AdjustBalanceCommand{}
+	err := c.BodyParser(&command)
+	if err != nil {
+		return err
+	}
+
+	err = obj.accountService.AdjustBalance(c.UserContext(), command)
+	if err != nil {
+		obj.logger.ErrorContext(c.UserContext(), "adjust balance failed", "account_id", command.ID, "error", err)
+		return fiber.NewError(statusFor(err), err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "adjust balance success",
+	})
+}
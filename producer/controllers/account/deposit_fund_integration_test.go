@@ -4,20 +4,28 @@ package accountcontrollers
 
 import (
 	"events"
+	"platform/logging"
+	"producer/aggregates"
 	"producer/commands"
 	internal "producer/internal"
 	accountservice "producer/services/account"
 	mockService "producer/services/mock"
+	outboxservice "producer/services/outbox"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/valyala/fasthttp"
 )
 
 func Test_Integration_Controller_Deposit_Fund(t *testing.T) {
 	mockEventProducer := mockService.NewIEventProducer(t)
-	accountservice := accountservice.NewAccountService(mockEventProducer)
+	store := internal.NewEventStore(map[string][]events.Event{
+		"test": {events.OpenAccountEvent{ID: "test", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000}},
+	})
+	accountRepository := aggregates.NewAccountRepository(store, 0, logging.Discard())
+	accountservice := accountservice.NewAccountService(outboxservice.NewRelay(store, mockEventProducer, 100, logging.Discard()), accountRepository, logging.Discard())
 
 	clearAllMock := func() {
 		mockEventProducer.ClearAll()
@@ -33,6 +41,20 @@ func Test_Integration_Controller_Deposit_Fund(t *testing.T) {
 		wantErrorCode                  string
 		wantControllerResponse         interface{}
 	}{
+		{
+			name: "Test should return not found if account was never opened",
+			mockPayload: commands.DepositFundCommand{
+				ID:     "missing",
+				Amount: 1000,
+			},
+			wantServiceCallTimes: map[string]map[string]int{
+				"eventProducer": {
+					"Produce": 0,
+				},
+			},
+			wantStatusCode:         404,
+			wantControllerResponse: "account not found",
+		},
 		{
 			name: "Test should return success if produce event success",
 			mockPayload: commands.DepositFundCommand{
@@ -40,7 +62,7 @@ func Test_Integration_Controller_Deposit_Fund(t *testing.T) {
 				Amount: 1000,
 			},
 			wantServiceCallWithAndResponse: func() {
-				mockEventProducer.On("Produce", events.DepositFundEvent{
+				mockEventProducer.On("Produce", mock.Anything, events.DepositFundEvent{
 					ID:     "test",
 					Amount: 1000,
 				}).Return(nil)
@@ -79,7 +101,7 @@ func Test_Integration_Controller_Deposit_Fund(t *testing.T) {
 				test.wantServiceCallWithAndResponse()
 			}
 
-			accountContrller := NewAccountController(accountservice)
+			accountContrller := NewAccountController(accountservice, logging.Discard())
 			accountContrller.DepositFund(ctx)
 
 			// ------------------ gin ------------------
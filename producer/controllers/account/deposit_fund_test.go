@@ -1,6 +1,8 @@
 package accountcontrollers
 
 import (
+	"platform/logging"
+	"producer/aggregates"
 	"producer/commands"
 	internal "producer/internal"
 	mockService "producer/services/mock"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/valyala/fasthttp"
 )
 
@@ -35,7 +38,7 @@ func Test_Controller_Deposit_Fund(t *testing.T) {
 				Amount: 1000,
 			},
 			wantServiceCallWithAndResponse: func() {
-				mockAccountService.On("DepositFund", commands.DepositFundCommand{
+				mockAccountService.On("DepositFund", mock.Anything, commands.DepositFundCommand{
 					ID:     "test",
 					Amount: 1000,
 				}).Return(fiber.ErrInternalServerError)
@@ -47,6 +50,42 @@ func Test_Controller_Deposit_Fund(t *testing.T) {
 			},
 			wantStatusCode: 500,
 		},
+		{
+			name: "Test should return not found if account does not exist",
+			mockPayload: commands.DepositFundCommand{
+				ID:     "test",
+				Amount: 1000,
+			},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("DepositFund", mock.Anything, mock.Anything).Return(aggregates.ErrAccountNotFound)
+			},
+			wantStatusCode:         404,
+			wantControllerResponse: "account not found",
+		},
+		{
+			name: "Test should return bad request if the amount is not positive",
+			mockPayload: commands.DepositFundCommand{
+				ID:     "test",
+				Amount: -1000,
+			},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("DepositFund", mock.Anything, mock.Anything).Return(aggregates.ErrInvalidAmount)
+			},
+			wantStatusCode:         400,
+			wantControllerResponse: "amount must be positive",
+		},
+		{
+			name: "Test should return conflict if account is closed",
+			mockPayload: commands.DepositFundCommand{
+				ID:     "test",
+				Amount: 1000,
+			},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("DepositFund", mock.Anything, mock.Anything).Return(aggregates.ErrAccountClosed)
+			},
+			wantStatusCode:         409,
+			wantControllerResponse: "account is closed",
+		},
 		{
 			name: "Test should return success if DepositFund of account service return success",
 			mockPayload: commands.DepositFundCommand{
@@ -54,7 +93,7 @@ func Test_Controller_Deposit_Fund(t *testing.T) {
 				Amount: 1000,
 			},
 			wantServiceCallWithAndResponse: func() {
-				mockAccountService.On("DepositFund", commands.DepositFundCommand{
+				mockAccountService.On("DepositFund", mock.Anything, commands.DepositFundCommand{
 					ID:     "test",
 					Amount: 1000,
 				}).Return(nil)
@@ -93,7 +132,7 @@ func Test_Controller_Deposit_Fund(t *testing.T) {
 				test.wantServiceCallWithAndResponse()
 			}
 
-			accountContrller := NewAccountController(mockAccountService)
+			accountContrller := NewAccountController(mockAccountService, logging.Discard())
 			accountContrller.DepositFund(ctx)
 
 			// ------------------ gin ------------------
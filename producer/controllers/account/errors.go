@@ -0,0 +1,26 @@
+package accountcontrollers
+
+import (
+	"errors"
+	"producer/aggregates"
+	"producer/eventstore"
+	services "producer/services/account"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// statusFor maps a command the account rejected to a status the client can
+// act on. Anything else is a server error.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidCommand), errors.Is(err, aggregates.ErrInvalidAmount), errors.Is(err, aggregates.ErrEmptyAdjustment):
+		return fiber.StatusBadRequest
+	case errors.Is(err, aggregates.ErrAccountNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, aggregates.ErrAccountClosed), errors.Is(err, eventstore.ErrConcurrencyConflict):
+		return fiber.StatusConflict
+	case errors.Is(err, aggregates.ErrInsufficientFunds):
+		return fiber.StatusUnprocessableEntity
+	}
+	return fiber.StatusInternalServerError
+}
@@ -3,10 +3,14 @@
 package accountcontrollers
 
 import (
+	"platform/logging"
+	"producer/aggregates"
 	"producer/commands"
+	"producer/eventstore"
 	internal "producer/internal"
 	accountservice "producer/services/account"
 	mockService "producer/services/mock"
+	outboxservice "producer/services/outbox"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,7 +21,8 @@ import (
 
 func Test_Integration_Controller_Open_Account(t *testing.T) {
 	mockEventProducer := mockService.NewIEventProducer(t)
-	accountservice := accountservice.NewAccountService(mockEventProducer)
+	store := eventstore.NewMemoryEventStore()
+	accountservice := accountservice.NewAccountService(outboxservice.NewRelay(store, mockEventProducer, 100, logging.Discard()), aggregates.NewAccountRepository(store, 0, logging.Discard()), logging.Discard())
 
 	clearAllMock := func() {
 		mockEventProducer.ClearAll()
@@ -41,7 +46,7 @@ func Test_Integration_Controller_Open_Account(t *testing.T) {
 				OpeningBalance: 1000,
 			},
 			wantServiceCallWithAndResponse: func() {
-				mockEventProducer.On("Produce", mock.Anything).Return(nil)
+				mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(nil)
 			},
 			wantServiceCallTimes: map[string]map[string]int{
 				"eventProducer": {
@@ -74,7 +79,7 @@ func Test_Integration_Controller_Open_Account(t *testing.T) {
 				test.wantServiceCallWithAndResponse()
 			}
 
-			accountContrller := NewAccountController(accountservice)
+			accountContrller := NewAccountController(accountservice, logging.Discard())
 			accountContrller.OpenAccount(ctx)
 
 			// ------------------ gin ------------------
@@ -1,13 +1,18 @@
 package accountcontrollers
 
 import (
+	"fmt"
+	"platform/logging"
+	"producer/aggregates"
 	"producer/commands"
 	internal "producer/internal"
+	services "producer/services/account"
 	mockService "producer/services/mock"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/valyala/fasthttp"
 )
 
@@ -36,7 +41,7 @@ func Test_Controller_Open_Account(t *testing.T) {
 				OpeningBalance: 1000,
 			},
 			wantServiceCallWithAndResponse: func() {
-				mockAccountService.On("OpenAccount", commands.OpenAccountCommand{
+				mockAccountService.On("OpenAccount", mock.Anything, commands.OpenAccountCommand{
 					AccountHolder:  "test",
 					AccountType:    1,
 					OpeningBalance: 1000,
@@ -49,6 +54,31 @@ func Test_Controller_Open_Account(t *testing.T) {
 			},
 			wantStatusCode: 500,
 		},
+		{
+			name: "Test should return bad request if the command is invalid",
+			mockPayload: commands.OpenAccountCommand{
+				AccountType:    1,
+				OpeningBalance: 1000,
+			},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("OpenAccount", mock.Anything, mock.Anything).Return("", fmt.Errorf("%w: account holder is required", services.ErrInvalidCommand)).Once()
+			},
+			wantStatusCode:         400,
+			wantControllerResponse: "invalid command: account holder is required",
+		},
+		{
+			name: "Test should return bad request if the opening balance is not positive",
+			mockPayload: commands.OpenAccountCommand{
+				AccountHolder:  "test",
+				AccountType:    1,
+				OpeningBalance: -1000,
+			},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("OpenAccount", mock.Anything, mock.Anything).Return("", aggregates.ErrInvalidAmount).Once()
+			},
+			wantStatusCode:         400,
+			wantControllerResponse: "amount must be positive",
+		},
 		{
 			name: "Test should return success if OpenAccount of account service return success",
 			mockPayload: commands.OpenAccountCommand{
@@ -57,7 +87,7 @@ func Test_Controller_Open_Account(t *testing.T) {
 				OpeningBalance: 1000,
 			},
 			wantServiceCallWithAndResponse: func() {
-				mockAccountService.On("OpenAccount", commands.OpenAccountCommand{
+				mockAccountService.On("OpenAccount", mock.Anything, commands.OpenAccountCommand{
 					AccountHolder:  "test",
 					AccountType:    1,
 					OpeningBalance: 1000,
@@ -98,7 +128,7 @@ func Test_Controller_Open_Account(t *testing.T) {
 				test.wantServiceCallWithAndResponse()
 			}
 
-			accountContrller := NewAccountController(mockAccountService)
+			accountContrller := NewAccountController(mockAccountService, logging.Discard())
 			accountContrller.OpenAccount(ctx)
 
 			// ------------------ gin ------------------
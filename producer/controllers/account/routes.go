@@ -0,0 +1,11 @@
+package accountcontrollers
+
+import "github.com/gofiber/fiber/v2"
+
+func RegisterRoutes(router fiber.Router, accountController IAccountController) {
+	router.Post("/openAccount", accountController.OpenAccount)
+	router.Post("/depositFund", accountController.DepositFund)
+	router.Post("/withdrawFund", accountController.WithdrawFund)
+	router.Post("/closeAccount", accountController.CloseAccount)
+	router.Post("/adjustBalance", accountController.AdjustBalance)
+}
@@ -0,0 +1,82 @@
+package accountcontrollers
+
+import (
+	"events"
+	"net/http/httptest"
+	"platform/logging"
+	"producer/aggregates"
+	"producer/internal"
+	"producer/middlewares"
+	accountservice "producer/services/account"
+	outboxservice "producer/services/outbox"
+	eventproducerservice "producer/services/producer"
+	"strings"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type recordingSyncProducer struct {
+	sarama.SyncProducer
+	messages []*sarama.ProducerMessage
+}
+
+func (obj *recordingSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	obj.messages = append(obj.messages, msg)
+	return 0, 0, nil
+}
+
+func Test_Trace_Deposit_Fund(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	producer := &recordingSyncProducer{}
+	eventProducer := eventproducerservice.NewEventProducer(producer, events.JSONCodec{})
+
+	store := internal.NewEventStore(map[string][]events.Event{
+		"123": {events.OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000}},
+	})
+	accountRepository := aggregates.NewAccountRepository(store, 0, logging.Discard())
+
+	app := fiber.New()
+	app.Use(middlewares.Tracing())
+	app.Use(middlewares.Metadata())
+	RegisterRoutes(app, NewAccountController(accountservice.NewAccountService(outboxservice.NewRelay(store, eventProducer, 100, logging.Discard()), accountRepository, logging.Discard()), logging.Discard()))
+
+	request := httptest.NewRequest(fiber.MethodPost, "/depositFund", strings.NewReader(`{"ID":"123","Amount":1000}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	response, err := app.Test(request)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spanRecorder.Ended() {
+		spans[span.Name()] = span
+	}
+	serverSpan := spans["POST /depositFund"]
+	serviceSpan := spans["accountService.DepositFund"]
+	producerSpan := spans["DepositFundEvent send"]
+	if !assert.NotNil(t, serverSpan) || !assert.NotNil(t, serviceSpan) || !assert.NotNil(t, producerSpan) {
+		return
+	}
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), serviceSpan.Parent().SpanID())
+	assert.Equal(t, serviceSpan.SpanContext().SpanID(), producerSpan.Parent().SpanID())
+
+	assert.Len(t, producer.messages, 1)
+	headers := map[string]string{}
+	for _, header := range producer.messages[0].Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+producerSpan.SpanContext().SpanID().String()+"-01", headers["traceparent"])
+}
@@ -0,0 +1,22 @@
+package healthcontrollers
+
+import (
+	"platform/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes serves liveness on /healthz and the checker's readiness on
+// /readyz.
+func RegisterRoutes(router fiber.Router, checker *health.Checker) {
+	router.Get("/healthz", func(c *fiber.Ctx) error {
+		return c.JSON(health.Report{Status: health.StatusOK})
+	})
+	router.Get("/readyz", func(c *fiber.Ctx) error {
+		report := checker.Ready(c.UserContext())
+		if !report.OK() {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(report)
+	})
+}
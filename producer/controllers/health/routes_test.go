@@ -0,0 +1,76 @@
+package healthcontrollers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"platform/health"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegisterRoutes(t *testing.T) {
+	kafkaErr := error(nil)
+	checker := health.NewChecker()
+	checker.Add("kafka", func(context.Context) error { return kafkaErr })
+
+	app := fiber.New()
+	RegisterRoutes(app, checker)
+
+	tests := []struct {
+		name         string
+		mockPath     string
+		mockKafkaErr error
+		mockShutdown bool
+
+		wantStatus int
+		wantReport health.Report
+	}{
+		{
+			name:       "Test should be alive",
+			mockPath:   "/healthz",
+			wantStatus: fiber.StatusOK,
+			wantReport: health.Report{Status: health.StatusOK},
+		},
+		{
+			name:       "Test should be ready when kafka is reachable",
+			mockPath:   "/readyz",
+			wantStatus: fiber.StatusOK,
+			wantReport: health.Report{Status: health.StatusOK, Checks: map[string]string{"kafka": health.StatusOK}},
+		},
+		{
+			name:         "Test should not be ready when kafka is unreachable",
+			mockPath:     "/readyz",
+			mockKafkaErr: errors.New("client has run out of available brokers"),
+			wantStatus:   fiber.StatusServiceUnavailable,
+			wantReport:   health.Report{Status: health.StatusUnavailable, Checks: map[string]string{"kafka": "client has run out of available brokers"}},
+		},
+		{
+			name:         "Test should stay alive but not ready while shutting down",
+			mockPath:     "/readyz",
+			mockShutdown: true,
+			wantStatus:   fiber.StatusServiceUnavailable,
+			wantReport:   health.Report{Status: health.StatusShutdown},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kafkaErr = test.mockKafkaErr
+			if test.mockShutdown {
+				checker.Shutdown()
+			}
+
+			response, err := app.Test(httptest.NewRequest(fiber.MethodGet, test.mockPath, nil))
+			assert.NoError(t, err)
+
+			report := health.Report{}
+			assert.NoError(t, json.NewDecoder(response.Body).Decode(&report))
+			assert.Equal(t, test.wantStatus, response.StatusCode)
+			assert.Equal(t, test.wantReport, report)
+		})
+	}
+}
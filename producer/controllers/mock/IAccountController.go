@@ -12,6 +12,20 @@ type IAccountController struct {
 	mock.Mock
 }
 
+// AdjustBalance provides a mock function with given fields: c
+func (_m *IAccountController) AdjustBalance(c *fiber.Ctx) error {
+	ret := _m.Called(c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*fiber.Ctx) error); ok {
+		r0 = rf(c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CloseAccount provides a mock function with given fields: c
 func (_m *IAccountController) CloseAccount(c *fiber.Ctx) error {
 	ret := _m.Called(c)
@@ -0,0 +1,87 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"reflect"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ErrConcurrencyConflict means the stream moved past the expected version
+// between loading it and appending to it.
+var ErrConcurrencyConflict = errors.New("concurrency conflict")
+
+// ErrSnapshotNotFound means the stream has no snapshot yet.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot is the state of a stream after its first Version events.
+type Snapshot struct {
+	StreamID string
+	Version  int
+	State    []byte
+}
+
+// IEventStore keeps one ordered stream of events per account. The first
+// event of a stream has version 1.
+type IEventStore interface {
+	// Load returns the events of a stream after afterVersion, oldest first.
+	Load(ctx context.Context, streamID string, afterVersion int) ([]events.Event, error)
+	// Append adds events to a stream, all or none, if the stream is still at
+	// expectedVersion, and returns ErrConcurrencyConflict otherwise. The
+	// events are added to the outbox along with them.
+	Append(ctx context.Context, streamID string, expectedVersion int, events []events.Event) error
+	LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error)
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+	// Unsent returns up to limit outbox entries not yet marked sent, of
+	// every stream when streamID is empty. A stream's entries come in
+	// version order.
+	Unsent(ctx context.Context, streamID string, limit int) ([]OutboxEntry, error)
+	// UnsentStreams returns up to limit IDs of the streams with outbox
+	// entries not yet marked sent, in ID order after afterStreamID.
+	UnsentStreams(ctx context.Context, afterStreamID string, limit int) ([]string, error)
+	MarkSent(ctx context.Context, streamID string, version int) error
+}
+
+// OutboxEntry is an appended event waiting to be published, with the
+// headers of the request that appended it.
+type OutboxEntry struct {
+	StreamID string
+	Version  int
+	Event    events.Event
+	Headers  map[string]string
+}
+
+// outboxHeaders returns the metadata and trace context of ctx as headers,
+// so an event published later still belongs to the request that appended
+// it.
+func outboxHeaders(ctx context.Context) map[string]string {
+	headers := events.MetadataFromContext(ctx).Headers()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+func eventType(event events.Event) string {
+	return reflect.TypeOf(event).Name()
+}
+
+func encode(event events.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// decode returns the event stored as eventType, as a value like the ones
+// that were appended.
+func decode(eventType string, data []byte) (events.Event, error) {
+	event, err := events.New(eventType)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, event)
+	if err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(event).Elem().Interface(), nil
+}
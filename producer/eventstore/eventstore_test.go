@@ -0,0 +1,163 @@
+package eventstore_test
+
+import (
+	"context"
+	"events"
+	"fmt"
+	"producer/eventstore"
+	"producer/internal"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var databases int64
+
+var stores = map[string]func(t *testing.T) eventstore.IEventStore{
+	"memory": func(t *testing.T) eventstore.IEventStore {
+		return eventstore.NewMemoryEventStore()
+	},
+	"sqlite": func(t *testing.T) eventstore.IEventStore {
+		return eventstore.NewSQLEventStore(internal.OpenSQLiteDB(fmt.Sprintf("eventstore_%d", atomic.AddInt64(&databases, 1))))
+	},
+}
+
+func Test_EventStore(t *testing.T) {
+	ctx := context.Background()
+	opened := events.OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000}
+	deposited := events.DepositFundEvent{ID: "123", Amount: 100}
+	closed := events.CloseAccountEvent{ID: "123"}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Test should load appended events in order after a version", func(t *testing.T) {
+				store := newStore(t)
+
+				assert.NoError(t, store.Append(ctx, "123", 0, []events.Event{opened, deposited}))
+				assert.NoError(t, store.Append(ctx, "123", 2, []events.Event{closed}))
+				assert.NoError(t, store.Append(ctx, "456", 0, []events.Event{events.OpenAccountEvent{ID: "456"}}))
+
+				stream, err := store.Load(ctx, "123", 0)
+				assert.NoError(t, err)
+				assert.Equal(t, []events.Event{opened, deposited, closed}, stream)
+				stream, err = store.Load(ctx, "123", 2)
+				assert.NoError(t, err)
+				assert.Equal(t, []events.Event{closed}, stream)
+				stream, err = store.Load(ctx, "789", 0)
+				assert.NoError(t, err)
+				assert.Empty(t, stream)
+			})
+
+			t.Run("Test should reject an append at a stale version", func(t *testing.T) {
+				store := newStore(t)
+				assert.NoError(t, store.Append(ctx, "123", 0, []events.Event{opened}))
+
+				assert.ErrorIs(t, store.Append(ctx, "123", 0, []events.Event{opened}), eventstore.ErrConcurrencyConflict)
+				assert.ErrorIs(t, store.Append(ctx, "123", 2, []events.Event{deposited}), eventstore.ErrConcurrencyConflict)
+
+				stream, err := store.Load(ctx, "123", 0)
+				assert.NoError(t, err)
+				assert.Equal(t, []events.Event{opened}, stream)
+			})
+
+			t.Run("Test should let one of concurrent appends win", func(t *testing.T) {
+				store := newStore(t)
+				assert.NoError(t, store.Append(ctx, "123", 0, []events.Event{opened}))
+
+				errs := make([]error, 5)
+				wait := sync.WaitGroup{}
+				for i := range errs {
+					wait.Add(1)
+					go func(i int) {
+						defer wait.Done()
+						errs[i] = store.Append(ctx, "123", 1, []events.Event{deposited})
+					}(i)
+				}
+				wait.Wait()
+
+				succeeded := 0
+				for _, err := range errs {
+					if err == nil {
+						succeeded++
+					}
+				}
+				assert.Equal(t, 1, succeeded, errs)
+				stream, err := store.Load(ctx, "123", 0)
+				assert.NoError(t, err)
+				assert.Len(t, stream, 2)
+			})
+
+			t.Run("Test should keep appended events in the outbox until sent", func(t *testing.T) {
+				store := newStore(t)
+				requestCtx := events.ContextWithMetadata(ctx, events.Metadata{RequestID: "request", CorrelationID: "correlation"})
+				headers := map[string]string{events.RequestIDHeader: "request", events.CorrelationIDHeader: "correlation"}
+
+				assert.NoError(t, store.Append(requestCtx, "123", 0, []events.Event{opened, deposited}))
+				assert.NoError(t, store.Append(ctx, "456", 0, []events.Event{events.OpenAccountEvent{ID: "456"}}))
+				assert.ErrorIs(t, store.Append(ctx, "123", 0, []events.Event{opened}), eventstore.ErrConcurrencyConflict)
+
+				unsent, err := store.Unsent(ctx, "123", 10)
+				assert.NoError(t, err)
+				assert.Equal(t, []eventstore.OutboxEntry{
+					{StreamID: "123", Version: 1, Event: opened, Headers: headers},
+					{StreamID: "123", Version: 2, Event: deposited, Headers: headers},
+				}, unsent)
+				unsent, err = store.Unsent(ctx, "", 10)
+				assert.NoError(t, err)
+				assert.Len(t, unsent, 3)
+				unsent, err = store.Unsent(ctx, "", 2)
+				assert.NoError(t, err)
+				assert.Len(t, unsent, 2)
+				streamIDs, err := store.UnsentStreams(ctx, "", 10)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"123", "456"}, streamIDs)
+				streamIDs, err = store.UnsentStreams(ctx, "123", 10)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"456"}, streamIDs)
+				streamIDs, err = store.UnsentStreams(ctx, "", 1)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"123"}, streamIDs)
+
+				assert.NoError(t, store.MarkSent(ctx, "123", 1))
+				assert.NoError(t, store.Append(ctx, "123", 2, []events.Event{closed}))
+
+				unsent, err = store.Unsent(ctx, "123", 10)
+				assert.NoError(t, err)
+				assert.Equal(t, []eventstore.OutboxEntry{
+					{StreamID: "123", Version: 2, Event: deposited, Headers: headers},
+					{StreamID: "123", Version: 3, Event: closed, Headers: map[string]string{}},
+				}, unsent)
+			})
+
+			t.Run("Test should keep the latest snapshot", func(t *testing.T) {
+				store := newStore(t)
+
+				_, err := store.LoadSnapshot(ctx, "123")
+				assert.ErrorIs(t, err, eventstore.ErrSnapshotNotFound)
+
+				assert.NoError(t, store.SaveSnapshot(ctx, eventstore.Snapshot{StreamID: "123", Version: 100, State: []byte(`{"Balance":1}`)}))
+				assert.NoError(t, store.SaveSnapshot(ctx, eventstore.Snapshot{StreamID: "123", Version: 200, State: []byte(`{"Balance":2}`)}))
+
+				snapshot, err := store.LoadSnapshot(ctx, "123")
+				assert.NoError(t, err)
+				assert.Equal(t, eventstore.Snapshot{StreamID: "123", Version: 200, State: []byte(`{"Balance":2}`)}, snapshot)
+			})
+		})
+	}
+}
+
+func Test_sqlEventStore_Append_outbox(t *testing.T) {
+	ctx := context.Background()
+	db := internal.OpenSQLiteDB("eventstore_outbox")
+	store := eventstore.NewSQLEventStore(db)
+	assert.NoError(t, db.Migrator().DropTable("account_outbox"))
+
+	assert.Error(t, store.Append(ctx, "123", 0, []events.Event{events.OpenAccountEvent{ID: "123"}}))
+
+	// the events are not stored without their outbox rows
+	stream, err := store.Load(ctx, "123", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, stream)
+}
@@ -0,0 +1,115 @@
+package eventstore
+
+import (
+	"context"
+	"events"
+	"sort"
+	"sync"
+)
+
+type memoryEventStore struct {
+	mutex     sync.Mutex
+	streams   map[string][]events.Event
+	snapshots map[string]Snapshot
+	// outbox holds the unsent entries in the order they were appended.
+	outbox []OutboxEntry
+}
+
+// NewMemoryEventStore keeps events in process, for tests and local runs
+// that don't need them to outlive the process.
+func NewMemoryEventStore() IEventStore {
+	return &memoryEventStore{streams: map[string][]events.Event{}, snapshots: map[string]Snapshot{}}
+}
+
+func (obj *memoryEventStore) Load(ctx context.Context, streamID string, afterVersion int) ([]events.Event, error) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	stream := obj.streams[streamID]
+	if afterVersion >= len(stream) {
+		return nil, nil
+	}
+	return append([]events.Event(nil), stream[afterVersion:]...), nil
+}
+
+func (obj *memoryEventStore) Append(ctx context.Context, streamID string, expectedVersion int, events []events.Event) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	if len(obj.streams[streamID]) != expectedVersion {
+		return ErrConcurrencyConflict
+	}
+	obj.streams[streamID] = append(obj.streams[streamID], events...)
+	headers := outboxHeaders(ctx)
+	for i, event := range events {
+		obj.outbox = append(obj.outbox, OutboxEntry{streamID, expectedVersion + i + 1, event, headers})
+	}
+	return nil
+}
+
+func (obj *memoryEventStore) LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	snapshot, ok := obj.snapshots[streamID]
+	if !ok {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func (obj *memoryEventStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	obj.snapshots[snapshot.StreamID] = snapshot
+	return nil
+}
+
+func (obj *memoryEventStore) Unsent(ctx context.Context, streamID string, limit int) ([]OutboxEntry, error) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	var entries []OutboxEntry
+	for _, entry := range obj.outbox {
+		if len(entries) == limit {
+			break
+		}
+		if streamID == "" || entry.StreamID == streamID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (obj *memoryEventStore) UnsentStreams(ctx context.Context, afterStreamID string, limit int) ([]string, error) {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	seen := map[string]bool{}
+	var streamIDs []string
+	for _, entry := range obj.outbox {
+		if entry.StreamID > afterStreamID && !seen[entry.StreamID] {
+			seen[entry.StreamID] = true
+			streamIDs = append(streamIDs, entry.StreamID)
+		}
+	}
+	sort.Strings(streamIDs)
+	if len(streamIDs) > limit {
+		streamIDs = streamIDs[:limit]
+	}
+	return streamIDs, nil
+}
+
+func (obj *memoryEventStore) MarkSent(ctx context.Context, streamID string, version int) error {
+	obj.mutex.Lock()
+	defer obj.mutex.Unlock()
+
+	for i, entry := range obj.outbox {
+		if entry.StreamID == streamID && entry.Version == version {
+			obj.outbox = append(obj.outbox[:i], obj.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
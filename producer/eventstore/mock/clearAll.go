@@ -0,0 +1,7 @@
+package mockEventStore
+
+import "github.com/stretchr/testify/mock"
+
+func (m *IEventStore) ClearAll() {
+	m.Mock = mock.Mock{}
+}
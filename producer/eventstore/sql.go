@@ -0,0 +1,186 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"events"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	eventsTable    = "account_events"
+	snapshotsTable = "account_snapshots"
+	outboxTable    = "account_outbox"
+)
+
+// recordedAtLayout is fixed width UTC, so the stored text sorts in time
+// order.
+const recordedAtLayout = "2006-01-02T15:04:05.000000Z"
+
+type eventRow struct {
+	StreamID   string
+	Version    int
+	EventType  string
+	Data       string
+	RecordedAt string
+}
+
+type outboxRow struct {
+	StreamID   string
+	Version    int
+	EventType  string
+	Data       string
+	Headers    string
+	RecordedAt string
+	SentAt     *string
+}
+
+type snapshotRow struct {
+	StreamID string `gorm:"primaryKey"`
+	Version  int
+	State    string
+	TakenAt  string
+}
+
+type sqlEventStore struct {
+	db *gorm.DB
+}
+
+// NewSQLEventStore keeps events in the tables of producer/migrations.
+func NewSQLEventStore(db *gorm.DB) IEventStore {
+	return sqlEventStore{db}
+}
+
+func (obj sqlEventStore) Load(ctx context.Context, streamID string, afterVersion int) ([]events.Event, error) {
+	var rows []eventRow
+	err := obj.db.WithContext(ctx).Table(eventsTable).
+		Where("stream_id = ? AND version > ?", streamID, afterVersion).
+		Order("version").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var stream []events.Event
+	for _, row := range rows {
+		event, err := decode(row.EventType, []byte(row.Data))
+		if err != nil {
+			return nil, err
+		}
+		stream = append(stream, event)
+	}
+	return stream, nil
+}
+
+func (obj sqlEventStore) version(tx *gorm.DB, streamID string) (int, error) {
+	var version int
+	err := tx.Table(eventsTable).Where("stream_id = ?", streamID).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+func (obj sqlEventStore) Append(ctx context.Context, streamID string, expectedVersion int, events []events.Event) error {
+	recordedAt := time.Now().UTC().Format(recordedAtLayout)
+	headers, err := json.Marshal(outboxHeaders(ctx))
+	if err != nil {
+		return err
+	}
+	var rows []eventRow
+	var outboxRows []outboxRow
+	for i, event := range events {
+		data, err := encode(event)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, eventRow{streamID, expectedVersion + i + 1, eventType(event), string(data), recordedAt})
+		outboxRows = append(outboxRows, outboxRow{streamID, expectedVersion + i + 1, eventType(event), string(data), string(headers), recordedAt, nil})
+	}
+
+	err = obj.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		version, err := obj.version(tx, streamID)
+		if err != nil {
+			return err
+		}
+		if version != expectedVersion {
+			return ErrConcurrencyConflict
+		}
+		err = tx.Table(eventsTable).Create(&rows).Error
+		if err != nil {
+			return err
+		}
+		return tx.Table(outboxTable).Create(&outboxRows).Error
+	})
+	if err != nil && !errors.Is(err, ErrConcurrencyConflict) {
+		// A concurrent append that passed the same check fails on the
+		// primary key instead, which drivers report differently.
+		version, versionErr := obj.version(obj.db.WithContext(ctx), streamID)
+		if versionErr == nil && version != expectedVersion {
+			return ErrConcurrencyConflict
+		}
+	}
+	return err
+}
+
+func (obj sqlEventStore) LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error) {
+	var rows []snapshotRow
+	err := obj.db.WithContext(ctx).Table(snapshotsTable).Where("stream_id = ?", streamID).Limit(1).Find(&rows).Error
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if len(rows) == 0 {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	return Snapshot{rows[0].StreamID, rows[0].Version, []byte(rows[0].State)}, nil
+}
+
+func (obj sqlEventStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	row := snapshotRow{snapshot.StreamID, snapshot.Version, string(snapshot.State), time.Now().UTC().Format(time.RFC3339Nano)}
+	return obj.db.WithContext(ctx).Table(snapshotsTable).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "stream_id"}}, UpdateAll: true}).
+		Create(&row).Error
+}
+
+func (obj sqlEventStore) Unsent(ctx context.Context, streamID string, limit int) ([]OutboxEntry, error) {
+	query := obj.db.WithContext(ctx).Table(outboxTable).Where("sent_at IS NULL")
+	if streamID != "" {
+		query = query.Where("stream_id = ?", streamID)
+	}
+	var rows []outboxRow
+	err := query.Order("recorded_at").Order("stream_id").Order("version").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []OutboxEntry
+	for _, row := range rows {
+		event, err := decode(row.EventType, []byte(row.Data))
+		if err != nil {
+			return nil, err
+		}
+		headers := map[string]string{}
+		err = json.Unmarshal([]byte(row.Headers), &headers)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, OutboxEntry{row.StreamID, row.Version, event, headers})
+	}
+	return entries, nil
+}
+
+func (obj sqlEventStore) UnsentStreams(ctx context.Context, afterStreamID string, limit int) ([]string, error) {
+	var streamIDs []string
+	err := obj.db.WithContext(ctx).Table(outboxTable).
+		Where("sent_at IS NULL AND stream_id > ?", afterStreamID).
+		Distinct("stream_id").Order("stream_id").Limit(limit).
+		Pluck("stream_id", &streamIDs).Error
+	return streamIDs, err
+}
+
+func (obj sqlEventStore) MarkSent(ctx context.Context, streamID string, version int) error {
+	return obj.db.WithContext(ctx).Table(outboxTable).
+		Where("stream_id = ? AND version = ?", streamID, version).
+		Update("sent_at", time.Now().UTC().Format(recordedAtLayout)).Error
+}
@@ -2,9 +2,18 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"events"
+	"fmt"
 	"net/http"
+	"producer/eventstore"
+	"producer/migrations"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 func MarshalJSONData(req interface{}) []byte {
@@ -39,3 +48,45 @@ func Min(a, b int) int {
 	}
 	return b
 }
+
+// OpenSQLiteDB opens a private in-memory SQLite database named after name
+// and migrates it, so every test gets its own empty event store.
+func OpenSQLiteDB(name string) *gorm.DB {
+	dsn := fmt.Sprintf("file:%v?mode=memory&cache=shared", name)
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		panic(err)
+	}
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// NewEventStore returns an in-memory event store holding streams, keyed by
+// account ID, as already produced.
+func NewEventStore(streams map[string][]events.Event) eventstore.IEventStore {
+	store := eventstore.NewMemoryEventStore()
+	for id, stream := range streams {
+		err := store.Append(context.Background(), id, 0, stream)
+		if err != nil {
+			panic(err)
+		}
+		for version := range stream {
+			err = store.MarkSent(context.Background(), id, version+1)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	return store
+}
@@ -1,44 +1,255 @@
 package main
 
 import (
+	"context"
+	"events"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"platform/database"
+	"platform/health"
+	"platform/kafka"
+	"platform/logging"
+	"platform/shutdown"
+	"platform/tracing"
+	"producer/aggregates"
+	"producer/config"
 	accountcontrollers "producer/controllers/account"
+	healthcontrollers "producer/controllers/health"
+	"producer/eventstore"
+	"producer/metrics"
+	"producer/middlewares"
+	"producer/migrations"
 	accountservice "producer/services/account"
+	outboxservice "producer/services/outbox"
 	eventproducerservice "producer/services/producer"
-	"strings"
+	"syscall"
 
 	"github.com/Shopify/sarama"
 	"github.com/gofiber/fiber/v2"
-	"github.com/spf13/viper"
+	"gorm.io/gorm"
 )
 
-func init() {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	if err := viper.ReadInConfig(); err != nil {
+func initDatabase(cfg config.Config) *gorm.DB {
+	db, err := database.Open(cfg.DB)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// migrate applies pending migrations when the config allows it at startup,
+// and otherwise refuses to run against an outdated schema.
+func migrate(cfg config.Config, db *gorm.DB, logger *slog.Logger) {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if !cfg.DB.MigrateOnStart {
+		pending, err := migrator.Pending(context.Background())
+		if err != nil {
+			panic(err)
+		}
+		if len(pending) > 0 {
+			panic(fmt.Errorf("%d pending migrations, run `producer migrate up` first", len(pending)))
+		}
+		return
+	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, migration := range applied {
+		logger.Info("applied migration", "version", migration.Version, "name", migration.Name)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+// provision ensures the declared topics at startup when the config allows
+// it. Drift it cannot fix is only logged.
+func provision(cfg config.Config, client sarama.Client, logger *slog.Logger) {
+	if !cfg.Kafka.Topics.Provision {
+		return
+	}
+
+	// closing the admin would close the shared client
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		panic(err)
+	}
+	changes, err := kafka.ProvisionTopics(admin, cfg.Kafka.Topics.Resolve(events.Topics), true)
+	for _, change := range changes {
+		attrs := []any{"topic", change.Topic, "setting", change.Setting, "have", change.Have, "want", change.Want}
+		if change.Status == kafka.ChangeManual {
+			logger.Warn("topic drift needs a manual fix", attrs...)
+			continue
+		}
+		logger.Info("topic provisioned", attrs...)
+	}
+	if err != nil {
 		panic(err)
 	}
 }
 
+// subcommands run instead of the producer when named by the first argument.
+var subcommands = map[string]func(cfg config.Config, args []string) error{
+	"migrate":   runMigrate,
+	"provision": runProvision,
+}
+
+func runMigrate(cfg config.Config, args []string) error {
+	migrator, err := migrations.New(initDatabase(cfg))
+	if err != nil {
+		return err
+	}
+	return migrator.Command(context.Background(), args, os.Stdout)
+}
+
+func runProvision(cfg config.Config, args []string) error {
+	saramaConfig, err := cfg.Kafka.Sarama()
+	if err != nil {
+		return err
+	}
+	admin, err := sarama.NewClusterAdmin(cfg.Kafka.Servers, saramaConfig)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	return kafka.ProvisionCommand(admin, cfg.Kafka.Topics.Resolve(events.Topics), args, os.Stdout)
+}
+
 func main() {
-	producer, err := sarama.NewSyncProducer(viper.GetStringSlice("kafka.servers"), nil)
+	cfg, err := config.Load(".", os.Getenv("APP_PROFILE"))
+	if err != nil {
+		panic(err)
+	}
+
+	logger, err := logging.New(os.Stdout, logging.Config{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	})
+	if err != nil {
+		panic(err)
+	}
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 && subcommands[os.Args[1]] != nil {
+		err := subcommands[os.Args[1]](cfg, os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: "producer",
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	saramaConfig, err := cfg.Kafka.Sarama()
+	if err != nil {
+		panic(err)
+	}
+
+	client, err := sarama.NewClient(cfg.Kafka.Servers, saramaConfig)
+	if err != nil {
+		panic(err)
+	}
+	provision(cfg, client, logger)
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		panic(err)
+	}
+
+	db := initDatabase(cfg)
+	migrate(cfg, db, logger)
+
+	sqlDB, err := db.DB()
 	if err != nil {
 		panic(err)
 	}
-	defer producer.Close()
 
-	eventProducer := eventproducerservice.NewEventProducer(producer)
-	accountService := accountservice.NewAccountService(eventProducer)
-	accountController := accountcontrollers.NewAccountController(accountService)
+	checker := health.NewChecker()
+	checker.Add("kafka", kafka.Check(client))
+	checker.Add("db", database.Check(db))
+
+	registry := events.NewSchemaRegistry(cfg.SchemaRegistry.URL, cfg.SchemaRegistry.File)
+	if registry != nil {
+		events.RegisterCodec(events.AvroCodec{Registry: registry})
+	}
+
+	codec, err := events.CodecFor(cfg.Kafka.ContentType)
+	if err != nil {
+		panic(err)
+	}
+
+	producerMetrics := metrics.New()
+	eventProducer := eventproducerservice.NewInstrumentedEventProducer(eventproducerservice.NewEventProducer(producer, codec), producerMetrics)
+	store := eventstore.NewSQLEventStore(db)
+	relay := outboxservice.NewRelay(store, eventProducer, cfg.Outbox.BatchSize, logger)
+	accountRepository := aggregates.NewAccountRepository(store, cfg.EventStore.SnapshotEvery, logger)
+	accountService := accountservice.NewAccountService(relay, accountRepository, logger)
+	accountController := accountcontrollers.NewAccountController(accountService, logger)
 
 	app := fiber.New()
+	app.Get("/metrics", producerMetrics.Handler())
+	healthcontrollers.RegisterRoutes(app, checker)
+	app.Use(middlewares.Metrics(producerMetrics))
+	app.Use(middlewares.Tracing())
+	app.Use(middlewares.Metadata())
+
+	accountcontrollers.RegisterRoutes(app, accountController)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	app.Post("/openAccount", accountController.OpenAccount)
-	app.Post("/depositFund", accountController.DepositFund)
-	app.Post("/withdrawFund", accountController.WithdrawFund)
-	app.Post("/closeAccount", accountController.CloseAccount)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(ctx, cfg.Outbox.Interval)
+	}()
 
-	app.Listen(":8000")
+	go func() {
+		err := app.Listen(cfg.Server.Address)
+		if err != nil {
+			logger.Error("server stopped", "error", err)
+		}
+		stop()
+	}()
+
+	<-ctx.Done()
+	logger.Info("shutting down", "timeout", cfg.Shutdown.Timeout)
+	checker.Shutdown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+	err = shutdown.Run(shutdownCtx,
+		// drain in-flight requests before closing the producer they use
+		shutdown.Step{Name: "http server", Run: func(context.Context) error { return app.Shutdown() }},
+		shutdown.Step{Name: "outbox relay", Run: func(ctx context.Context) error {
+			select {
+			case <-relayDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}},
+		shutdown.Close("kafka producer", producer),
+		shutdown.Close("kafka client", client),
+		shutdown.Close("database", sqlDB),
+		shutdown.Step{Name: "tracing", Run: shutdownTracing},
+	)
+	if err != nil {
+		logger.Error("shutdown incomplete", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("shutdown complete")
 }
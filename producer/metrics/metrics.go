@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// Metrics holds the producer collectors on a registry of its own, so tests
+// can build one per case and read it back with testutil.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	HTTPRequestDuration *prometheus.HistogramVec
+	EventsProduced      *prometheus.CounterVec
+	ProduceErrors       *prometheus.CounterVec
+	ProduceDuration     *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	obj := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency, by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		EventsProduced: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "producer_events_total",
+			Help: "Events produced to Kafka, by topic.",
+		}, []string{"topic"}),
+		ProduceErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "producer_errors_total",
+			Help: "Events that failed to encode or send, by topic.",
+		}, []string{"topic"}),
+		ProduceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "producer_send_duration_seconds",
+			Help:    "Time spent encoding and sending an event, by topic.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"topic"}),
+	}
+	obj.Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		obj.HTTPRequestDuration,
+		obj.EventsProduced,
+		obj.ProduceErrors,
+		obj.ProduceDuration,
+	)
+	return obj
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (obj *Metrics) Handler() fiber.Handler {
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(obj.Registry, promhttp.HandlerOpts{}))
+	return func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	}
+}
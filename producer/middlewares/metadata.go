@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"events"
+	"platform/logging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// Metadata puts the request ID and correlation ID into the request's user
+// context, where the event producer reads them into Kafka headers and the
+// logger adds them to every record. The correlation ID falls back to the
+// request ID.
+func Metadata() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		correlationID := c.Get(CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = requestID
+		}
+		c.Set(CorrelationIDHeader, correlationID)
+
+		ctx := events.ContextWithMetadata(c.UserContext(), events.Metadata{
+			RequestID:     requestID,
+			CorrelationID: correlationID,
+		})
+		ctx = logging.ContextWith(ctx, "request_id", requestID, "correlation_id", correlationID)
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
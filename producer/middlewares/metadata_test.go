@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"events"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Metadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockHeaders map[string]string
+
+		wantRequestID     string
+		wantCorrelationID string
+	}{
+		{
+			name: "Test should keep incoming request id and correlation id",
+			mockHeaders: map[string]string{
+				fiber.HeaderXRequestID: "request",
+				CorrelationIDHeader:    "correlation",
+			},
+			wantRequestID:     "request",
+			wantCorrelationID: "correlation",
+		},
+		{
+			name: "Test should fall back to request id when correlation id is missing",
+			mockHeaders: map[string]string{
+				fiber.HeaderXRequestID: "request",
+			},
+			wantRequestID:     "request",
+			wantCorrelationID: "request",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metadata := events.Metadata{}
+			app := fiber.New()
+			app.Use(Metadata())
+			app.Post("/mock-endpoint", func(c *fiber.Ctx) error {
+				metadata = events.MetadataFromContext(c.UserContext())
+				return nil
+			})
+
+			request := httptest.NewRequest(fiber.MethodPost, "/mock-endpoint", nil)
+			for key, value := range test.mockHeaders {
+				request.Header.Set(key, value)
+			}
+			response, err := app.Test(request)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantRequestID, metadata.RequestID)
+			assert.Equal(t, test.wantCorrelationID, metadata.CorrelationID)
+			assert.Equal(t, test.wantRequestID, response.Header.Get(fiber.HeaderXRequestID))
+			assert.Equal(t, test.wantCorrelationID, response.Header.Get(CorrelationIDHeader))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"errors"
+	"producer/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Metrics observes the latency of every request by method, route and
+// status. The route is the registered path, so path parameters do not
+// create a series per account.
+func Metrics(metrics *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		// the error handler sets the status of a failed request only after
+		// this returns, answering any error but a *fiber.Error with 500
+		status := c.Response().StatusCode()
+		var fiberError *fiber.Error
+		if errors.As(err, &fiberError) {
+			status = fiberError.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		// fiber reuses the request buffers, so copy the method before the
+		// registry keeps it as a label value
+		metrics.HTTPRequestDuration.
+			WithLabelValues(utils.CopyString(c.Method()), c.Route().Path, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
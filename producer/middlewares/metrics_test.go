@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"producer/metrics"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Metrics(t *testing.T) {
+	producerMetrics := metrics.New()
+	app := fiber.New()
+	app.Use(Metrics(producerMetrics))
+	app.Get("/metrics", producerMetrics.Handler())
+	app.Put("/mock-endpoint/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return fiber.NewError(fiber.StatusNotFound)
+		}
+		if c.Params("id") == "broken" {
+			return errors.New("error")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/mock-endpoint/1", "/mock-endpoint/2", "/mock-endpoint/missing", "/mock-endpoint/broken"} {
+		_, err := app.Test(httptest.NewRequest(fiber.MethodPut, path, nil))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, testutil.CollectAndCount(producerMetrics.HTTPRequestDuration))
+
+	response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(response.Body)
+	assert.True(t, strings.Contains(string(body), `http_request_duration_seconds_count{method="PUT",route="/mock-endpoint/:id",status="200"} 2`))
+	assert.True(t, strings.Contains(string(body), `http_request_duration_seconds_count{method="PUT",route="/mock-endpoint/:id",status="404"} 1`))
+	assert.True(t, strings.Contains(string(body), `http_request_duration_seconds_count{method="PUT",route="/mock-endpoint/:id",status="500"} 1`))
+}
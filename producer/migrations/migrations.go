@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+
+	"platform/migrations"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Table records the applied migrations apart from the consumer's, so both
+// services can share a database.
+const Table = "event_store_migrations"
+
+// Source returns the producer's migrations.
+func Source() migrations.Source {
+	sql, _ := fs.Sub(files, "sql")
+	return migrations.Source{Files: sql, Table: Table}
+}
+
+// New loads the migrations for db's driver.
+func New(db *gorm.DB) (*migrations.Migrator, error) {
+	return migrations.New(db, Source())
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_snapshots;
+DROP TABLE IF EXISTS account_events;
@@ -0,0 +1,17 @@
+-- One row per account event; the primary key rejects a second event with
+-- the same version, which backs the event store's concurrency check.
+CREATE TABLE IF NOT EXISTS account_events (
+	stream_id VARCHAR(191) NOT NULL,
+	version BIGINT NOT NULL,
+	event_type VARCHAR(191) NOT NULL,
+	data TEXT NOT NULL,
+	recorded_at VARCHAR(64) NOT NULL,
+	PRIMARY KEY (stream_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS account_snapshots (
+	stream_id VARCHAR(191) NOT NULL PRIMARY KEY,
+	version BIGINT NOT NULL,
+	state TEXT NOT NULL,
+	taken_at VARCHAR(64) NOT NULL
+);
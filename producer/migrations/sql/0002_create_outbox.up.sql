@@ -0,0 +1,17 @@
+-- One row per appended event, written in the same transaction as
+-- account_events, until the relay publishes it and sets sent_at. headers
+-- holds the metadata and trace context of the request that appended it.
+-- recorded_at is fixed width UTC text, which sorts the same way in every
+-- driver.
+CREATE TABLE IF NOT EXISTS account_outbox (
+	stream_id VARCHAR(191) NOT NULL,
+	version BIGINT NOT NULL,
+	event_type VARCHAR(191) NOT NULL,
+	data TEXT NOT NULL,
+	headers TEXT NOT NULL,
+	recorded_at VARCHAR(32) NOT NULL,
+	sent_at VARCHAR(32),
+	PRIMARY KEY (stream_id, version)
+);
+
+CREATE INDEX account_outbox_unsent ON account_outbox (sent_at, recorded_at);
@@ -0,0 +1,167 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Record is one line of a replay corpus.
+type Record struct {
+	Method         string
+	Path           string
+	Body           json.RawMessage
+	ExpectedStatus int
+
+	// Line is the corpus line the record was read from.
+	Line int `json:"-"`
+}
+
+type Mismatch struct {
+	Record    Record
+	GotStatus int
+	GotBody   string
+	Error     string
+}
+
+type Report struct {
+	Total      int
+	Passed     int
+	Mismatches []Mismatch
+}
+
+// ITarget sends a request to the producer. *http.Client satisfies it for a
+// running producer, NewFiberTarget for an in-process app.
+type ITarget interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type fiberTarget struct {
+	app *fiber.App
+}
+
+func NewFiberTarget(app *fiber.App) ITarget {
+	return fiberTarget{app}
+}
+
+func (obj fiberTarget) Do(req *http.Request) (*http.Response, error) {
+	return obj.app.Test(req, -1)
+}
+
+type Options struct {
+	// BaseURL is prepended to every record path, e.g. http://localhost:8000.
+	BaseURL string
+	// Rate caps requests per second across all workers; zero means unlimited.
+	Rate float64
+	// Concurrency is the number of workers sending requests; defaults to 1.
+	Concurrency int
+}
+
+// ReadRecords parses a JSONL corpus, skipping blank lines.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		record := Record{Line: line}
+		err := json.Unmarshal([]byte(text), &record)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+		if record.Method == "" || record.Path == "" {
+			return nil, fmt.Errorf("line %v: method and path are required", line)
+		}
+		if record.ExpectedStatus == 0 {
+			return nil, fmt.Errorf("line %v: expected status is required", line)
+		}
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// Run sends every record to target and reports the ones whose status code
+// does not match ExpectedStatus. Records are dispatched in corpus order, but
+// with more than one worker they may complete out of order.
+func Run(target ITarget, records []Record, options Options) Report {
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var tick <-chan time.Time
+	if options.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / options.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	jobs := make(chan int)
+	mismatches := make([]*Mismatch, len(records))
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				mismatches[index] = send(target, options.BaseURL, records[index])
+			}
+		}()
+	}
+
+	for index := range records {
+		if tick != nil && index > 0 {
+			<-tick
+		}
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := Report{Total: len(records), Mismatches: []Mismatch{}}
+	for _, mismatch := range mismatches {
+		if mismatch == nil {
+			report.Passed++
+			continue
+		}
+		report.Mismatches = append(report.Mismatches, *mismatch)
+	}
+
+	return report
+}
+
+func send(target ITarget, baseURL string, record Record) *Mismatch {
+	req, err := http.NewRequest(record.Method, baseURL+record.Path, bytes.NewReader(record.Body))
+	if err != nil {
+		return &Mismatch{Record: record, Error: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := target.Do(req)
+	if err != nil {
+		return &Mismatch{Record: record, Error: err.Error()}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == record.ExpectedStatus {
+		return nil
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	return &Mismatch{Record: record, GotStatus: res.StatusCode, GotBody: string(body)}
+}
@@ -0,0 +1,140 @@
+package replay
+
+import (
+	"errors"
+	"os"
+	"platform/logging"
+	"producer/commands"
+	accountcontrollers "producer/controllers/account"
+	mockService "producer/services/mock"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ReadRecords(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockCorpus  string
+		wantError   string
+		wantRecords int
+	}{
+		{
+			name:       "Test should return error when line is not json",
+			mockCorpus: "{\"Method\":\"POST\",\"Path\":\"/openAccount\",\"ExpectedStatus\":201}\nnot json\n",
+			wantError:  "line 2",
+		},
+		{
+			name:       "Test should return error when path is missing",
+			mockCorpus: "{\"Method\":\"POST\"}\n",
+			wantError:  "line 1: method and path are required",
+		},
+		{
+			name:       "Test should return error when expected status is missing",
+			mockCorpus: "{\"Method\":\"POST\",\"Path\":\"/openAccount\",\"ExpectedStatus\":201}\n{\"Method\":\"POST\",\"Path\":\"/openAccount\"}\n",
+			wantError:  "line 2: expected status is required",
+		},
+		{
+			name:        "Test should skip blank lines",
+			mockCorpus:  "\n{\"Method\":\"POST\",\"Path\":\"/openAccount\",\"ExpectedStatus\":201}\n\n",
+			wantRecords: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := ReadRecords(strings.NewReader(test.mockCorpus))
+
+			if test.wantError != "" {
+				assert.ErrorContains(t, err, test.wantError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, records, test.wantRecords)
+		})
+	}
+}
+
+func Test_Run(t *testing.T) {
+	mockAccountService := mockService.NewIAccountService(t)
+
+	clearAllMock := func() {
+		mockAccountService.ClearAll()
+	}
+
+	corpus, err := os.Open("testdata/requests.jsonl")
+	assert.NoError(t, err)
+	defer corpus.Close()
+	records, err := ReadRecords(corpus)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		mockOptions Options
+
+		wantServiceCallWithAndResponse func()
+		wantPassed                     int
+		wantMismatchLines              []int
+	}{
+		{
+			name:        "Test should report no mismatch when every status matches",
+			mockOptions: Options{Concurrency: 2},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("OpenAccount", mock.Anything, commands.OpenAccountCommand{
+					AccountHolder:  "John Doe",
+					AccountType:    1,
+					OpeningBalance: 1000,
+				}).Return("123", nil)
+				mockAccountService.On("DepositFund", mock.Anything, commands.DepositFundCommand{ID: "123", Amount: 500}).Return(nil)
+				mockAccountService.On("WithdrawFund", mock.Anything, commands.WithdrawFundCommand{ID: "123", Amount: 200}).Return(nil)
+				mockAccountService.On("CloseAccount", mock.Anything, commands.CloseAccountCommand{ID: "123"}).Return(nil)
+			},
+			wantPassed:        4,
+			wantMismatchLines: []int{},
+		},
+		{
+			name:        "Test should report mismatch with corpus line when status differs",
+			mockOptions: Options{Rate: 1000},
+			wantServiceCallWithAndResponse: func() {
+				mockAccountService.On("OpenAccount", mock.Anything, commands.OpenAccountCommand{
+					AccountHolder:  "John Doe",
+					AccountType:    1,
+					OpeningBalance: 1000,
+				}).Return("123", nil)
+				mockAccountService.On("DepositFund", mock.Anything, commands.DepositFundCommand{ID: "123", Amount: 500}).Return(errors.New("error"))
+				mockAccountService.On("WithdrawFund", mock.Anything, commands.WithdrawFundCommand{ID: "123", Amount: 200}).Return(nil)
+				mockAccountService.On("CloseAccount", mock.Anything, commands.CloseAccountCommand{ID: "123"}).Return(nil)
+			},
+			wantPassed:        3,
+			wantMismatchLines: []int{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer clearAllMock()
+
+			if test.wantServiceCallWithAndResponse != nil {
+				test.wantServiceCallWithAndResponse()
+			}
+
+			app := fiber.New()
+			accountcontrollers.RegisterRoutes(app, accountcontrollers.NewAccountController(mockAccountService, logging.Discard()))
+
+			report := Run(NewFiberTarget(app), records, test.mockOptions)
+
+			assert.Equal(t, len(records), report.Total)
+			assert.Equal(t, test.wantPassed, report.Passed)
+
+			mismatchLines := []int{}
+			for _, mismatch := range report.Mismatches {
+				mismatchLines = append(mismatchLines, mismatch.Record.Line)
+			}
+			assert.Equal(t, test.wantMismatchLines, mismatchLines)
+		})
+	}
+}
@@ -1,84 +1,163 @@
 package accountservice
 
 import (
+	"context"
 	"errors"
 	"events"
-	"log"
+	"fmt"
+	"log/slog"
+	"producer/aggregates"
 	"producer/commands"
-	services "producer/services/producer"
+	outboxservice "producer/services/outbox"
+
+	"platform/tracing"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
 )
 
+// ErrInvalidCommand means a command lacks a field it needs.
+var ErrInvalidCommand = errors.New("invalid command")
+
 type IAccountService interface {
-	OpenAccount(command commands.OpenAccountCommand) (id string, err error)
-	DepositFund(command commands.DepositFundCommand) error
-	WithdrawFund(command commands.WithdrawFundCommand) error
-	CloseAccount(command commands.CloseAccountCommand) error
+	OpenAccount(ctx context.Context, command commands.OpenAccountCommand) (id string, err error)
+	DepositFund(ctx context.Context, command commands.DepositFundCommand) error
+	WithdrawFund(ctx context.Context, command commands.WithdrawFundCommand) error
+	CloseAccount(ctx context.Context, command commands.CloseAccountCommand) error
+	AdjustBalance(ctx context.Context, command commands.AdjustBalanceCommand) error
 }
 
 type accountService struct {
-	eventProducer services.IEventProducer
+	relay             outboxservice.IRelay
+	accountRepository aggregates.IAccountRepository
+	logger            *slog.Logger
 }
 
-func NewAccountService(eventProducer services.IEventProducer) IAccountService {
-	return accountService{eventProducer}
+// NewAccountService decides commands against the account as stored in
+// accountRepository and has relay publish the resulting event once it is
+// appended there.
+func NewAccountService(relay outboxservice.IRelay, accountRepository aggregates.IAccountRepository, logger *slog.Logger) IAccountService {
+	return accountService{relay, accountRepository, logger}
 }
 
-func (sv accountService) OpenAccount(command commands.OpenAccountCommand) (id string, err error) {
+func (sv accountService) OpenAccount(ctx context.Context, command commands.OpenAccountCommand) (id string, err error) {
+	ctx, span := otel.Tracer("producer/services/account").Start(ctx, "accountService.OpenAccount")
+	defer func() { tracing.End(span, err) }()
 
-	if command.AccountHolder == "" || command.AccountType == 0 || command.OpeningBalance == 0 {
-		return "", errors.New("bad request")
+	if command.AccountHolder == "" {
+		return "", fmt.Errorf("%w: account holder is required", ErrInvalidCommand)
+	}
+	if command.AccountType == 0 {
+		return "", fmt.Errorf("%w: account type is required", ErrInvalidCommand)
 	}
 
-	event := events.OpenAccountEvent{
-		ID:             uuid.NewString(),
-		AccountHolder:  command.AccountHolder,
-		AccountType:    command.AccountType,
-		OpeningBalance: command.OpeningBalance,
+	account := aggregates.Account{}
+	event, err := account.Open(uuid.NewString(), command.AccountHolder, command.AccountType, command.OpeningBalance)
+	if err != nil {
+		return "", err
 	}
 
-	log.Printf("%#v", event)
-	return event.AccountHolder, sv.eventProducer.Produce(event)
+	err = sv.save(ctx, &account, event)
+	return account.ID, err
 }
 
-func (sv accountService) DepositFund(command commands.DepositFundCommand) error {
-	if command.ID == "" || command.Amount == 0 {
-		return errors.New("bad request")
+func (sv accountService) DepositFund(ctx context.Context, command commands.DepositFundCommand) (err error) {
+	ctx, span := otel.Tracer("producer/services/account").Start(ctx, "accountService.DepositFund")
+	defer func() { tracing.End(span, err) }()
+
+	if command.ID == "" {
+		return fmt.Errorf("%w: account id is required", ErrInvalidCommand)
+	}
+
+	account, err := sv.accountRepository.Load(ctx, command.ID)
+	if err != nil {
+		return err
+	}
+	event, err := account.Deposit(command.Amount)
+	if err != nil {
+		return err
+	}
+
+	return sv.save(ctx, &account, event)
+}
+
+func (sv accountService) WithdrawFund(ctx context.Context, command commands.WithdrawFundCommand) (err error) {
+	ctx, span := otel.Tracer("producer/services/account").Start(ctx, "accountService.WithdrawFund")
+	defer func() { tracing.End(span, err) }()
+
+	if command.ID == "" {
+		return fmt.Errorf("%w: account id is required", ErrInvalidCommand)
 	}
 
-	event := events.DepositFundEvent{
-		ID:     command.ID,
-		Amount: command.Amount,
+	account, err := sv.accountRepository.Load(ctx, command.ID)
+	if err != nil {
+		return err
+	}
+	event, err := account.Withdraw(command.Amount)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("%#v", event)
-	return sv.eventProducer.Produce(event)
+	return sv.save(ctx, &account, event)
 }
 
-func (sv accountService) WithdrawFund(command commands.WithdrawFundCommand) error {
-	if command.ID == "" || command.Amount == 0 {
-		return errors.New("bad request")
+func (sv accountService) CloseAccount(ctx context.Context, command commands.CloseAccountCommand) (err error) {
+	ctx, span := otel.Tracer("producer/services/account").Start(ctx, "accountService.CloseAccount")
+	defer func() { tracing.End(span, err) }()
+
+	if command.ID == "" {
+		return fmt.Errorf("%w: account id is required", ErrInvalidCommand)
 	}
 
-	event := events.WithdrawFundEvent{
-		ID:     command.ID,
-		Amount: command.Amount,
+	account, err := sv.accountRepository.Load(ctx, command.ID)
+	if err != nil {
+		return err
+	}
+	event, err := account.Close()
+	if err != nil {
+		return err
 	}
 
-	log.Printf("%#v", event)
-	return sv.eventProducer.Produce(event)
+	return sv.save(ctx, &account, event)
 }
 
-func (sv accountService) CloseAccount(command commands.CloseAccountCommand) error {
+func (sv accountService) AdjustBalance(ctx context.Context, command commands.AdjustBalanceCommand) (err error) {
+	ctx, span := otel.Tracer("producer/services/account").Start(ctx, "accountService.AdjustBalance")
+	defer func() { tracing.End(span, err) }()
+
 	if command.ID == "" {
-		return errors.New("bad request")
+		return fmt.Errorf("%w: account id is required", ErrInvalidCommand)
+	}
+	if command.Reason == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidCommand)
 	}
 
-	event := events.CloseAccountEvent{
-		ID: command.ID,
+	account, err := sv.accountRepository.Load(ctx, command.ID)
+	if err != nil {
+		return err
 	}
+	event, err := account.Adjust(command.Amount, command.Reason)
+	if err != nil {
+		return err
+	}
+
+	return sv.save(ctx, &account, event)
+}
 
-	log.Printf("%#v", event)
-	return sv.eventProducer.Produce(event)
+// save appends event to the account's stream and its outbox, then relays
+// the stream, so only events that passed the concurrency check reach Kafka
+// and none is lost once appended. An event whose produce fails is left for
+// the background relay; the command still succeeded.
+func (sv accountService) save(ctx context.Context, account *aggregates.Account, event events.Event) error {
+	err := sv.accountRepository.Save(ctx, account, event)
+	if err != nil {
+		return err
+	}
+
+	sv.logger.InfoContext(ctx, "producing event", "account_id", account.ID, "version", account.Version, "event", event)
+	err = sv.relay.RelayStream(ctx, account.ID)
+	if err != nil {
+		sv.logger.WarnContext(ctx, "producing event failed, relaying it later", "account_id", account.ID, "version", account.Version, "error", err)
+	}
+	return nil
 }
@@ -0,0 +1,121 @@
+package accountservice
+
+import (
+	"context"
+	"platform/logging"
+	"producer/aggregates"
+	"producer/commands"
+	"producer/internal"
+	mockService "producer/services/mock"
+	outboxservice "producer/services/outbox"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_accountService_AdjustBalance(t *testing.T) {
+	mockEventProducer := mockService.NewIEventProducer(t)
+
+	clearAllMock := func() {
+		mockEventProducer.ClearAll()
+	}
+	tests := []struct {
+		name               string
+		mockServiceRequest commands.AdjustBalanceCommand
+
+		wantServiceOrRepoCallWithAndResponse func()
+		wantServiceOrRepoCallTimes           map[string]map[string]int
+		wantMainServiceError                 error
+	}{
+		{
+			name: "Test should return error when id request is empty",
+			mockServiceRequest: commands.AdjustBalanceCommand{
+				ID:     "",
+				Amount: -100,
+				Reason: "reconciliation",
+			},
+			wantMainServiceError: ErrInvalidCommand,
+		},
+		{
+			name: "Test should return error when reason request is empty",
+			mockServiceRequest: commands.AdjustBalanceCommand{
+				ID:     "123",
+				Amount: -100,
+			},
+			wantMainServiceError: ErrInvalidCommand,
+		},
+		{
+			name: "Test should return error when amount request is zero",
+			mockServiceRequest: commands.AdjustBalanceCommand{
+				ID:     "123",
+				Amount: 0,
+				Reason: "reconciliation",
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventProducerService": {
+					"Produce": 0,
+				},
+			},
+			wantMainServiceError: aggregates.ErrEmptyAdjustment,
+		},
+		{
+			name: "Test should return error when account is closed",
+			mockServiceRequest: commands.AdjustBalanceCommand{
+				ID:     "456",
+				Amount: -100,
+				Reason: "reconciliation",
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventProducerService": {
+					"Produce": 0,
+				},
+			},
+			wantMainServiceError: aggregates.ErrAccountClosed,
+		},
+		{
+			name: "Test should return nil when produce of event producer service return nil",
+			mockServiceRequest: commands.AdjustBalanceCommand{
+				ID:     "123",
+				Amount: -100,
+				Reason: "reconciliation",
+			},
+			wantServiceOrRepoCallWithAndResponse: func() {
+				mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(nil)
+			},
+			wantMainServiceError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer clearAllMock()
+
+			if test.wantServiceOrRepoCallWithAndResponse != nil {
+				test.wantServiceOrRepoCallWithAndResponse()
+			}
+
+			store := internal.NewEventStore(streams)
+			accountRepository := aggregates.NewAccountRepository(store, 0, logging.Discard())
+			accountService := NewAccountService(outboxservice.NewRelay(store, mockEventProducer, 100, logging.Discard()), accountRepository, logging.Discard())
+			err := accountService.AdjustBalance(context.Background(), test.mockServiceRequest)
+
+			if test.wantMainServiceError != nil {
+				assert.ErrorIs(t, err, test.wantMainServiceError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
+				for methodName, times := range serviceCallTimes {
+					switch serviceName {
+					case "eventProducerService":
+						mockEventProducer.AssertNumberOfCalls(t, methodName, times)
+					default:
+						t.Errorf("service %s or method %s not found", serviceName, methodName)
+					}
+				}
+			}
+		})
+	}
+}
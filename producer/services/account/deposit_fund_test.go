@@ -1,15 +1,31 @@
 package accountservice
 
 import (
+	"context"
 	"errors"
+	"events"
+	"platform/logging"
+	"producer/aggregates"
 	"producer/commands"
+	"producer/internal"
 	mockService "producer/services/mock"
+	outboxservice "producer/services/outbox"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var streams = map[string][]events.Event{
+	"123": {
+		events.OpenAccountEvent{ID: "123", AccountHolder: "John Doe", AccountType: 1, OpeningBalance: 1000},
+	},
+	"456": {
+		events.OpenAccountEvent{ID: "456", AccountHolder: "Jane Doe", AccountType: 1, OpeningBalance: 1000},
+		events.CloseAccountEvent{ID: "456"},
+	},
+}
+
 func Test_accountService_DepositFund(t *testing.T) {
 	mockEventProducer := mockService.NewIEventProducer(t)
 
@@ -25,6 +41,7 @@ func Test_accountService_DepositFund(t *testing.T) {
 		wantServiceOrRepoError               error
 		wantMainServiceError                 error
 		wantMainServiceResponse              interface{}
+		wantUnsent                           int
 	}{
 		{
 			name: "Test should return error when id request is empty",
@@ -32,7 +49,7 @@ func Test_accountService_DepositFund(t *testing.T) {
 				ID:     "",
 				Amount: 1000,
 			},
-			wantMainServiceError: errors.New("bad request"),
+			wantMainServiceError: ErrInvalidCommand,
 		},
 		{
 			name: "Test should return error when amount request is zero",
@@ -40,17 +57,57 @@ func Test_accountService_DepositFund(t *testing.T) {
 				ID:     "123",
 				Amount: 0,
 			},
+			wantMainServiceError: aggregates.ErrInvalidAmount,
+		},
+		{
+			name: "Test should return error when amount request is negative",
+			mockServiceRequest: commands.DepositFundCommand{
+				ID:     "123",
+				Amount: -1000,
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventProducerService": {
+					"Produce": 0,
+				},
+			},
+			wantMainServiceError: aggregates.ErrInvalidAmount,
+		},
+		{
+			name: "Test should return error when account does not exist",
+			mockServiceRequest: commands.DepositFundCommand{
+				ID:     "999",
+				Amount: 1000,
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventProducerService": {
+					"Produce": 0,
+				},
+			},
+			wantMainServiceError: aggregates.ErrAccountNotFound,
+		},
+		{
+			name: "Test should return error when account is closed",
+			mockServiceRequest: commands.DepositFundCommand{
+				ID:     "456",
+				Amount: 1000,
+			},
+			wantServiceOrRepoCallTimes: map[string]map[string]int{
+				"eventProducerService": {
+					"Produce": 0,
+				},
+			},
+			wantMainServiceError: aggregates.ErrAccountClosed,
 		},
 		{
-			name: "Test should return error when produce of event producer service return error",
+			name: "Test should keep the event in the outbox when produce of event producer service return error",
 			mockServiceRequest: commands.DepositFundCommand{
 				ID:     "123",
 				Amount: 1000,
 			},
 			wantServiceOrRepoCallWithAndResponse: func() {
-				mockEventProducer.On("Produce", mock.Anything).Return(errors.New("error"))
+				mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(errors.New("error"))
 			},
-			wantMainServiceError: errors.New("error"),
+			wantUnsent: 1,
 		},
 		{
 			name: "Test should return nil when produce of event producer service return nil",
@@ -59,7 +116,7 @@ func Test_accountService_DepositFund(t *testing.T) {
 				Amount: 1000,
 			},
 			wantServiceOrRepoCallWithAndResponse: func() {
-				mockEventProducer.On("Produce", mock.Anything).Return(nil)
+				mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(nil)
 			},
 			wantMainServiceError: nil,
 		},
@@ -73,12 +130,19 @@ func Test_accountService_DepositFund(t *testing.T) {
 				test.wantServiceOrRepoCallWithAndResponse()
 			}
 
-			accountService := NewAccountService(mockEventProducer)
-			err := accountService.DepositFund(test.mockServiceRequest)
+			store := internal.NewEventStore(streams)
+			accountRepository := aggregates.NewAccountRepository(store, 0, logging.Discard())
+			accountService := NewAccountService(outboxservice.NewRelay(store, mockEventProducer, 100, logging.Discard()), accountRepository, logging.Discard())
+			err := accountService.DepositFund(context.Background(), test.mockServiceRequest)
 
 			if test.wantMainServiceError != nil {
-				assert.Equal(t, test.wantMainServiceError.Error(), err.Error())
+				assert.ErrorIs(t, err, test.wantMainServiceError)
+			} else {
+				assert.NoError(t, err)
 			}
+			unsent, err := store.Unsent(context.Background(), "", 100)
+			assert.NoError(t, err)
+			assert.Len(t, unsent, test.wantUnsent)
 
 			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
 				for methodName, times := range serviceCallTimes {
@@ -0,0 +1,43 @@
+package accountservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"platform/logging"
+	"producer/aggregates"
+	"producer/commands"
+	"producer/eventstore"
+	mockService "producer/services/mock"
+	outboxservice "producer/services/outbox"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_accountService_OpenAccount_log(t *testing.T) {
+	mockEventProducer := mockService.NewIEventProducer(t)
+	mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(nil)
+
+	output := &bytes.Buffer{}
+	logger, err := logging.New(output, logging.Config{})
+	assert.NoError(t, err)
+
+	ctx := logging.ContextWith(context.Background(), "request_id", "request")
+	store := eventstore.NewMemoryEventStore()
+	_, err = NewAccountService(outboxservice.NewRelay(store, mockEventProducer, 100, logger), aggregates.NewAccountRepository(store, 0, logger), logger).OpenAccount(ctx, commands.OpenAccountCommand{
+		AccountHolder:  "John Doe",
+		AccountType:    1,
+		OpeningBalance: 1000,
+	})
+	assert.NoError(t, err)
+
+	record := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &record))
+	assert.Equal(t, "producing event", record["msg"])
+	assert.Equal(t, "request", record["request_id"])
+	assert.NotEmpty(t, record["account_id"])
+	assert.Equal(t, logging.Redacted, record["event"].(map[string]interface{})["AccountHolder"])
+	assert.NotContains(t, output.String(), "John Doe")
+}
@@ -1,12 +1,18 @@
 package accountservice
 
 import (
+	"context"
 	"errors"
+	"platform/logging"
+	"producer/aggregates"
 	"producer/commands"
+	"producer/eventstore"
 	mockService "producer/services/mock"
+	outboxservice "producer/services/outbox"
 	"reflect"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -25,6 +31,7 @@ func Test_accountService_OpenAccount(t *testing.T) {
 		wantServiceOrRepoCallTimes           map[string]map[string]int
 		wantMainServiceError                 error
 		wantMainServiceResponse              string
+		wantNewID                            bool
 	}{
 		{
 			name: "Test should return error when account holder request is empty",
@@ -33,7 +40,7 @@ func Test_accountService_OpenAccount(t *testing.T) {
 				AccountType:    1,
 				OpeningBalance: 1000,
 			},
-			wantMainServiceError: errors.New("bad request"),
+			wantMainServiceError: ErrInvalidCommand,
 		},
 		{
 			name: "Test should return error when account type request is zero",
@@ -42,7 +49,7 @@ func Test_accountService_OpenAccount(t *testing.T) {
 				AccountType:    0,
 				OpeningBalance: 1000,
 			},
-			wantMainServiceError: errors.New("bad request"),
+			wantMainServiceError: ErrInvalidCommand,
 		},
 		{
 			name: "Test should return error when opening balance request is zero",
@@ -51,23 +58,33 @@ func Test_accountService_OpenAccount(t *testing.T) {
 				AccountType:    1,
 				OpeningBalance: 0,
 			},
-			wantMainServiceError: errors.New("bad request"),
+			wantMainServiceError: aggregates.ErrInvalidAmount,
 		},
 		{
-			name: "Test should return error when produce of event producer service return error",
+			name: "Test should return error when opening balance request is negative",
+			mockServiceRequest: commands.OpenAccountCommand{
+				AccountHolder:  "John Doe",
+				AccountType:    1,
+				OpeningBalance: -1000,
+			},
+			wantMainServiceError: aggregates.ErrInvalidAmount,
+		},
+		{
+			name: "Test should return account id when produce of event producer service return error",
 			mockServiceRequest: commands.OpenAccountCommand{
 				AccountHolder:  "John Doe",
 				AccountType:    1,
 				OpeningBalance: 1000,
 			},
 			wantServiceOrRepoCallWithAndResponse: func() {
-				mockEventProducer.On("Produce", mock.Anything).Return(errors.New("error"))
+				mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(errors.New("error"))
 			},
 			wantServiceOrRepoCallTimes: map[string]map[string]int{
 				"eventProducerService": {
 					"Produce": 1,
 				},
 			},
+			wantNewID: true,
 		},
 		{
 			name: "Test should return account holder when event producer service return nil",
@@ -77,14 +94,14 @@ func Test_accountService_OpenAccount(t *testing.T) {
 				OpeningBalance: 1000,
 			},
 			wantServiceOrRepoCallWithAndResponse: func() {
-				mockEventProducer.On("Produce", mock.Anything).Return(nil)
+				mockEventProducer.On("Produce", mock.Anything, mock.Anything).Return(nil)
 			},
 			wantServiceOrRepoCallTimes: map[string]map[string]int{
 				"eventProducerService": {
 					"Produce": 1,
 				},
 			},
-			wantMainServiceResponse: "John Doe",
+			wantNewID: true,
 		},
 	}
 
@@ -96,16 +113,21 @@ func Test_accountService_OpenAccount(t *testing.T) {
 				test.wantServiceOrRepoCallWithAndResponse()
 			}
 
-			accountService := NewAccountService(mockEventProducer)
-			response, err := accountService.OpenAccount(test.mockServiceRequest)
+			store := eventstore.NewMemoryEventStore()
+			accountService := NewAccountService(outboxservice.NewRelay(store, mockEventProducer, 100, logging.Discard()), aggregates.NewAccountRepository(store, 0, logging.Discard()), logging.Discard())
+			response, err := accountService.OpenAccount(context.Background(), test.mockServiceRequest)
 
 			if test.wantMainServiceError != nil {
-				assert.Equal(t, test.wantMainServiceError.Error(), err.Error())
+				assert.ErrorIs(t, err, test.wantMainServiceError)
 			}
 
 			if !reflect.DeepEqual(test.wantMainServiceResponse, "") {
 				assert.Equal(t, test.wantMainServiceResponse, response)
 			}
+			if test.wantNewID {
+				_, err := uuid.Parse(response)
+				assert.NoError(t, err)
+			}
 
 			for serviceName, serviceCallTimes := range test.wantServiceOrRepoCallTimes {
 				for methodName, times := range serviceCallTimes {
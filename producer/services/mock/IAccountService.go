@@ -3,6 +3,8 @@
 package mockService
 
 import (
+	context "context"
+
 	commands "producer/commands"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,13 +15,27 @@ type IAccountService struct {
 	mock.Mock
 }
 
-// CloseAccount provides a mock function with given fields: command
-func (_m *IAccountService) CloseAccount(command commands.CloseAccountCommand) error {
-	ret := _m.Called(command)
+// AdjustBalance provides a mock function with given fields: ctx, command
+func (_m *IAccountService) AdjustBalance(ctx context.Context, command commands.AdjustBalanceCommand) error {
+	ret := _m.Called(ctx, command)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, commands.AdjustBalanceCommand) error); ok {
+		r0 = rf(ctx, command)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CloseAccount provides a mock function with given fields: ctx, command
+func (_m *IAccountService) CloseAccount(ctx context.Context, command commands.CloseAccountCommand) error {
+	ret := _m.Called(ctx, command)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(commands.CloseAccountCommand) error); ok {
-		r0 = rf(command)
+	if rf, ok := ret.Get(0).(func(context.Context, commands.CloseAccountCommand) error); ok {
+		r0 = rf(ctx, command)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -27,13 +43,13 @@ func (_m *IAccountService) CloseAccount(command commands.CloseAccountCommand) er
 	return r0
 }
 
-// DepositFund provides a mock function with given fields: command
-func (_m *IAccountService) DepositFund(command commands.DepositFundCommand) error {
-	ret := _m.Called(command)
+// DepositFund provides a mock function with given fields: ctx, command
+func (_m *IAccountService) DepositFund(ctx context.Context, command commands.DepositFundCommand) error {
+	ret := _m.Called(ctx, command)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(commands.DepositFundCommand) error); ok {
-		r0 = rf(command)
+	if rf, ok := ret.Get(0).(func(context.Context, commands.DepositFundCommand) error); ok {
+		r0 = rf(ctx, command)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -41,23 +57,23 @@ func (_m *IAccountService) DepositFund(command commands.DepositFundCommand) erro
 	return r0
 }
 
-// OpenAccount provides a mock function with given fields: command
-func (_m *IAccountService) OpenAccount(command commands.OpenAccountCommand) (string, error) {
-	ret := _m.Called(command)
+// OpenAccount provides a mock function with given fields: ctx, command
+func (_m *IAccountService) OpenAccount(ctx context.Context, command commands.OpenAccountCommand) (string, error) {
+	ret := _m.Called(ctx, command)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(commands.OpenAccountCommand) (string, error)); ok {
-		return rf(command)
+	if rf, ok := ret.Get(0).(func(context.Context, commands.OpenAccountCommand) (string, error)); ok {
+		return rf(ctx, command)
 	}
-	if rf, ok := ret.Get(0).(func(commands.OpenAccountCommand) string); ok {
-		r0 = rf(command)
+	if rf, ok := ret.Get(0).(func(context.Context, commands.OpenAccountCommand) string); ok {
+		r0 = rf(ctx, command)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(commands.OpenAccountCommand) error); ok {
-		r1 = rf(command)
+	if rf, ok := ret.Get(1).(func(context.Context, commands.OpenAccountCommand) error); ok {
+		r1 = rf(ctx, command)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,13 +81,13 @@ func (_m *IAccountService) OpenAccount(command commands.OpenAccountCommand) (str
 	return r0, r1
 }
 
-// WithdrawFund provides a mock function with given fields: command
-func (_m *IAccountService) WithdrawFund(command commands.WithdrawFundCommand) error {
-	ret := _m.Called(command)
+// WithdrawFund provides a mock function with given fields: ctx, command
+func (_m *IAccountService) WithdrawFund(ctx context.Context, command commands.WithdrawFundCommand) error {
+	ret := _m.Called(ctx, command)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(commands.WithdrawFundCommand) error); ok {
-		r0 = rf(command)
+	if rf, ok := ret.Get(0).(func(context.Context, commands.WithdrawFundCommand) error); ok {
+		r0 = rf(ctx, command)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -3,6 +3,8 @@
 package mockService
 
 import (
+	context "context"
+
 	events "events"
 
 	mock "github.com/stretchr/testify/mock"
@@ -13,13 +15,13 @@ type IEventProducer struct {
 	mock.Mock
 }
 
-// Produce provides a mock function with given fields: event
-func (_m *IEventProducer) Produce(event events.Event) error {
-	ret := _m.Called(event)
+// Produce provides a mock function with given fields: ctx, event
+func (_m *IEventProducer) Produce(ctx context.Context, event events.Event) error {
+	ret := _m.Called(ctx, event)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(events.Event) error); ok {
-		r0 = rf(event)
+	if rf, ok := ret.Get(0).(func(context.Context, events.Event) error); ok {
+		r0 = rf(ctx, event)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,143 @@
+package outboxservice
+
+import (
+	"context"
+	"events"
+	"hash/fnv"
+	"log/slog"
+	"producer/eventstore"
+	services "producer/services/producer"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type IRelay interface {
+	// RelayStream publishes the unsent events of one stream in version
+	// order, marking each sent, and stops at the first that fails.
+	RelayStream(ctx context.Context, streamID string) error
+	// Relay publishes the unsent events of every stream, returning how many
+	// were sent. A stream whose event fails is left for the next call, and
+	// the streams after it are still published.
+	Relay(ctx context.Context) (sent int, err error)
+	// Run relays every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type relay struct {
+	store         eventstore.IEventStore
+	eventProducer services.IEventProducer
+	batchSize     int
+	logger        *slog.Logger
+	// locks serialize relaying a stream, so a request and the background
+	// loop never send its events twice or out of order.
+	locks [32]sync.Mutex
+}
+
+// NewRelay publishes the events appended to store with eventProducer,
+// reading the outbox batchSize entries or streams at a time. An event is published at
+// least once: one sent but not marked, e.g. because the process stopped in
+// between, is sent again. Only one relay may run against a database.
+func NewRelay(store eventstore.IEventStore, eventProducer services.IEventProducer, batchSize int, logger *slog.Logger) IRelay {
+	return &relay{store: store, eventProducer: eventProducer, batchSize: batchSize, logger: logger}
+}
+
+func (obj *relay) lock(streamID string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(streamID))
+	return &obj.locks[hash.Sum32()%uint32(len(obj.locks))]
+}
+
+func (obj *relay) RelayStream(ctx context.Context, streamID string) error {
+	_, err := obj.relayStream(ctx, streamID)
+	return err
+}
+
+func (obj *relay) relayStream(ctx context.Context, streamID string) (sent int, err error) {
+	lock := obj.lock(streamID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	for {
+		entries, err := obj.store.Unsent(ctx, streamID, obj.batchSize)
+		if err != nil {
+			return sent, err
+		}
+		for _, entry := range entries {
+			err = obj.publish(ctx, entry)
+			if err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(entries) < obj.batchSize {
+			return sent, nil
+		}
+	}
+}
+
+// publish sends entry as part of the request that appended it and marks it
+// sent.
+func (obj *relay) publish(ctx context.Context, entry eventstore.OutboxEntry) error {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(entry.Headers))
+	ctx = events.ContextWithMetadata(ctx, events.MetadataFromHeaders(entry.Headers))
+
+	err := obj.eventProducer.Produce(ctx, entry.Event)
+	if err != nil {
+		return err
+	}
+	return obj.store.MarkSent(ctx, entry.StreamID, entry.Version)
+}
+
+// Relay goes through the streams by ID rather than the outbox by age, so a
+// stream that keeps failing is passed over instead of filling every batch
+// ahead of the others.
+func (obj *relay) Relay(ctx context.Context) (sent int, err error) {
+	var firstErr error
+	afterStreamID := ""
+	for {
+		streamIDs, err := obj.store.UnsentStreams(ctx, afterStreamID, obj.batchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, streamID := range streamIDs {
+			streamSent, err := obj.relayStream(ctx, streamID)
+			sent += streamSent
+			if err != nil {
+				obj.logger.WarnContext(ctx, "relaying events failed", "account_id", streamID, "error", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+		if len(streamIDs) < obj.batchSize {
+			return sent, firstErr
+		}
+		afterStreamID = streamIDs[len(streamIDs)-1]
+	}
+}
+
+func (obj *relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		sent, err := obj.Relay(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			obj.logger.ErrorContext(ctx, "relaying the outbox failed", "sent", sent, "error", err)
+		} else if sent > 0 {
+			obj.logger.InfoContext(ctx, "relayed events", "sent", sent)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
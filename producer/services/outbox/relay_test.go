@@ -0,0 +1,153 @@
+package outboxservice
+
+import (
+	"context"
+	"errors"
+	"events"
+	"platform/logging"
+	"producer/eventstore"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type producedEvent struct {
+	event     events.Event
+	requestID string
+}
+
+// fakeEventProducer records the events produced, failing those of the
+// accounts in fail.
+type fakeEventProducer struct {
+	fail     map[string]bool
+	produced []producedEvent
+}
+
+func (obj *fakeEventProducer) Produce(ctx context.Context, event events.Event) error {
+	id := ""
+	switch event := event.(type) {
+	case events.OpenAccountEvent:
+		id = event.ID
+	case events.DepositFundEvent:
+		id = event.ID
+	}
+	if obj.fail[id] {
+		return errors.New("broker unavailable")
+	}
+	obj.produced = append(obj.produced, producedEvent{event, events.MetadataFromContext(ctx).RequestID})
+	return nil
+}
+
+func Test_relay(t *testing.T) {
+	ctx := context.Background()
+	requestCtx := events.ContextWithMetadata(ctx, events.Metadata{RequestID: "request"})
+	opened := func(id string) events.Event { return events.OpenAccountEvent{ID: id} }
+	deposited := func(id string) events.Event { return events.DepositFundEvent{ID: id, Amount: 100} }
+
+	newStore := func() eventstore.IEventStore {
+		store := eventstore.NewMemoryEventStore()
+		assert.NoError(t, store.Append(requestCtx, "123", 0, []events.Event{opened("123"), deposited("123")}))
+		assert.NoError(t, store.Append(ctx, "456", 0, []events.Event{opened("456")}))
+		assert.NoError(t, store.Append(ctx, "123", 2, []events.Event{deposited("123")}))
+		return store
+	}
+
+	t.Run("Test should publish every stream in order as part of the appending request", func(t *testing.T) {
+		store := newStore()
+		eventProducer := &fakeEventProducer{}
+		relay := NewRelay(store, eventProducer, 2, logging.Discard())
+
+		sent, err := relay.Relay(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, sent)
+		assert.Equal(t, []producedEvent{
+			{opened("123"), "request"},
+			{deposited("123"), "request"},
+			{deposited("123"), ""},
+			{opened("456"), ""},
+		}, eventProducer.produced)
+		unsent, err := store.Unsent(ctx, "", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, unsent)
+
+		sent, err = relay.Relay(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+	})
+
+	t.Run("Test should hold back a failed stream and publish the others", func(t *testing.T) {
+		store := newStore()
+		eventProducer := &fakeEventProducer{fail: map[string]bool{"123": true}}
+		relay := NewRelay(store, eventProducer, 10, logging.Discard())
+
+		sent, err := relay.Relay(ctx)
+
+		assert.EqualError(t, err, "broker unavailable")
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, []producedEvent{{opened("456"), ""}}, eventProducer.produced)
+
+		eventProducer.fail = nil
+		sent, err = relay.Relay(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, sent)
+		assert.Equal(t, []producedEvent{
+			{opened("456"), ""},
+			{opened("123"), "request"},
+			{deposited("123"), "request"},
+			{deposited("123"), ""},
+		}, eventProducer.produced)
+	})
+
+	t.Run("Test should publish the streams past one that keeps failing", func(t *testing.T) {
+		store := newStore()
+		assert.NoError(t, store.Append(ctx, "789", 0, []events.Event{opened("789")}))
+		eventProducer := &fakeEventProducer{fail: map[string]bool{"123": true}}
+		// the failing stream alone fills a batch of the oldest entries
+		relay := NewRelay(store, eventProducer, 1, logging.Discard())
+
+		sent, err := relay.Relay(ctx)
+
+		assert.EqualError(t, err, "broker unavailable")
+		assert.Equal(t, 2, sent)
+		assert.Equal(t, []producedEvent{{opened("456"), ""}, {opened("789"), ""}}, eventProducer.produced)
+		unsent, err := store.Unsent(ctx, "", 10)
+		assert.NoError(t, err)
+		assert.Len(t, unsent, 3)
+	})
+
+	t.Run("Test should publish only the stream asked for", func(t *testing.T) {
+		store := newStore()
+		eventProducer := &fakeEventProducer{}
+		relay := NewRelay(store, eventProducer, 10, logging.Discard())
+
+		assert.NoError(t, relay.RelayStream(ctx, "456"))
+
+		assert.Equal(t, []producedEvent{{opened("456"), ""}}, eventProducer.produced)
+		unsent, err := store.Unsent(ctx, "", 10)
+		assert.NoError(t, err)
+		assert.Len(t, unsent, 3)
+	})
+}
+
+func Test_relay_Run(t *testing.T) {
+	store := eventstore.NewMemoryEventStore()
+	assert.NoError(t, store.Append(context.Background(), "123", 0, []events.Event{events.OpenAccountEvent{ID: "123"}}))
+	eventProducer := &fakeEventProducer{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewRelay(store, eventProducer, 10, logging.Discard()).Run(ctx, time.Millisecond)
+	}()
+
+	assert.Eventually(t, func() bool {
+		unsent, err := store.Unsent(context.Background(), "", 10)
+		return err == nil && len(unsent) == 0
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	assert.Len(t, eventProducer.produced, 1)
+}
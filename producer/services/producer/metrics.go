@@ -0,0 +1,35 @@
+package eventproducerservice
+
+import (
+	"context"
+	"events"
+	"producer/metrics"
+	"reflect"
+	"time"
+)
+
+type instrumentedEventProducer struct {
+	eventProducer IEventProducer
+	metrics       *metrics.Metrics
+}
+
+// NewInstrumentedEventProducer counts the events eventProducer sends and
+// fails to send, and how long each took, by topic.
+func NewInstrumentedEventProducer(eventProducer IEventProducer, metrics *metrics.Metrics) IEventProducer {
+	return instrumentedEventProducer{eventProducer, metrics}
+}
+
+func (obj instrumentedEventProducer) Produce(ctx context.Context, event events.Event) error {
+	topic := reflect.TypeOf(event).Name()
+
+	start := time.Now()
+	err := obj.eventProducer.Produce(ctx, event)
+	obj.metrics.ProduceDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		obj.metrics.ProduceErrors.WithLabelValues(topic).Inc()
+		return err
+	}
+	obj.metrics.EventsProduced.WithLabelValues(topic).Inc()
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package eventproducerservice
+
+import (
+	"context"
+	"errors"
+	"events"
+	"producer/metrics"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_instrumentedEventProducer_Produce(t *testing.T) {
+	producerMetrics := metrics.New()
+	syncProducer := &fakeSyncProducer{}
+	eventProducer := NewInstrumentedEventProducer(NewEventProducer(syncProducer, events.JSONCodec{}), producerMetrics)
+
+	assert.NoError(t, eventProducer.Produce(context.Background(), events.DepositFundEvent{ID: "123", Amount: 1000}))
+	assert.NoError(t, eventProducer.Produce(context.Background(), events.DepositFundEvent{ID: "123", Amount: 500}))
+	syncProducer.err = errors.New("error")
+	assert.Error(t, eventProducer.Produce(context.Background(), events.CloseAccountEvent{ID: "123"}))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(producerMetrics.EventsProduced.WithLabelValues("DepositFundEvent")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(producerMetrics.ProduceErrors.WithLabelValues("DepositFundEvent")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(producerMetrics.ProduceErrors.WithLabelValues("CloseAccountEvent")))
+	assert.Equal(t, 2, testutil.CollectAndCount(producerMetrics.ProduceDuration))
+}
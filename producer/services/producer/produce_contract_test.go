@@ -0,0 +1,61 @@
+package eventproducerservice
+
+import (
+	"context"
+	"events"
+	"reflect"
+	"testing"
+
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_eventProducer_Produce_Contract(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockEvent events.Event
+	}{
+		{
+			name: "Test should produce OpenAccountEvent matching committed schema",
+			mockEvent: events.OpenAccountEvent{
+				ID:             "123",
+				AccountHolder:  "John Doe",
+				AccountType:    1,
+				OpeningBalance: 1000,
+			},
+		},
+		{
+			name:      "Test should produce DepositFundEvent matching committed schema",
+			mockEvent: events.DepositFundEvent{ID: "123", Amount: 1000},
+		},
+		{
+			name:      "Test should produce WithdrawFundEvent matching committed schema",
+			mockEvent: events.WithdrawFundEvent{ID: "123", Amount: 1000},
+		},
+		{
+			name:      "Test should produce CloseAccountEvent matching committed schema",
+			mockEvent: events.CloseAccountEvent{ID: "123"},
+		},
+		{
+			name:      "Test should produce AdjustBalanceEvent matching committed schema",
+			mockEvent: events.AdjustBalanceEvent{ID: "123", Amount: -150.5, Reason: "reconciliation"},
+		},
+	}
+
+	assert.Len(t, tests, len(events.Registry), "every event in events.Registry needs a contract case")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := events.CommittedSchema(reflect.TypeOf(test.mockEvent).Name())
+			assert.NoError(t, err)
+
+			mockProducer := mocks.NewSyncProducer(t, nil)
+			mockProducer.ExpectSendMessageWithCheckerFunctionAndSucceed(schema.Validate)
+
+			err = NewEventProducer(mockProducer, events.JSONCodec{}).Produce(context.Background(), test.mockEvent)
+
+			assert.NoError(t, err)
+			assert.NoError(t, mockProducer.Close())
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package eventproducerservice
+
+import (
+	"context"
+	"errors"
+	"events"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+	err      error
+	messages []*sarama.ProducerMessage
+}
+
+func (obj *fakeSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	obj.messages = append(obj.messages, msg)
+	return 0, 0, obj.err
+}
+
+func Test_eventProducer_Produce(t *testing.T) {
+	tests := []struct {
+		name          string
+		mockContext   context.Context
+		mockEvent     events.Event
+		mockCodec     events.Codec
+		mockSendError error
+
+		wantTopic   string
+		wantHeaders map[string]string
+		wantError   error
+	}{
+		{
+			name:          "Test should return error when send message return error",
+			mockContext:   context.Background(),
+			mockEvent:     events.DepositFundEvent{ID: "123", Amount: 1000},
+			mockCodec:     events.JSONCodec{},
+			mockSendError: errors.New("error"),
+			wantTopic:     "DepositFundEvent",
+			wantHeaders: map[string]string{
+				"event-type":     "DepositFundEvent",
+				"schema-version": "1",
+				"content-type":   events.ContentTypeJSON,
+			},
+			wantError: errors.New("error"),
+		},
+		{
+			name:        "Test should send protobuf event with protobuf content type header",
+			mockContext: context.Background(),
+			mockEvent:   events.CloseAccountEvent{ID: "123"},
+			mockCodec:   events.ProtobufCodec{},
+			wantTopic:   "CloseAccountEvent",
+			wantHeaders: map[string]string{
+				"event-type":     "CloseAccountEvent",
+				"schema-version": "1",
+				"content-type":   events.ContentTypeProtobuf,
+			},
+		},
+		{
+			name: "Test should attach request metadata from context as headers",
+			mockContext: events.ContextWithMetadata(context.Background(), events.Metadata{
+				RequestID:     "request",
+				CorrelationID: "correlation",
+				Traceparent:   "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			}),
+			mockEvent: events.DepositFundEvent{ID: "123", Amount: 1000},
+			mockCodec: events.JSONCodec{},
+			wantTopic: "DepositFundEvent",
+			wantHeaders: map[string]string{
+				"event-type":     "DepositFundEvent",
+				"schema-version": "1",
+				"content-type":   events.ContentTypeJSON,
+				"request-id":     "request",
+				"correlation-id": "correlation",
+				"traceparent":    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			producer := &fakeSyncProducer{err: test.mockSendError}
+
+			err := NewEventProducer(producer, test.mockCodec).Produce(test.mockContext, test.mockEvent)
+
+			assert.Equal(t, test.wantError, err)
+			assert.Len(t, producer.messages, 1)
+			msg := producer.messages[0]
+			assert.Equal(t, test.wantTopic, msg.Topic)
+
+			wantValue, _ := test.mockCodec.Encode(test.mockEvent)
+			value, _ := msg.Value.Encode()
+			assert.Equal(t, wantValue, value)
+
+			headers := map[string]string{}
+			for _, header := range msg.Headers {
+				headers[string(header.Key)] = string(header.Value)
+			}
+			assert.Equal(t, test.wantHeaders, headers)
+		})
+	}
+}
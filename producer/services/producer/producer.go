@@ -1,37 +1,65 @@
 package eventproducerservice
 
 import (
-	"encoding/json"
+	"context"
 	"events"
 	"reflect"
+	"strconv"
+
+	"platform/tracing"
 
 	"github.com/Shopify/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type IEventProducer interface {
-	Produce(event events.Event) error
+	Produce(ctx context.Context, event events.Event) error
 }
 
 type eventProducer struct {
 	producer sarama.SyncProducer
+	codec    events.Codec
 }
 
-func NewEventProducer(producer sarama.SyncProducer) IEventProducer {
-	return eventProducer{producer}
+func NewEventProducer(producer sarama.SyncProducer, codec events.Codec) IEventProducer {
+	return eventProducer{producer, codec}
 }
 
-func (obj eventProducer) Produce(event events.Event) error {
+func (obj eventProducer) Produce(ctx context.Context, event events.Event) (err error) {
 	topic := reflect.TypeOf(event).Name()
 
-	value, err := json.Marshal(event)
+	ctx, span := otel.Tracer("producer/services/producer").Start(ctx, topic+" send",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination", topic),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	value, err := obj.codec.Encode(event)
 	if err != nil {
 		return err
 	}
 
+	metadata := events.MetadataFromContext(ctx)
+	metadata.EventType = topic
+	metadata.SchemaVersion = strconv.Itoa(events.SchemaVersions[topic])
+	metadata.ContentType = obj.codec.ContentType()
+
+	headers := metadata.Headers()
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
 	msg := sarama.ProducerMessage{
 		Topic: topic,
 		Value: sarama.ByteEncoder(value),
 	}
+	for key, value := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
 
 	_, _, err = obj.producer.SendMessage(&msg)
 	if err != nil {